@@ -11,19 +11,50 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
 	"net/http"
 	"net/http/httptrace"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
 
 // ErrBadHandshake is returned when the server response to opening handshake is
-// invalid.
+// invalid. Dialer methods return a *BadHandshakeError for an invalid response.
+// Use errors.Is(err, ErrBadHandshake) to test for this condition.
 var ErrBadHandshake = errors.New("websocket: bad handshake")
 
-var errInvalidCompression = errors.New("websocket: invalid compression negotiation")
+// BadHandshakeError describes an invalid server response to the opening
+// handshake. The error matches ErrBadHandshake when tested with errors.Is.
+type BadHandshakeError struct {
+	// Check identifies the validation of the response that failed.
+	Check HandshakeCheck
+
+	// StatusCode is the status code of the response.
+	StatusCode int
+
+	// Response is the server's response. The response body contains the
+	// body sent by the server up to the limit set by
+	// Dialer.MaxErrorBodySize and does not need to be closed by the
+	// application.
+	Response *http.Response
+
+	// Request is the handshake request sent to the server.
+	Request *http.Request
+
+	reason string
+}
+
+func (e *BadHandshakeError) Error() string {
+	return "websocket: bad handshake: " + e.reason
+}
+
+// Is returns true if target is ErrBadHandshake.
+func (e *BadHandshakeError) Is(target error) bool {
+	return target == ErrBadHandshake
+}
 
 // NewClient creates a new client connection using the given net connection.
 // The URL u specifies the host and request URI. Use requestHeader to specify
@@ -87,6 +118,24 @@ type Dialer struct {
 	// If Proxy is nil or returns a nil *URL, no proxy is used.
 	Proxy func(*http.Request) (*url.URL, error)
 
+	// ProxyConnectHeader optionally specifies headers to send to HTTP proxies
+	// during CONNECT requests.
+	ProxyConnectHeader http.Header
+
+	// GetProxyConnectHeader optionally specifies a function to return the
+	// headers to send to proxyURL during a CONNECT request to the target
+	// host:port. If GetProxyConnectHeader returns an error, the dial fails
+	// with that error. If GetProxyConnectHeader is not nil,
+	// ProxyConnectHeader is ignored.
+	GetProxyConnectHeader func(ctx context.Context, proxyURL *url.URL, target string) (http.Header, error)
+
+	// ProxyAuthenticator responds to authentication challenges from HTTP
+	// proxies. If ProxyAuthenticator is nil, a 407 (Proxy Authentication
+	// Required) response fails the dial with a *ProxyConnectError. Basic
+	// credentials in the user information of the proxy URL are sent with
+	// the first CONNECT request regardless of this field.
+	ProxyAuthenticator ProxyAuthenticator
+
 	// TLSClientConfig specifies the TLS configuration to use with tls.Client.
 	// If nil, the default configuration is used.
 	// If NetDialTLSContext is set, Dial assumes the TLS handshake
@@ -125,6 +174,71 @@ type Dialer struct {
 	// If Jar is nil, cookies are not sent in requests and ignored
 	// in responses.
 	Jar http.CookieJar
+
+	// MaxErrorBodySize specifies the maximum number of bytes of the response
+	// body read when the handshake fails. The body is returned in the
+	// response. If zero, a default of 64 KiB is used. If negative, the body
+	// is not read and the returned response has an empty body.
+	MaxErrorBodySize int
+
+	// MaxRedirects specifies the maximum number of HTTP redirects followed
+	// during the opening handshake. If MaxRedirects is zero and CheckRedirect
+	// is nil, redirects are not followed and a redirect response is returned
+	// to the application with a *BadHandshakeError. If MaxRedirects is zero
+	// and CheckRedirect is not nil, at most 10 redirects are followed, as
+	// with http.Client.
+	MaxRedirects int
+
+	// CheckRedirect specifies the policy for following redirects. If
+	// CheckRedirect is not nil, the dialer calls it before following a
+	// redirect. The arguments req and via are the upcoming request and the
+	// requests made already, oldest first. The application can modify the
+	// header and URL of req. If CheckRedirect returns an error, the dialer
+	// returns the previous response and that error. As a special case, if
+	// CheckRedirect returns http.ErrUseLastResponse, the previous response is
+	// returned with a *BadHandshakeError. CheckRedirect is not called after
+	// the limit specified by MaxRedirects is reached.
+	//
+	// The scheme of the URL passed to CheckRedirect is http or https for
+	// the ws and wss schemes respectively. Redirects to http, https, ws and
+	// wss URLs are followed.
+	//
+	// The request header for the redirect is the header passed to Dial with
+	// the Host header removed. The Authorization, Www-Authenticate, Cookie and
+	// Cookie2 headers are also removed when redirecting to a domain that is
+	// not the original domain or a subdomain of it. Cookies in Jar are added
+	// to each request.
+	CheckRedirect func(req *http.Request, via []*http.Request) error
+
+	// NewFrameRecorder specifies a function for creating a recorder for a
+	// connection. The function is called with the handshake request after
+	// the handshake succeeds. If NewFrameRecorder is nil or returns nil,
+	// frames are not recorded.
+	NewFrameRecorder func(req *http.Request) FrameRecorder
+
+	// Metrics specifies the metrics for connections created by this dialer.
+	// If Metrics is nil, measurements are not taken.
+	Metrics Metrics
+
+	// ConnTrace specifies hooks for connections created by this dialer. The
+	// hooks are called before the hooks of a trace attached to the context
+	// passed to DialContext with WithConnTrace.
+	ConnTrace *ConnTrace
+
+	// Codecs maps subprotocols to the codecs used by the connection
+	// WriteValue and ReadValue methods. The codec for the subprotocol
+	// selected by the server, if any, is the default codec for the
+	// connection.
+	Codecs map[string]Codec
+
+	// JSONOptions specifies the options for decoding JSON messages on
+	// connections created by this dialer. See Conn.SetJSONOptions.
+	JSONOptions *JSONOptions
+
+	// Logger specifies a logger for invalid handshake responses and for
+	// connections created by this dialer. If Logger is nil, nothing is
+	// logged. See Conn.SetLogger for the records logged by connections.
+	Logger *slog.Logger
 }
 
 // Dial creates a new client connection by calling DialContext with a background context.
@@ -168,30 +282,44 @@ var nilDialer = *DefaultDialer
 //
 // The context will be used in the request and in the Dialer.
 //
-// If the WebSocket handshake fails, ErrBadHandshake is returned along with a
-// non-nil *http.Response so that callers can handle redirects, authentication,
-// etcetera. The response body may not contain the entire response and does not
-// need to be closed by the application.
+// If the WebSocket handshake fails, a *BadHandshakeError matching
+// ErrBadHandshake is returned along with a non-nil *http.Response so that
+// callers can handle redirects, authentication, etcetera. The response body
+// contains at most MaxErrorBodySize bytes and does not need to be closed by
+// the application.
+//
+// Redirect responses are followed as specified by the MaxRedirects and
+// CheckRedirect fields. The HandshakeTimeout applies to the entire sequence
+// of requests.
+//
+// The URL schemes ws+unix and wss+unix specify a connection to a Unix domain
+// socket. The URL path is the socket path, optionally followed by a colon and
+// the request path, as in ws+unix:///run/app.sock:/chat?room=1. The request
+// path defaults to "/". The Host header and the TLS server name default to
+// "localhost"; set the Host header in requestHeader to use a different
+// name. Proxies are not used and redirects are not followed for Unix domain
+// socket URLs.
 func (d *Dialer) DialContext(ctx context.Context, urlStr string, requestHeader http.Header) (*Conn, *http.Response, error) {
 	if d == nil {
 		d = &nilDialer
 	}
 
-	challengeKey, err := generateChallengeKey()
-	if err != nil {
-		return nil, nil, err
-	}
-
 	u, err := url.Parse(urlStr)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	var socketPath string
 	switch u.Scheme {
 	case "ws":
 		u.Scheme = "http"
 	case "wss":
 		u.Scheme = "https"
+	case "ws+unix", "wss+unix":
+		socketPath, err = parseUnixURL(u, requestHeader)
+		if err != nil {
+			return nil, nil, err
+		}
 	default:
 		return nil, nil, errMalformedURL
 	}
@@ -201,6 +329,93 @@ func (d *Dialer) DialContext(ctx context.Context, urlStr string, requestHeader h
 		return nil, nil, errMalformedURL
 	}
 
+	if d.HandshakeTimeout != 0 {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, d.HandshakeTimeout)
+		defer cancel()
+	}
+
+	trace := composeConnTrace(d.ConnTrace, ContextConnTrace(ctx))
+
+	var via []*http.Request
+	for {
+		conn, resp, err := d.dial(ctx, u, socketPath, requestHeader, trace)
+		if trace != nil && trace.HandshakeDone != nil {
+			trace.HandshakeDone(HandshakeDoneInfo{Conn: conn, Response: resp, Err: err})
+		}
+		var he *BadHandshakeError
+		if socketPath != "" || !d.followRedirects() || !errors.As(err, &he) || he.Check != HandshakeCheckStatus {
+			return conn, resp, err
+		}
+		loc, lerr := redirectLocation(resp)
+		if lerr != nil {
+			return nil, resp, lerr
+		}
+		if loc == nil {
+			return nil, resp, err
+		}
+
+		via = append(via, resp.Request)
+		req := &http.Request{
+			Method:     http.MethodGet,
+			URL:        loc,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     redirectHeader(requestHeader, u, loc),
+			Host:       loc.Host,
+			Response:   resp,
+		}
+		req = req.WithContext(ctx)
+		if cerr := d.checkRedirect(req, via); cerr != nil {
+			if cerr == http.ErrUseLastResponse {
+				return nil, resp, err
+			}
+			return nil, resp, cerr
+		}
+		u = req.URL
+		requestHeader = req.Header
+	}
+}
+
+// parseUnixURL converts the ws+unix or wss+unix URL u to the equivalent http
+// or https URL and returns the path of the Unix domain socket.
+func parseUnixURL(u *url.URL, requestHeader http.Header) (string, error) {
+	if u.Host != "" || u.Opaque != "" {
+		return "", errMalformedURL
+	}
+	socketPath, path, _ := strings.Cut(u.Path, ":")
+	if socketPath == "" {
+		return "", errMalformedURL
+	}
+	if path == "" {
+		path = "/"
+	} else if path[0] != '/' {
+		return "", errMalformedURL
+	}
+	if u.Scheme == "wss+unix" {
+		u.Scheme = "https"
+	} else {
+		u.Scheme = "http"
+	}
+	u.Host = "localhost"
+	if h := requestHeader.Get("Host"); h != "" {
+		u.Host = h
+	}
+	u.Path = path
+	u.RawPath = ""
+	return socketPath, nil
+}
+
+// dial performs the opening handshake with the server at u. The scheme of u
+// is http or https. If socketPath is not empty, the connection is made to the
+// Unix domain socket at socketPath without a proxy. The connTrace may be nil.
+func (d *Dialer) dial(ctx context.Context, u *url.URL, socketPath string, requestHeader http.Header, connTrace *ConnTrace) (*Conn, *http.Response, error) {
+	challengeKey, err := generateChallengeKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
 	req := &http.Request{
 		Method:     http.MethodGet,
 		URL:        u,
@@ -254,14 +469,12 @@ func (d *Dialer) DialContext(ctx context.Context, urlStr string, requestHeader h
 		req.Header["Sec-WebSocket-Extensions"] = []string{"permessage-deflate; server_no_context_takeover; client_no_context_takeover"}
 	}
 
-	if d.HandshakeTimeout != 0 {
-		var cancel func()
-		ctx, cancel = context.WithTimeout(ctx, d.HandshakeTimeout)
-		defer cancel()
+	if connTrace != nil && connTrace.HandshakeStart != nil {
+		connTrace.HandshakeStart(req)
 	}
 
 	var proxyURL *url.URL
-	if d.Proxy != nil {
+	if d.Proxy != nil && socketPath == "" {
 		proxyURL, err = d.Proxy(req)
 		if err != nil {
 			return nil, nil, err
@@ -272,13 +485,18 @@ func (d *Dialer) DialContext(ctx context.Context, urlStr string, requestHeader h
 		return nil, nil, err
 	}
 
+	network := "tcp"
 	hostPort, hostNoPort := hostPortNoPort(u)
+	if socketPath != "" {
+		network = "unix"
+		hostPort = socketPath
+	}
 	trace := httptrace.ContextClientTrace(ctx)
 	if trace != nil && trace.GetConn != nil {
 		trace.GetConn(hostPort)
 	}
 
-	netConn, err := netDial(ctx, "tcp", hostPort)
+	netConn, err := netDial(ctx, network, hostPort)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -357,40 +575,42 @@ func (d *Dialer) DialContext(ctx context.Context, urlStr string, requestHeader h
 		}
 	}
 
-	if resp.StatusCode != 101 ||
-		!tokenListContainsValue(resp.Header, "Upgrade", "websocket") ||
-		!tokenListContainsValue(resp.Header, "Connection", "upgrade") ||
-		resp.Header.Get("Sec-Websocket-Accept") != computeAcceptKey(challengeKey) {
+	compress, err := checkResponse(resp, challengeKey)
+	if err != nil {
+		if d.Logger != nil {
+			d.logHandshakeError(ctx, err.(*BadHandshakeError))
+		}
 		// Before closing the network connection on return from this
-		// function, slurp up some of the response to aid application
-		// debugging.
-		buf := make([]byte, 1024)
-		n, _ := io.ReadFull(resp.Body, buf)
-		resp.Body = io.NopCloser(bytes.NewReader(buf[:n]))
-		return nil, resp, ErrBadHandshake
+		// function, read the response body to aid application debugging.
+		var body []byte
+		if limit := d.maxErrorBodySize(); limit > 0 {
+			body, _ = io.ReadAll(io.LimitReader(resp.Body, int64(limit)))
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return nil, resp, err
 	}
 
-	for _, ext := range parseExtensions(resp.Header) {
-		if ext[""] != "permessage-deflate" {
-			continue
-		}
-		_, snct := ext["server_no_context_takeover"]
-		_, cnct := ext["client_no_context_takeover"]
-		if !snct || !cnct {
-			return nil, resp, errInvalidCompression
-		}
+	if compress {
 		conn.newCompressionWriter = compressNoContextTakeover
 		conn.newDecompressionReader = decompressNoContextTakeover
-		break
 	}
 
 	resp.Body = io.NopCloser(bytes.NewReader([]byte{}))
 	conn.subprotocol = resp.Header.Get("Sec-Websocket-Protocol")
+	conn.subprotocolCodec = d.Codecs[conn.subprotocol]
+	conn.jsonOptions = d.JSONOptions
 
 	if err := netConn.SetDeadline(time.Time{}); err != nil {
 		return nil, resp, err
 	}
 
+	if d.NewFrameRecorder != nil {
+		conn.setFrameRecorder(d.NewFrameRecorder(req))
+	}
+	conn.metrics = d.Metrics
+	conn.trace = connTrace
+	conn.SetLogger(d.Logger)
+
 	// Success! Set netConn to nil to stop the deferred function above from
 	// closing the network connection.
 	netConn = nil
@@ -398,6 +618,143 @@ func (d *Dialer) DialContext(ctx context.Context, urlStr string, requestHeader h
 	return conn, resp, nil
 }
 
+// checkResponse validates the server's response to the opening handshake and
+// returns whether compression was negotiated. The returned error is a
+// *BadHandshakeError.
+func checkResponse(resp *http.Response, challengeKey string) (compress bool, err error) {
+	fail := func(check HandshakeCheck, reason string) (bool, error) {
+		return false, &BadHandshakeError{
+			Check:      check,
+			StatusCode: resp.StatusCode,
+			Response:   resp,
+			Request:    resp.Request,
+			reason:     reason,
+		}
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return fail(HandshakeCheckStatus, "unexpected status "+strconv.Itoa(resp.StatusCode))
+	}
+	if !tokenListContainsValue(resp.Header, "Upgrade", "websocket") {
+		return fail(HandshakeCheckUpgrade, "'websocket' token not found in 'Upgrade' header")
+	}
+	if !tokenListContainsValue(resp.Header, "Connection", "upgrade") {
+		return fail(HandshakeCheckConnection, "'upgrade' token not found in 'Connection' header")
+	}
+	if resp.Header.Get("Sec-Websocket-Accept") != computeAcceptKey(challengeKey) {
+		return fail(HandshakeCheckAccept, "'Sec-WebSocket-Accept' header does not match the challenge key")
+	}
+
+	for _, ext := range parseExtensions(resp.Header) {
+		if ext[""] != "permessage-deflate" {
+			continue
+		}
+		_, snct := ext["server_no_context_takeover"]
+		_, cnct := ext["client_no_context_takeover"]
+		if !snct || !cnct {
+			return fail(HandshakeCheckExtensions, "invalid compression negotiation")
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// defaultMaxErrorBodySize is the default for Dialer.MaxErrorBodySize.
+const defaultMaxErrorBodySize = 64 << 10
+
+func (d *Dialer) maxErrorBodySize() int {
+	if d.MaxErrorBodySize == 0 {
+		return defaultMaxErrorBodySize
+	}
+	return d.MaxErrorBodySize
+}
+
+func (d *Dialer) followRedirects() bool {
+	return d.MaxRedirects > 0 || d.CheckRedirect != nil
+}
+
+// defaultMaxRedirects is the redirect limit used when CheckRedirect is set
+// and MaxRedirects is zero.
+const defaultMaxRedirects = 10
+
+func (d *Dialer) checkRedirect(req *http.Request, via []*http.Request) error {
+	limit := d.MaxRedirects
+	if limit <= 0 {
+		limit = defaultMaxRedirects
+	}
+	if len(via) > limit {
+		return fmt.Errorf("websocket: stopped after %d redirects", limit)
+	}
+	if d.CheckRedirect != nil {
+		if err := d.CheckRedirect(req, via); err != nil {
+			return err
+		}
+		if req.URL.Scheme != "http" && req.URL.Scheme != "https" || req.URL.User != nil {
+			return errMalformedURL
+		}
+	}
+	return nil
+}
+
+// redirectLocation returns the target of the redirect response resp or nil if
+// resp is not a redirect. The scheme of the returned URL is http or https.
+func redirectLocation(resp *http.Response) (*url.URL, error) {
+	switch resp.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return nil, nil
+	}
+	loc := resp.Header.Get("Location")
+	if loc == "" {
+		return nil, nil
+	}
+	u, err := resp.Request.URL.Parse(loc)
+	if err != nil {
+		return nil, fmt.Errorf("websocket: failed to parse Location header %q: %w", loc, err)
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	case "http", "https":
+	default:
+		return nil, errMalformedURL
+	}
+	if u.User != nil {
+		return nil, errMalformedURL
+	}
+	u.Fragment = ""
+	return u, nil
+}
+
+// redirectHeader returns the application request header for a redirect from
+// URL from to URL to.
+func redirectHeader(h http.Header, from, to *url.URL) http.Header {
+	h = h.Clone()
+	if h == nil {
+		h = make(http.Header)
+	}
+	delete(h, "Host")
+	if !isDomainOrSubdomain(to.Hostname(), from.Hostname()) {
+		for _, k := range []string{"Authorization", "Www-Authenticate", "Cookie", "Cookie2"} {
+			delete(h, k)
+		}
+	}
+	return h
+}
+
+// isDomainOrSubdomain returns true if sub is equal to parent or is a subdomain
+// of parent.
+func isDomainOrSubdomain(sub, parent string) bool {
+	if equalASCIIFold(sub, parent) {
+		return true
+	}
+	return len(sub) > len(parent) && sub[len(sub)-len(parent)-1] == '.' &&
+		equalASCIIFold(sub[len(sub)-len(parent):], parent)
+}
+
 // Returns the dial function to establish the connection to either the backend
 // server or the proxy (if it exists). If the dialed entity is HTTPS, then the
 // returned dial function *also* performs the TLS handshake to the dialed entity.
@@ -416,7 +773,7 @@ func (d *Dialer) netDialFn(ctx context.Context, proxyURL *url.URL, backendURL *u
 	}
 	// Proxy dialing is wrapped to implement CONNECT method and possibly proxy auth.
 	if proxyURL != nil {
-		return proxyFromURL(proxyURL, netDial)
+		return proxyFromURL(proxyURL, netDial, d)
 	}
 	return netDial, nil
 }
@@ -449,14 +806,17 @@ func (d *Dialer) netDialFromURL(u *url.URL) netDialerFunc {
 
 // Returns wrapped "netDial" function, performing TLS handshake after connecting.
 func netDialWithTLSHandshake(netDial netDialerFunc, tlsConfig *tls.Config, u *url.URL) netDialerFunc {
-	return func(ctx context.Context, unused, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
 		hostPort, hostNoPort := hostPortNoPort(u)
+		if network == "unix" {
+			hostPort = addr
+		}
 		trace := httptrace.ContextClientTrace(ctx)
 		if trace != nil && trace.GetConn != nil {
 			trace.GetConn(hostPort)
 		}
-		// Creates TCP connection to addr using passed "netDial" function.
-		conn, err := netDial(ctx, "tcp", addr)
+		// Creates the connection to addr using passed "netDial" function.
+		conn, err := netDial(ctx, network, addr)
 		if err != nil {
 			return nil, err
 		}
@@ -8,8 +8,10 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
 	"errors"
 	"io"
 	"net"
@@ -909,3 +911,214 @@ Sd1Fj0dR5YIqMRQhFNR+Y57Gf90x2cm0a2/X9GkCgYANawYx9bNfcX0HMVG7vktK
 m+VDcqT5XzcRADa/TLlEXA==
 -----END PRIVATE KEY-----
 `)
+
+// newAuthProxyServer returns a proxy server that calls authorize before
+// tunneling a CONNECT request. If authorize returns a non-zero status, the
+// proxy writes the status and header to the response.
+func newAuthProxyServer(authorize func(r *http.Request, h http.Header) int) (*httptest.Server, *url.URL) {
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if status := authorize(req, w.Header()); status != 0 {
+			w.WriteHeader(status)
+			_, _ = io.WriteString(w, "proxy error body")
+			return
+		}
+		proxyHandler.ServeHTTP(w, req)
+	}))
+	proxyURL, _ := url.Parse(proxyServer.URL)
+	return proxyServer, proxyURL
+}
+
+func TestHTTPProxyConnectHeader(t *testing.T) {
+	websocketServer, websocketURL, err := newWebsocketServer(false)
+	if err != nil {
+		t.Fatalf("error starting websocket server: %v", err)
+	}
+	defer websocketServer.Close()
+	proxyServer, proxyURL := newAuthProxyServer(func(r *http.Request, h http.Header) int {
+		if r.Header.Get("X-Proxy-Test") != "value" {
+			return http.StatusBadRequest
+		}
+		return 0
+	})
+	defer proxyServer.Close()
+
+	dialer := Dialer{
+		Proxy:              http.ProxyURL(proxyURL),
+		Subprotocols:       []string{subprotocolV1},
+		ProxyConnectHeader: http.Header{"X-Proxy-Test": {"value"}},
+	}
+	wsClient, _, err := dialer.Dial(websocketURL.String(), nil)
+	if err != nil {
+		t.Fatalf("websocket dial error: %v", err)
+	}
+	sendReceiveData(t, wsClient)
+
+	dialer.ProxyConnectHeader = nil
+	dialer.GetProxyConnectHeader = func(ctx context.Context, u *url.URL, target string) (http.Header, error) {
+		if u.String() != proxyURL.String() || target != websocketURL.Host {
+			t.Errorf("GetProxyConnectHeader(%s, %s), want %s, %s", u, target, proxyURL, websocketURL.Host)
+		}
+		return http.Header{"X-Proxy-Test": {"value"}}, nil
+	}
+	wsClient, _, err = dialer.Dial(websocketURL.String(), nil)
+	if err != nil {
+		t.Fatalf("websocket dial error: %v", err)
+	}
+	sendReceiveData(t, wsClient)
+
+	errHeader := errors.New("header error")
+	dialer.GetProxyConnectHeader = func(ctx context.Context, u *url.URL, target string) (http.Header, error) {
+		return nil, errHeader
+	}
+	if _, _, err := dialer.Dial(websocketURL.String(), nil); !errors.Is(err, errHeader) {
+		t.Errorf("Dial returned %v, want %v", err, errHeader)
+	}
+}
+
+func TestHTTPProxyConnectError(t *testing.T) {
+	proxyServer, proxyURL := newAuthProxyServer(func(r *http.Request, h http.Header) int {
+		return http.StatusForbidden
+	})
+	defer proxyServer.Close()
+
+	dialer := Dialer{Proxy: http.ProxyURL(proxyURL)}
+	_, _, err := dialer.Dial("ws://example.com/", nil)
+	var pe *ProxyConnectError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Dial returned %v, want *ProxyConnectError", err)
+	}
+	if pe.Response.StatusCode != http.StatusForbidden {
+		t.Errorf("Response.StatusCode=%d, want %d", pe.Response.StatusCode, http.StatusForbidden)
+	}
+	if body, _ := io.ReadAll(pe.Response.Body); string(body) != "proxy error body" {
+		t.Errorf("Response.Body=%q, want %q", body, "proxy error body")
+	}
+	if err.Error() != "Forbidden" {
+		t.Errorf("err.Error()=%q, want %q", err.Error(), "Forbidden")
+	}
+}
+
+func TestHTTPProxyDigestAuth(t *testing.T) {
+	websocketServer, websocketURL, err := newWebsocketServer(false)
+	if err != nil {
+		t.Fatalf("error starting websocket server: %v", err)
+	}
+	defer websocketServer.Close()
+
+	const (
+		realm    = "proxy"
+		nonce    = "dcd98b7102dd2f0e8b11d0f600bfb0c093"
+		username = "Mufasa"
+		password = "Circle Of Life"
+	)
+	var challenges int32
+	proxyServer, proxyURL := newAuthProxyServer(func(r *http.Request, h http.Header) int {
+		if verifyDigest(r, username, password, realm, nonce) {
+			return 0
+		}
+		atomic.AddInt32(&challenges, 1)
+		h.Set("Proxy-Authenticate", `Digest realm="`+realm+`", qop="auth,auth-int", algorithm=SHA-256, nonce="`+nonce+`", opaque="xyz"`)
+		return http.StatusProxyAuthRequired
+	})
+	defer proxyServer.Close()
+
+	dialer := Dialer{
+		Proxy:              http.ProxyURL(proxyURL),
+		Subprotocols:       []string{subprotocolV1},
+		ProxyAuthenticator: &DigestProxyAuth{Username: username, Password: password},
+	}
+	wsClient, _, err := dialer.Dial(websocketURL.String(), nil)
+	if err != nil {
+		t.Fatalf("websocket dial error: %v", err)
+	}
+	sendReceiveData(t, wsClient)
+	if n := atomic.LoadInt32(&challenges); n != 1 {
+		t.Errorf("challenges=%d, want 1", n)
+	}
+
+	// Wrong password.
+	dialer.ProxyAuthenticator = &DigestProxyAuth{Username: username, Password: "wrong"}
+	_, _, err = dialer.Dial(websocketURL.String(), nil)
+	var pe *ProxyConnectError
+	if !errors.As(err, &pe) || pe.Response.StatusCode != http.StatusProxyAuthRequired {
+		t.Errorf("Dial with wrong password returned %v, want *ProxyConnectError with status 407", err)
+	}
+}
+
+// verifyDigest returns true if the request has valid digest credentials.
+func verifyDigest(r *http.Request, username, password, realm, nonce string) bool {
+	v := r.Header.Get("Proxy-Authorization")
+	challenges := parseAuthChallenges([]string{v})
+	if len(challenges) != 1 || challenges[0].scheme != "Digest" {
+		return false
+	}
+	p := challenges[0].params
+	if p["username"] != username || p["realm"] != realm || p["nonce"] != nonce || p["opaque"] != "xyz" ||
+		p["uri"] != r.RequestURI || p["qop"] != "auth" || p["algorithm"] != "SHA-256" {
+		return false
+	}
+	h := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	ha1 := h(username + ":" + realm + ":" + password)
+	ha2 := h(r.Method + ":" + p["uri"])
+	return p["response"] == h(ha1+":"+nonce+":"+p["nc"]+":"+p["cnonce"]+":auth:"+ha2)
+}
+
+// negotiateAuth is a ProxyAuthenticator for a two leg challenge-response
+// scheme similar to Negotiate.
+type negotiateAuth struct{}
+
+func (negotiateAuth) Authorization(req *http.Request, resp *http.Response) (string, error) {
+	for _, c := range parseAuthChallenges(resp.Header["Proxy-Authenticate"]) {
+		if c.scheme != "Negotiate" {
+			continue
+		}
+		switch c.token68 {
+		case "":
+			return "Negotiate bGVnMQ==", nil
+		case "Y2hhbGxlbmdl":
+			return "Negotiate bGVnMg==", nil
+		}
+	}
+	return "", nil
+}
+
+func TestHTTPProxyCustomAuth(t *testing.T) {
+	websocketServer, websocketURL, err := newWebsocketServer(false)
+	if err != nil {
+		t.Fatalf("error starting websocket server: %v", err)
+	}
+	defer websocketServer.Close()
+
+	var remoteAddrs []string
+	proxyServer, proxyURL := newAuthProxyServer(func(r *http.Request, h http.Header) int {
+		remoteAddrs = append(remoteAddrs, r.RemoteAddr)
+		switch r.Header.Get("Proxy-Authorization") {
+		case "Negotiate bGVnMg==":
+			return 0
+		case "Negotiate bGVnMQ==":
+			h.Set("Proxy-Authenticate", "Negotiate Y2hhbGxlbmdl")
+		default:
+			h.Add("Proxy-Authenticate", `Basic realm="proxy"`)
+			h.Add("Proxy-Authenticate", "Negotiate")
+		}
+		return http.StatusProxyAuthRequired
+	})
+	defer proxyServer.Close()
+
+	dialer := Dialer{
+		Proxy:              http.ProxyURL(proxyURL),
+		Subprotocols:       []string{subprotocolV1},
+		ProxyAuthenticator: negotiateAuth{},
+	}
+	wsClient, _, err := dialer.Dial(websocketURL.String(), nil)
+	if err != nil {
+		t.Fatalf("websocket dial error: %v", err)
+	}
+	sendReceiveData(t, wsClient)
+	if len(remoteAddrs) != 3 || remoteAddrs[0] != remoteAddrs[1] || remoteAddrs[1] != remoteAddrs[2] {
+		t.Errorf("remote addresses %v, want three requests on one connection", remoteAddrs)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"net/http/httptest"
 	"net/http/httptrace"
 	"net/url"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"sync"
@@ -592,6 +593,11 @@ func TestRespOnBadHandshake(t *testing.T) {
 		t.Fatalf("resp=nil, err=%v", err)
 	}
 
+	var he *BadHandshakeError
+	if !errors.As(err, &he) || he.Check != HandshakeCheckStatus || he.StatusCode != expectedStatus || he.Response != resp {
+		t.Errorf("err=%#v, want *BadHandshakeError with status %d", err, expectedStatus)
+	}
+
 	if resp.StatusCode != expectedStatus {
 		t.Errorf("resp.StatusCode=%d, want %d", resp.StatusCode, expectedStatus)
 	}
@@ -606,6 +612,248 @@ func TestRespOnBadHandshake(t *testing.T) {
 	}
 }
 
+func TestRespOnBadHandshakeBodySize(t *testing.T) {
+	body := strings.Repeat("x", 10000)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = io.WriteString(w, body)
+	}))
+	defer s.Close()
+
+	for _, tt := range []struct {
+		max  int
+		want int
+	}{
+		{0, len(body)},
+		{100, 100},
+		{-1, 0},
+	} {
+		d := cstDialer
+		d.MaxErrorBodySize = tt.max
+		_, resp, err := d.Dial(makeWsProto(s.URL), nil)
+		if !errors.Is(err, ErrBadHandshake) || resp == nil {
+			t.Fatalf("MaxErrorBodySize %d: err=%v, resp=%v, want bad handshake with response", tt.max, err, resp)
+		}
+		p, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p) != tt.want {
+			t.Errorf("MaxErrorBodySize %d: len(body)=%d, want %d", tt.max, len(p), tt.want)
+		}
+	}
+}
+
+func TestDialRedirect(t *testing.T) {
+	s := newServer(t)
+	defer s.Close()
+
+	target := s.Server.URL // http scheme, mapped to ws by the dialer.
+	var hops int
+	rs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hops++
+		switch r.URL.Path {
+		case "/first":
+			http.Redirect(w, r, "/second", http.StatusFound)
+		case "/second":
+			if r.Header.Get("X-Test") != "value" {
+				t.Errorf("X-Test=%q, want value", r.Header.Get("X-Test"))
+			}
+			w.Header().Set("Location", target)
+			w.WriteHeader(http.StatusPermanentRedirect)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer rs.Close()
+
+	d := cstDialer
+	d.MaxRedirects = 2
+	ws, resp, err := d.Dial(makeWsProto(rs.URL)+"/first", http.Header{"X-Test": {"value"}})
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer ws.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("resp.StatusCode=%d, want %d", resp.StatusCode, http.StatusSwitchingProtocols)
+	}
+	if hops != 2 {
+		t.Errorf("hops=%d, want 2", hops)
+	}
+	sendRecv(t, ws)
+
+	// Exceed the redirect limit.
+	d.MaxRedirects = 1
+	ws, resp, err = d.Dial(makeWsProto(rs.URL)+"/first", http.Header{"X-Test": {"value"}})
+	if err == nil {
+		ws.Close()
+		t.Fatal("Dial with exceeded redirect limit returned nil error")
+	}
+	if resp == nil || resp.StatusCode != http.StatusPermanentRedirect {
+		t.Errorf("resp=%v, want response with status %d", resp, http.StatusPermanentRedirect)
+	}
+}
+
+func TestDialRedirectNotFollowed(t *testing.T) {
+	s := httptest.NewServer(http.RedirectHandler("ws://example.com/", http.StatusFound))
+	defer s.Close()
+
+	_, resp, err := cstDialer.Dial(makeWsProto(s.URL), nil)
+	if !errors.Is(err, ErrBadHandshake) {
+		t.Errorf("err=%v, want %v", err, ErrBadHandshake)
+	}
+	if resp == nil || resp.StatusCode != http.StatusFound {
+		t.Fatalf("resp=%v, want response with status %d", resp, http.StatusFound)
+	}
+	if loc := resp.Header.Get("Location"); loc != "ws://example.com/" {
+		t.Errorf("Location=%q, want ws://example.com/", loc)
+	}
+}
+
+func TestDialCheckRedirect(t *testing.T) {
+	s := newServer(t)
+	defer s.Close()
+
+	rs := httptest.NewServer(http.RedirectHandler(s.URL, http.StatusTemporaryRedirect))
+	defer rs.Close()
+
+	d := cstDialer
+	var called bool
+	d.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		called = true
+		if len(via) != 1 {
+			t.Errorf("len(via)=%d, want 1", len(via))
+		}
+		if req.URL.Scheme != "http" {
+			t.Errorf("req.URL.Scheme=%s, want http", req.URL.Scheme)
+		}
+		if req.Response == nil || req.Response.StatusCode != http.StatusTemporaryRedirect {
+			t.Errorf("req.Response=%v, want redirect response", req.Response)
+		}
+		return nil
+	}
+	ws, _, err := d.Dial(makeWsProto(rs.URL), nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer ws.Close()
+	if !called {
+		t.Error("CheckRedirect not called")
+	}
+	sendRecv(t, ws)
+
+	d.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	_, resp, err := d.Dial(makeWsProto(rs.URL), nil)
+	if !errors.Is(err, ErrBadHandshake) {
+		t.Errorf("err=%v, want %v", err, ErrBadHandshake)
+	}
+	if resp == nil || resp.StatusCode != http.StatusTemporaryRedirect {
+		t.Errorf("resp=%v, want response with status %d", resp, http.StatusTemporaryRedirect)
+	}
+
+	errCheck := errors.New("check failed")
+	d.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return errCheck
+	}
+	if _, _, err := d.Dial(makeWsProto(rs.URL), nil); err != errCheck {
+		t.Errorf("err=%v, want %v", err, errCheck)
+	}
+}
+
+func TestDialCheckRedirectLimit(t *testing.T) {
+	var hops int
+	rs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hops++
+		http.Redirect(w, r, "/", http.StatusFound)
+	}))
+	defer rs.Close()
+
+	// A redirect loop stops after the default limit when CheckRedirect is
+	// set and MaxRedirects is zero.
+	d := cstDialer
+	var calls int
+	d.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		calls++
+		return nil
+	}
+	_, resp, err := d.Dial(makeWsProto(rs.URL), nil)
+	if err == nil || !strings.Contains(err.Error(), "stopped after 10 redirects") {
+		t.Errorf("err=%v, want redirect limit error", err)
+	}
+	if resp == nil || resp.StatusCode != http.StatusFound {
+		t.Errorf("resp=%v, want response with status %d", resp, http.StatusFound)
+	}
+	if calls != 10 || hops != 11 {
+		t.Errorf("calls=%d, hops=%d, want 10 and 11", calls, hops)
+	}
+}
+
+func TestDialRedirectCookieJar(t *testing.T) {
+	s := newServer(t)
+	defer s.Close()
+
+	rs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "redirect", Value: "1", Path: "/"})
+		http.Redirect(w, r, s.Server.URL, http.StatusFound)
+	}))
+	defer rs.Close()
+
+	jar, _ := cookiejar.New(nil)
+	d := cstDialer
+	d.Jar = jar
+	d.MaxRedirects = 1
+	ws, _, err := d.Dial(makeWsProto(rs.URL), nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer ws.Close()
+
+	u, _ := url.Parse(rs.URL)
+	if cookies := jar.Cookies(u); len(cookies) != 1 || cookies[0].Value != "1" {
+		t.Errorf("jar.Cookies(%s)=%v, want cookie set by redirect response", u, cookies)
+	}
+}
+
+func TestRedirectHeader(t *testing.T) {
+	h := http.Header{
+		"Host":          {"example.com"},
+		"Authorization": {"secret"},
+		"Cookie":        {"a=b"},
+		"X-Test":        {"value"},
+	}
+	tests := []struct {
+		from, to  string
+		sensitive bool
+	}{
+		{"http://example.com/", "http://example.com:8080/", true},
+		{"http://example.com/", "https://sub.example.com/", true},
+		{"http://EXAMPLE.com/", "http://sub.example.COM/", true},
+		{"http://example.com/", "http://notexample.com/", false},
+		{"http://sub.example.com/", "http://example.com/", false},
+	}
+	for _, tt := range tests {
+		from, _ := url.Parse(tt.from)
+		to, _ := url.Parse(tt.to)
+		got := redirectHeader(h, from, to)
+		if _, ok := got["Host"]; ok {
+			t.Errorf("redirectHeader(%s, %s) contains Host", tt.from, tt.to)
+		}
+		if got.Get("X-Test") != "value" {
+			t.Errorf("redirectHeader(%s, %s) does not contain X-Test", tt.from, tt.to)
+		}
+		_, auth := got["Authorization"]
+		_, cookie := got["Cookie"]
+		if auth != tt.sensitive || cookie != tt.sensitive {
+			t.Errorf("redirectHeader(%s, %s) has Authorization=%v, Cookie=%v, want %v", tt.from, tt.to, auth, cookie, tt.sensitive)
+		}
+	}
+	if _, ok := h["Host"]; !ok {
+		t.Error("redirectHeader modified the original header")
+	}
+}
+
 type testLogWriter struct {
 	t *testing.T
 }
@@ -1243,3 +1491,54 @@ func TestDataReceivedBeforeHandshake(t *testing.T) {
 		})
 	}
 }
+
+func TestDialUnix(t *testing.T) {
+	for _, useTLS := range []bool{false, true} {
+		socketPath := filepath.Join(t.TempDir(), "ws.sock")
+		l, err := net.Listen("unix", socketPath)
+		if err != nil {
+			t.Skipf("unix sockets not supported: %v", err)
+		}
+
+		var s cstServer
+		var host string
+		handler := cstHandler{T: t, s: &s}
+		s.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host = r.Host
+			handler.ServeHTTP(w, r)
+		}))
+		s.Server.Listener.Close()
+		s.Server.Listener = l
+		scheme := "ws+unix://"
+		if useTLS {
+			s.Server.StartTLS()
+			scheme = "wss+unix://"
+		} else {
+			s.Server.Start()
+		}
+
+		d := cstDialer
+		d.Proxy = func(*http.Request) (*url.URL, error) {
+			t.Error("Proxy called for Unix domain socket URL")
+			return nil, nil
+		}
+		var header http.Header
+		wantHost := "localhost"
+		if useTLS {
+			// The test certificate is valid for example.com.
+			d.TLSClientConfig = &tls.Config{RootCAs: rootCAs(t, s.Server)}
+			header = http.Header{"Host": {"example.com"}}
+			wantHost = "example.com"
+		}
+		ws, _, err := d.Dial(scheme+socketPath+":"+cstRequestURI, header)
+		if err != nil {
+			t.Fatalf("Dial(tls=%v): %v", useTLS, err)
+		}
+		sendRecv(t, ws)
+		ws.Close()
+		if host != wantHost {
+			t.Errorf("Host=%q, want %q", host, wantHost)
+		}
+		s.Close()
+	}
+}
@@ -5,6 +5,8 @@
 package websocket
 
 import (
+	"errors"
+	"net/http"
 	"net/url"
 	"testing"
 )
@@ -30,3 +32,85 @@ func TestHostPortNoPort(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckResponse(t *testing.T) {
+	const challengeKey = "dGhlIHNhbXBsZSBub25jZQ=="
+	accept := computeAcceptKey(challengeKey)
+	tests := []struct {
+		status   int
+		header   http.Header
+		check    HandshakeCheck
+		compress bool
+	}{
+		{http.StatusSwitchingProtocols, http.Header{"Upgrade": {"websocket"}, "Connection": {"Upgrade"}, "Sec-Websocket-Accept": {accept}}, HandshakeCheckNone, false},
+		{http.StatusSwitchingProtocols, http.Header{"Upgrade": {"websocket"}, "Connection": {"Upgrade"}, "Sec-Websocket-Accept": {accept},
+			"Sec-Websocket-Extensions": {"permessage-deflate; server_no_context_takeover; client_no_context_takeover"}}, HandshakeCheckNone, true},
+		{http.StatusForbidden, http.Header{}, HandshakeCheckStatus, false},
+		{http.StatusSwitchingProtocols, http.Header{"Connection": {"Upgrade"}, "Sec-Websocket-Accept": {accept}}, HandshakeCheckUpgrade, false},
+		{http.StatusSwitchingProtocols, http.Header{"Upgrade": {"websocket"}, "Sec-Websocket-Accept": {accept}}, HandshakeCheckConnection, false},
+		{http.StatusSwitchingProtocols, http.Header{"Upgrade": {"websocket"}, "Connection": {"Upgrade"}, "Sec-Websocket-Accept": {"bad"}}, HandshakeCheckAccept, false},
+		{http.StatusSwitchingProtocols, http.Header{"Upgrade": {"websocket"}, "Connection": {"Upgrade"}, "Sec-Websocket-Accept": {accept},
+			"Sec-Websocket-Extensions": {"permessage-deflate"}}, HandshakeCheckExtensions, false},
+	}
+	for _, tt := range tests {
+		req := &http.Request{Method: http.MethodGet}
+		resp := &http.Response{StatusCode: tt.status, Header: tt.header, Request: req}
+		compress, err := checkResponse(resp, challengeKey)
+		if tt.check == HandshakeCheckNone {
+			if err != nil || compress != tt.compress {
+				t.Errorf("checkResponse(%d, %v) = %v, %v, want %v, nil", tt.status, tt.header, compress, err, tt.compress)
+			}
+			continue
+		}
+		var he *BadHandshakeError
+		if !errors.As(err, &he) {
+			t.Errorf("checkResponse(%d, %v) returned %v, want *BadHandshakeError", tt.status, tt.header, err)
+			continue
+		}
+		if !errors.Is(err, ErrBadHandshake) {
+			t.Errorf("checkResponse(%d, %v) returned %v, want error matching ErrBadHandshake", tt.status, tt.header, err)
+		}
+		if he.Check != tt.check || he.StatusCode != tt.status || he.Response != resp || he.Request != req {
+			t.Errorf("checkResponse(%d, %v) returned %+v, want check %v", tt.status, tt.header, he, tt.check)
+		}
+	}
+}
+
+var parseUnixURLTests = []struct {
+	s          string
+	header     http.Header
+	socketPath string
+	u          string
+}{
+	{"ws+unix:///tmp/ws.sock", nil, "/tmp/ws.sock", "http://localhost/"},
+	{"wss+unix:///tmp/ws.sock:/a/b?x=y", nil, "/tmp/ws.sock", "https://localhost/a/b?x=y"},
+	{"ws+unix:///tmp/ws.sock:/", http.Header{"Host": {"api.example.com"}}, "/tmp/ws.sock", "http://api.example.com/"},
+	{"ws+unix:///tmp/a%20b.sock:/c%2Fd", nil, "/tmp/a b.sock", "http://localhost/c/d"},
+	{"ws+unix://host/tmp/ws.sock", nil, "", ""},
+	{"ws+unix:///tmp/ws.sock:path", nil, "", ""},
+	{"ws+unix://", nil, "", ""},
+	{"ws+unix:tmp/ws.sock", nil, "", ""},
+}
+
+func TestParseUnixURL(t *testing.T) {
+	for _, tt := range parseUnixURLTests {
+		u, err := url.Parse(tt.s)
+		if err != nil {
+			t.Fatalf("url.Parse(%q) returned %v", tt.s, err)
+		}
+		socketPath, err := parseUnixURL(u, tt.header)
+		if tt.socketPath == "" {
+			if err == nil {
+				t.Errorf("parseUnixURL(%q) did not return error", tt.s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseUnixURL(%q) returned %v", tt.s, err)
+			continue
+		}
+		if socketPath != tt.socketPath || u.String() != tt.u {
+			t.Errorf("parseUnixURL(%q) = %q, %q, want %q, %q", tt.s, socketPath, u, tt.socketPath, tt.u)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+)
+
+// Codec encodes and decodes values as WebSocket messages.
+//
+// Codecs are used by the connection WriteValue and ReadValue methods. A
+// codec must be safe for concurrent use by multiple connections.
+type Codec interface {
+	// MessageType returns the type of the messages written by the codec,
+	// TextMessage or BinaryMessage.
+	MessageType() int
+
+	// Marshal writes the encoding of v to the message writer w.
+	Marshal(w io.Writer, v interface{}) error
+
+	// Unmarshal decodes the message read from r and stores the result in
+	// the value pointed to by v.
+	Unmarshal(r io.Reader, v interface{}) error
+}
+
+// Built-in codecs.
+var (
+	// JSONCodec encodes values as JSON in text messages. See the
+	// documentation for the encoding/json package for details about the
+	// conversion of Go values to JSON.
+	JSONCodec Codec = jsonCodec{}
+
+	// GobCodec encodes values with the encoding/gob package in binary
+	// messages. Each message is a self-contained gob stream that includes
+	// the type information for the value.
+	GobCodec Codec = gobCodec{}
+
+	// XMLCodec encodes values as XML in text messages. See the
+	// documentation for the encoding/xml package for details about the
+	// conversion of Go values to XML.
+	XMLCodec Codec = xmlCodec{}
+)
+
+type jsonCodec struct {
+	opts *JSONOptions // decoding options, may be nil
+}
+
+// NewJSONCodec returns a JSON codec that decodes messages as specified by
+// opts. If opts is nil, the codec is equivalent to JSONCodec.
+func NewJSONCodec(opts *JSONOptions) Codec {
+	return jsonCodec{opts: opts}
+}
+
+func (jsonCodec) MessageType() int { return TextMessage }
+
+func (jsonCodec) Marshal(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (c jsonCodec) Unmarshal(r io.Reader, v interface{}) error {
+	return decodeJSON(r, v, c.opts)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) MessageType() int { return BinaryMessage }
+
+func (gobCodec) Marshal(w io.Writer, v interface{}) error {
+	return gob.NewEncoder(w).Encode(v)
+}
+
+func (gobCodec) Unmarshal(r io.Reader, v interface{}) error {
+	return gob.NewDecoder(r).Decode(v)
+}
+
+type xmlCodec struct{}
+
+func (xmlCodec) MessageType() int { return TextMessage }
+
+func (xmlCodec) Marshal(w io.Writer, v interface{}) error {
+	return xml.NewEncoder(w).Encode(v)
+}
+
+func (xmlCodec) Unmarshal(r io.Reader, v interface{}) error {
+	return xml.NewDecoder(r).Decode(v)
+}
+
+// SetCodec sets the codec used by WriteValue and ReadValue. If codec is nil,
+// the connection uses the codec selected during the handshake or JSONCodec.
+func (c *Conn) SetCodec(codec Codec) {
+	c.codec = codec
+}
+
+// Codec returns the codec used by WriteValue and ReadValue. The codec is the
+// codec set with SetCodec, the codec for the negotiated subprotocol in the
+// Codecs field of the Upgrader or Dialer, or JSONCodec, in that order. If the
+// codec is JSONCodec, the returned codec decodes messages as specified by the
+// connection's JSON options.
+func (c *Conn) Codec() Codec {
+	codec := JSONCodec
+	switch {
+	case c.codec != nil:
+		codec = c.codec
+	case c.subprotocolCodec != nil:
+		codec = c.subprotocolCodec
+	}
+	if codec == JSONCodec {
+		return c.jsonCodec()
+	}
+	return codec
+}
+
+// WriteValue writes the encoding of v as a message using the connection's
+// codec. The message type is the codec's message type.
+func (c *Conn) WriteValue(v interface{}) error {
+	return c.writeValue(c.Codec(), v)
+}
+
+// ReadValue reads the next message from the connection and decodes it with
+// the connection's codec into the value pointed to by v.
+func (c *Conn) ReadValue(v interface{}) error {
+	return c.readValue(c.Codec(), v)
+}
+
+func (c *Conn) writeValue(codec Codec, v interface{}) error {
+	w, err := c.NextWriter(codec.MessageType())
+	if err != nil {
+		return err
+	}
+	err1 := codec.Marshal(w, v)
+	err2 := w.Close()
+	if err1 != nil {
+		return err1
+	}
+	return err2
+}
+
+func (c *Conn) readValue(codec Codec, v interface{}) error {
+	_, r, err := c.NextReader()
+	if err != nil {
+		return err
+	}
+	err = codec.Unmarshal(r, v)
+	if err == io.EOF {
+		// One value is expected in the message.
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type codecTestValue struct {
+	A int
+	B string
+}
+
+func TestCodecs(t *testing.T) {
+	tests := []struct {
+		name        string
+		codec       Codec
+		messageType int
+	}{
+		{"json", JSONCodec, TextMessage},
+		{"gob", GobCodec, BinaryMessage},
+		{"xml", XMLCodec, TextMessage},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		wc := newTestConn(nil, &buf, true)
+		rc := newTestConn(&buf, nil, false)
+		wc.SetCodec(tt.codec)
+		rc.SetCodec(tt.codec)
+
+		expect := codecTestValue{A: 1, B: "hello"}
+		if err := wc.WriteValue(&expect); err != nil {
+			t.Fatalf("%s: WriteValue() returned error %v", tt.name, err)
+		}
+		if err := wc.WriteValue(&expect); err != nil {
+			t.Fatalf("%s: WriteValue() returned error %v", tt.name, err)
+		}
+
+		var actual codecTestValue
+		if err := rc.ReadValue(&actual); err != nil {
+			t.Fatalf("%s: ReadValue() returned error %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(actual, expect) {
+			t.Errorf("%s: ReadValue() = %+v, want %+v", tt.name, actual, expect)
+		}
+
+		messageType, _, err := rc.ReadMessage()
+		if err != nil || messageType != tt.messageType {
+			t.Errorf("%s: message type = %d, %v, want %d", tt.name, messageType, err, tt.messageType)
+		}
+	}
+}
+
+func TestCodecEmptyMessage(t *testing.T) {
+	for _, codec := range []Codec{JSONCodec, GobCodec} {
+		var buf bytes.Buffer
+		wc := newTestConn(nil, &buf, true)
+		rc := newTestConn(&buf, nil, false)
+		rc.SetCodec(codec)
+		_ = wc.WriteMessage(codec.MessageType(), nil)
+
+		var v codecTestValue
+		if err := rc.ReadValue(&v); err != io.ErrUnexpectedEOF {
+			t.Errorf("%T: ReadValue() returned %v, want %v", codec, err, io.ErrUnexpectedEOF)
+		}
+	}
+}
+
+func TestCodecSubprotocol(t *testing.T) {
+	codecs := map[string]Codec{"gob": GobCodec, "xml": XMLCodec}
+	upgrader := Upgrader{Subprotocols: []string{"gob", "xml"}, Codecs: codecs}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		var v codecTestValue
+		if err := c.ReadValue(&v); err != nil {
+			return
+		}
+		_ = c.WriteValue(&v)
+	}))
+	defer s.Close()
+
+	tests := []struct {
+		subprotocols []string
+		codec        Codec
+	}{
+		{[]string{"gob"}, GobCodec},
+		{[]string{"xml"}, XMLCodec},
+		{[]string{"other"}, JSONCodec},
+		{nil, JSONCodec},
+	}
+	for _, tt := range tests {
+		d := Dialer{Subprotocols: tt.subprotocols, Codecs: codecs}
+		c, _, err := d.Dial(makeWsProto(s.URL), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Codec() != tt.codec {
+			t.Errorf("%v: Codec() = %T, want %T", tt.subprotocols, c.Codec(), tt.codec)
+		}
+		expect := codecTestValue{A: 2, B: "world"}
+		var actual codecTestValue
+		if err := c.WriteValue(&expect); err != nil {
+			t.Fatalf("%v: WriteValue() returned error %v", tt.subprotocols, err)
+		}
+		if err := c.ReadValue(&actual); err != nil {
+			t.Fatalf("%v: ReadValue() returned error %v", tt.subprotocols, err)
+		}
+		if actual != expect {
+			t.Errorf("%v: ReadValue() = %+v, want %+v", tt.subprotocols, actual, expect)
+		}
+
+		c.SetCodec(XMLCodec)
+		if c.Codec() != XMLCodec {
+			t.Errorf("%v: Codec() = %T after SetCodec, want %T", tt.subprotocols, c.Codec(), XMLCodec)
+		}
+		c.Close()
+	}
+}
@@ -0,0 +1,445 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package conformance
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Case categories. The categories and the numbering of cases follow the
+// Autobahn test suite.
+const (
+	Framing       = "Framing"
+	PingPong      = "Pings and pongs"
+	ReservedBits  = "Reserved bits"
+	Opcodes       = "Opcodes"
+	Fragmentation = "Fragmentation"
+	UTF8          = "UTF-8 handling"
+	Close         = "Close handling"
+	Limits        = "Limits"
+	Compression   = "Compression"
+)
+
+// Close codes expected when the implementation fails the connection.
+const (
+	codeProtocolError      = 1002
+	codeInvalidPayloadData = 1007
+)
+
+// step is one step of a case.
+type step func(p *peer) error
+
+func seq(steps ...step) step {
+	return func(p *peer) error {
+		for _, s := range steps {
+			if err := s(p); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func send(frames ...frame) step {
+	return func(p *peer) error { return p.send(frames...) }
+}
+
+func sendChopped(n int, frames ...frame) step {
+	return func(p *peer) error { return p.sendChopped(n, frames...) }
+}
+
+func expectMessage(opcode int, payload []byte) step {
+	return func(p *peer) error { return p.expectMessage(opcode, payload) }
+}
+
+func expectText(s string) step { return expectMessage(opText, []byte(s)) }
+
+func expectPong(payload []byte) step {
+	return func(p *peer) error { return p.expectPong(payload) }
+}
+
+func closeHandshake(code int) step {
+	return func(p *peer) error { return p.closeHandshake(code) }
+}
+
+func expectClose(code int) step {
+	return func(p *peer) error { return p.expectClose(code) }
+}
+
+func expectFail(codes ...int) step {
+	return func(p *peer) error { return p.expectFail(codes...) }
+}
+
+// echo sends a message in a single frame and expects it back.
+func echo(opcode int, payload []byte) step {
+	return seq(send(msg(opcode, payload)), expectMessage(opcode, payload))
+}
+
+// Frame constructors.
+
+func msg(opcode int, payload []byte) frame {
+	return frame{fin: true, opcode: opcode, payload: payload}
+}
+
+func text(s string) frame { return msg(opText, []byte(s)) }
+
+func fragment(opcode int, fin bool, s string) frame {
+	return frame{fin: fin, opcode: opcode, payload: []byte(s)}
+}
+
+func ping(payload []byte) frame { return msg(opPing, payload) }
+
+func pong(payload []byte) frame { return msg(opPong, payload) }
+
+func closeFrame(payload []byte) frame { return msg(opClose, payload) }
+
+func compressed(opcode int, payload []byte) frame {
+	return frame{fin: true, rsv: rsv1Bit, opcode: opcode, payload: deflate(payload)}
+}
+
+// Payload generators.
+
+func textPayload(n int) []byte {
+	return []byte(strings.Repeat("*", n))
+}
+
+func binaryPayload(n int) []byte {
+	p := make([]byte, n)
+	rand.New(rand.NewSource(int64(n))).Read(p)
+	return p
+}
+
+func codePayload(code int) []byte {
+	return binary.BigEndian.AppendUint16(nil, uint16(code))
+}
+
+// fragments splits payload into frames of at most n bytes.
+func fragments(opcode int, payload []byte, n int) []frame {
+	var frames []frame
+	for {
+		m := n
+		if m > len(payload) {
+			m = len(payload)
+		}
+		frames = append(frames, frame{fin: m == len(payload), opcode: opcode, payload: payload[:m]})
+		payload = payload[m:]
+		opcode = opContinuation
+		if len(payload) == 0 {
+			return frames
+		}
+	}
+}
+
+// Cases returns the cases in the suite in order.
+func Cases() []*Case {
+	var cs []*Case
+	add := func(id, category, description string, steps ...step) {
+		cs = append(cs, &Case{ID: id, Category: category, Description: description, run: seq(steps...)})
+	}
+	addCompressed := func(id, description string, steps ...step) {
+		add(id, Compression, description, steps...)
+		cs[len(cs)-1].compress = true
+	}
+
+	// 1. Framing
+
+	lengths := []int{0, 125, 126, 127, 128, 65535, 65536}
+	for i, n := range lengths {
+		add(fmt.Sprintf("1.1.%d", i+1), Framing, fmt.Sprintf("Text message with payload length %d", n),
+			echo(opText, textPayload(n)), closeHandshake(1000))
+	}
+	for i, n := range lengths {
+		add(fmt.Sprintf("1.2.%d", i+1), Framing, fmt.Sprintf("Binary message with payload length %d", n),
+			echo(opBinary, binaryPayload(n)), closeHandshake(1000))
+	}
+	add("1.3.1", Framing, "Text message with payload length 65536 written in chunks of 997 bytes",
+		sendChopped(997, msg(opText, textPayload(65536))), expectMessage(opText, textPayload(65536)), closeHandshake(1000))
+	add("1.3.2", Framing, "Binary message with payload length 16 written one byte at a time",
+		sendChopped(1, msg(opBinary, binaryPayload(16))), expectMessage(opBinary, binaryPayload(16)), closeHandshake(1000))
+	add("1.4.1", Framing, "Two messages in a single write",
+		send(text("first"), text("second")), expectText("first"), expectText("second"), closeHandshake(1000))
+	add("1.5.1", Framing, "Frame with incorrect masking",
+		send(frame{fin: true, opcode: opText, payload: []byte("hello"), badMask: true}), expectFail(codeProtocolError))
+	add("1.5.2", Framing, "Frame with the most significant bit of the 64 bit payload length set",
+		func(p *peer) error {
+			b := p.encode(msg(opBinary, binaryPayload(65536)))
+			b[2] |= 0x80
+			if _, err := p.conn.Write(b[:10]); err != nil {
+				return err
+			}
+			return p.expectFail(codeProtocolError, 1009)
+		})
+
+	// 2. Pings and pongs
+
+	add("2.1", PingPong, "Ping with empty payload",
+		send(ping(nil)), expectPong(nil), closeHandshake(1000))
+	add("2.2", PingPong, "Ping with text payload",
+		send(ping([]byte("Hello, world!"))), expectPong([]byte("Hello, world!")), closeHandshake(1000))
+	add("2.3", PingPong, "Ping with binary payload",
+		send(ping(binaryPayload(8))), expectPong(binaryPayload(8)), closeHandshake(1000))
+	add("2.4", PingPong, "Ping with payload length 125",
+		send(ping(binaryPayload(125))), expectPong(binaryPayload(125)), closeHandshake(1000))
+	add("2.5", PingPong, "Ping with payload length 126",
+		send(ping(binaryPayload(126))), expectFail(codeProtocolError))
+	add("2.6", PingPong, "Ping with payload length 125 written one byte at a time",
+		sendChopped(1, ping(binaryPayload(125))), expectPong(binaryPayload(125)), closeHandshake(1000))
+	add("2.7", PingPong, "Unsolicited pong with empty payload",
+		send(pong(nil)), echo(opText, []byte("after pong")), closeHandshake(1000))
+	add("2.8", PingPong, "Unsolicited pong with payload",
+		send(pong([]byte("unsolicited"))), echo(opText, []byte("after pong")), closeHandshake(1000))
+	add("2.9", PingPong, "Unsolicited pong followed by ping",
+		send(pong([]byte("unsolicited")), ping([]byte("solicited"))), expectPong([]byte("solicited")), closeHandshake(1000))
+	add("2.10", PingPong, "Ten pings in a single write",
+		func(p *peer) error {
+			var frames []frame
+			for i := 0; i < 10; i++ {
+				frames = append(frames, ping([]byte(fmt.Sprintf("ping %d", i))))
+			}
+			if err := p.send(frames...); err != nil {
+				return err
+			}
+			for i := 0; i < 10; i++ {
+				if err := p.expectPong([]byte(fmt.Sprintf("ping %d", i))); err != nil {
+					return err
+				}
+			}
+			return p.closeHandshake(1000)
+		})
+
+	// 3. Reserved bits
+
+	add("3.1", ReservedBits, "Text message with RSV1 set",
+		send(frame{fin: true, rsv: rsv1Bit, opcode: opText, payload: []byte("hello")}), expectFail(codeProtocolError))
+	add("3.2", ReservedBits, "Text message with RSV2 set after a valid message",
+		echo(opText, []byte("hello")),
+		send(frame{fin: true, rsv: rsv2Bit, opcode: opText, payload: []byte("hello")}), expectFail(codeProtocolError))
+	add("3.3", ReservedBits, "Text message with RSV3 set followed by ping",
+		send(frame{fin: true, rsv: rsv3Bit, opcode: opText, payload: []byte("hello")}, ping(nil)), expectFail(codeProtocolError))
+	add("3.4", ReservedBits, "Binary message with RSV1 and RSV2 set",
+		send(frame{fin: true, rsv: rsv1Bit | rsv2Bit, opcode: opBinary, payload: binaryPayload(8)}), expectFail(codeProtocolError))
+	add("3.5", ReservedBits, "Ping with RSV1, RSV2 and RSV3 set",
+		send(frame{fin: true, rsv: rsv1Bit | rsv2Bit | rsv3Bit, opcode: opPing}), expectFail(codeProtocolError))
+	add("3.6", ReservedBits, "Close with RSV2 set",
+		send(frame{fin: true, rsv: rsv2Bit, opcode: opClose, payload: codePayload(1000)}), expectFail(codeProtocolError))
+
+	// 4. Opcodes
+
+	for i, op := range []int{3, 4, 5, 6, 7} {
+		add(fmt.Sprintf("4.1.%d", i+1), Opcodes, fmt.Sprintf("Reserved non-control opcode %d", op),
+			send(msg(op, []byte("reserved"))), expectFail(codeProtocolError))
+	}
+	add("4.1.6", Opcodes, "Reserved non-control opcode 3 after a valid message",
+		echo(opText, []byte("hello")), send(msg(3, nil)), expectFail(codeProtocolError))
+	for i, op := range []int{11, 12, 13, 14, 15} {
+		add(fmt.Sprintf("4.2.%d", i+1), Opcodes, fmt.Sprintf("Reserved control opcode %d", op),
+			send(msg(op, []byte("reserved"))), expectFail(codeProtocolError))
+	}
+	add("4.2.6", Opcodes, "Reserved control opcode 11 after a valid message",
+		echo(opText, []byte("hello")), send(msg(11, nil)), expectFail(codeProtocolError))
+
+	// 5. Fragmentation
+
+	add("5.1", Fragmentation, "Text message in two fragments",
+		send(fragment(opText, false, "fragment1"), fragment(opContinuation, true, "fragment2")),
+		expectText("fragment1fragment2"), closeHandshake(1000))
+	add("5.2", Fragmentation, "Binary message in 1024 byte fragments",
+		send(fragments(opBinary, binaryPayload(4000), 1024)...),
+		expectMessage(opBinary, binaryPayload(4000)), closeHandshake(1000))
+	add("5.3", Fragmentation, "Text message with empty fragments",
+		send(fragment(opText, false, ""), fragment(opContinuation, false, ""),
+			fragment(opContinuation, false, "hello"), fragment(opContinuation, true, "")),
+		expectText("hello"), closeHandshake(1000))
+	add("5.4", Fragmentation, "Ping between fragments",
+		send(fragment(opText, false, "fragment1"), ping([]byte("ping")), fragment(opContinuation, true, "fragment2")),
+		expectPong([]byte("ping")), expectText("fragment1fragment2"), closeHandshake(1000))
+	add("5.5", Fragmentation, "Ping between fragments written one byte at a time",
+		sendChopped(1, fragment(opText, false, "fragment1"), ping([]byte("ping")), fragment(opContinuation, true, "fragment2")),
+		expectPong([]byte("ping")), expectText("fragment1fragment2"), closeHandshake(1000))
+	add("5.6", Fragmentation, "Pong between fragments",
+		send(fragment(opText, false, "fragment1"), pong([]byte("pong")), fragment(opContinuation, true, "fragment2")),
+		expectText("fragment1fragment2"), closeHandshake(1000))
+	add("5.7", Fragmentation, "Fragmented ping",
+		send(fragment(opPing, false, "fragment1"), fragment(opContinuation, true, "fragment2")),
+		expectFail(codeProtocolError))
+	add("5.8", Fragmentation, "Fragmented close",
+		send(frame{opcode: opClose, payload: codePayload(1000)}, fragment(opContinuation, true, "")),
+		expectFail(codeProtocolError))
+	add("5.9", Fragmentation, "Continuation without a message",
+		send(fragment(opContinuation, true, "fragment")), expectFail(codeProtocolError))
+	add("5.10", Fragmentation, "Unfinished continuation without a message",
+		send(fragment(opContinuation, false, "fragment")), expectFail(codeProtocolError))
+	add("5.11", Fragmentation, "Continuation after a finished message",
+		echo(opText, []byte("hello")), send(fragment(opContinuation, true, "fragment")), expectFail(codeProtocolError))
+	add("5.12", Fragmentation, "Text message before the previous message is finished",
+		send(fragment(opText, false, "fragment1"), fragment(opText, true, "fragment2")), expectFail(codeProtocolError))
+	add("5.13", Fragmentation, "Binary message in one byte fragments",
+		send(fragments(opBinary, binaryPayload(100), 1)...),
+		expectMessage(opBinary, binaryPayload(100)), closeHandshake(1000))
+
+	// 6. UTF-8 handling
+
+	valid := []string{
+		"Hello-µ@ßöäüàá-UTF-8!!",
+		"κόσμε",
+		"\xed\x9f\xbf",     // U+D7FF
+		"\xef\xbf\xbd",     // U+FFFD
+		"\xf0\x9d\x84\x9e", // U+1D11E
+		"\xf4\x8f\xbf\xbf", // U+10FFFF
+	}
+	for i, s := range valid {
+		add(fmt.Sprintf("6.1.%d", i+1), UTF8, fmt.Sprintf("Valid UTF-8 text %q", s),
+			echo(opText, []byte(s)), closeHandshake(1000))
+	}
+	add("6.2.1", UTF8, "Valid UTF-8 text fragmented within a code point",
+		send(fragment(opText, false, "\xce"), fragment(opContinuation, true, "\xba\xe1\xbd\xb9\xcf\x83\xce\xbc\xce\xb5")),
+		expectText("\xce\xba\xe1\xbd\xb9\xcf\x83\xce\xbc\xce\xb5"), closeHandshake(1000))
+	add("6.2.2", UTF8, "Valid UTF-8 text in one byte fragments",
+		send(fragments(opText, []byte("κόσμε"), 1)...), expectText("κόσμε"), closeHandshake(1000))
+	invalid := []string{
+		"\x80",                    // lone continuation byte
+		"\xc0\xaf",                // overlong encoding
+		"\xed\xa0\x80",            // surrogate
+		"\xf4\x90\x80\x80",        // above U+10FFFF
+		"\xfe",                    // invalid byte
+		"\xce",                    // truncated sequence
+		"κόσμε\xed\xa0\x80edited", // surrogate after valid text
+	}
+	for i, s := range invalid {
+		add(fmt.Sprintf("6.3.%d", i+1), UTF8, fmt.Sprintf("Invalid UTF-8 text %q", s),
+			send(text(s)), expectFail(codeInvalidPayloadData))
+	}
+	add("6.4.1", UTF8, "Invalid UTF-8 in the second fragment of a text message",
+		send(fragment(opText, false, "κόσμε"), fragment(opContinuation, true, "\x80")),
+		expectFail(codeInvalidPayloadData))
+	add("6.4.2", UTF8, "Truncated UTF-8 sequence at the end of a fragmented text message",
+		send(fragment(opText, false, "κόσμε"), fragment(opContinuation, true, "\xce")),
+		expectFail(codeInvalidPayloadData))
+
+	// 7. Close handling
+
+	add("7.1.1", Close, "Close with code 1000",
+		closeHandshake(1000))
+	add("7.1.2", Close, "Close with empty payload",
+		closeHandshake(0))
+	add("7.1.3", Close, "Close after a message",
+		echo(opText, []byte("hello")), closeHandshake(1000))
+	add("7.1.4", Close, "Text message after close",
+		send(closeFrame(codePayload(1000)), text("after close")), expectClose(1000))
+	add("7.1.5", Close, "Ping after close",
+		send(closeFrame(codePayload(1000)), ping([]byte("after close"))), expectClose(1000))
+	add("7.1.6", Close, "Close with reason of length 123",
+		send(closeFrame(append(codePayload(1000), textPayload(123)...))), expectClose(1000))
+	add("7.2.1", Close, "Close with payload length 1",
+		send(closeFrame([]byte{0x03})), expectFail(codeProtocolError))
+	add("7.2.2", Close, "Close with payload length 126",
+		send(closeFrame(append(codePayload(1000), textPayload(124)...))), expectFail(codeProtocolError))
+	add("7.2.3", Close, "Close with invalid UTF-8 reason",
+		send(closeFrame(append(codePayload(1000), "\xed\xa0\x80"...))), expectFail(codeProtocolError, codeInvalidPayloadData))
+	for i, code := range []int{1000, 1001, 1002, 1003, 1007, 1008, 1009, 1010, 1011, 3000, 3999, 4000, 4999} {
+		add(fmt.Sprintf("7.3.%d", i+1), Close, fmt.Sprintf("Close with valid code %d", code),
+			closeHandshake(code))
+	}
+	for i, code := range []int{0, 999, 1004, 1005, 1006, 1015, 1016, 1100, 2000, 2999, 5000, 65535} {
+		add(fmt.Sprintf("7.4.%d", i+1), Close, fmt.Sprintf("Close with invalid code %d", code),
+			send(closeFrame(codePayload(code))), expectFail(codeProtocolError))
+	}
+
+	// 9. Limits
+
+	add("9.1.1", Limits, "Text message with payload length 1 MiB",
+		echo(opText, textPayload(1<<20)), closeHandshake(1000))
+	add("9.1.2", Limits, "Binary message with payload length 4 MiB",
+		echo(opBinary, binaryPayload(4<<20)), closeHandshake(1000))
+	add("9.2.1", Limits, "Binary message with payload length 1 MiB in 64 KiB fragments",
+		send(fragments(opBinary, binaryPayload(1<<20), 64<<10)...),
+		expectMessage(opBinary, binaryPayload(1<<20)), closeHandshake(1000))
+	add("9.2.2", Limits, "Text message in 1000 fragments",
+		send(fragments(opText, textPayload(1000), 1)...), expectMessage(opText, textPayload(1000)), closeHandshake(1000))
+	add("9.3.1", Limits, "100 text messages in a single write",
+		func(p *peer) error {
+			var frames []frame
+			for i := 0; i < 100; i++ {
+				frames = append(frames, text(fmt.Sprintf("message %d", i)))
+			}
+			if err := p.send(frames...); err != nil {
+				return err
+			}
+			for i := 0; i < 100; i++ {
+				if err := p.expectMessage(opText, []byte(fmt.Sprintf("message %d", i))); err != nil {
+					return err
+				}
+			}
+			return p.closeHandshake(1000)
+		})
+
+	// 12. Compression
+
+	for i, n := range []int{0, 16, 1024, 65536, 1 << 20} {
+		addCompressed(fmt.Sprintf("12.1.%d", i+1), fmt.Sprintf("Compressed text message with payload length %d", n),
+			send(compressed(opText, textPayload(n))), expectMessage(opText, textPayload(n)), closeHandshake(1000))
+	}
+	for i, n := range []int{16, 4096, 65536} {
+		addCompressed(fmt.Sprintf("12.2.%d", i+1), fmt.Sprintf("Compressed binary message with payload length %d", n),
+			send(compressed(opBinary, binaryPayload(n))), expectMessage(opBinary, binaryPayload(n)), closeHandshake(1000))
+	}
+	addCompressed("12.3.1", "Compressed text message in three fragments",
+		func(p *peer) error {
+			payload := deflate(textPayload(4096))
+			frames := fragments(opText, payload, (len(payload)+2)/3)
+			frames[0].rsv = rsv1Bit
+			if err := p.send(frames...); err != nil {
+				return err
+			}
+			return seq(expectMessage(opText, textPayload(4096)), closeHandshake(1000))(p)
+		})
+	addCompressed("12.3.2", "Compressed text message with ping between fragments",
+		func(p *peer) error {
+			payload := deflate([]byte("Hello, world!"))
+			first := frame{rsv: rsv1Bit, opcode: opText, payload: payload[:2]}
+			last := frame{fin: true, opcode: opContinuation, payload: payload[2:]}
+			if err := p.send(first, ping([]byte("ping")), last); err != nil {
+				return err
+			}
+			return seq(expectPong([]byte("ping")), expectText("Hello, world!"), closeHandshake(1000))(p)
+		})
+	addCompressed("12.4.1", "Uncompressed message on a compressed connection",
+		echo(opText, []byte("uncompressed")), closeHandshake(1000))
+	addCompressed("12.4.2", "Ten compressed messages",
+		func(p *peer) error {
+			for i := 0; i < 10; i++ {
+				payload := []byte(fmt.Sprintf("message %d %s", i, textPayload(100)))
+				if err := p.send(compressed(opText, payload)); err != nil {
+					return err
+				}
+				if err := p.expectMessage(opText, payload); err != nil {
+					return err
+				}
+			}
+			return p.closeHandshake(1000)
+		})
+	addCompressed("12.5.1", "Ping with RSV1 set",
+		send(frame{fin: true, rsv: rsv1Bit, opcode: opPing}), expectFail(codeProtocolError))
+	addCompressed("12.5.2", "Close with RSV1 set",
+		send(frame{fin: true, rsv: rsv1Bit, opcode: opClose, payload: codePayload(1000)}), expectFail(codeProtocolError))
+	addCompressed("12.5.3", "Continuation with RSV1 set",
+		func(p *peer) error {
+			payload := deflate([]byte("Hello, world!"))
+			first := frame{rsv: rsv1Bit, opcode: opText, payload: payload[:2]}
+			last := frame{fin: true, rsv: rsv1Bit, opcode: opContinuation, payload: payload[2:]}
+			if err := p.send(first, last); err != nil {
+				return err
+			}
+			return p.expectFail(codeProtocolError)
+		})
+	addCompressed("12.5.4", "Compressed message with invalid deflate data",
+		send(frame{fin: true, rsv: rsv1Bit, opcode: opBinary, payload: []byte{0xff, 0xff, 0xff, 0xff}}),
+		expectFail(codeProtocolError, codeInvalidPayloadData))
+
+	return cs
+}
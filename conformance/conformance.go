@@ -0,0 +1,386 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package conformance tests WebSocket servers and clients for conformance
+// with RFC 6455 and the permessage-deflate extension defined in RFC 7692.
+//
+// The suite reproduces the case categories of the Autobahn test suite:
+// framing, pings and pongs, reserved bits, opcodes, fragmentation, UTF-8
+// handling, close handling, limits and compression. Each case writes raw
+// frames to the implementation under test and checks the frames written in
+// response.
+//
+// The implementation under test is expected to echo every text and binary
+// message it receives and to fail the connection with close code 1007 when
+// a text message contains invalid UTF-8. EchoHandler and EchoClient are
+// implementations of this behavior using the websocket package.
+//
+// The suite runs over loopback TCP connections and does not require
+// external tools.
+package conformance
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gorilla/websocket/internal/handshake"
+)
+
+// Case is a conformance test case.
+type Case struct {
+	// ID identifies the case. IDs are dotted numbers where the first
+	// component identifies the category.
+	ID string
+
+	// Category is the category of the case.
+	Category string
+
+	// Description describes the frames sent and the expected behavior.
+	Description string
+
+	compress bool // the case requires permessage-deflate
+	run      step
+}
+
+// Outcome is the outcome of a case.
+type Outcome int
+
+const (
+	// Pass indicates that the implementation behaved as expected.
+	Pass Outcome = iota
+
+	// Fail indicates that the implementation did not behave as expected.
+	Fail
+
+	// Unsupported indicates that the case was not run because the
+	// implementation did not negotiate an extension required by the case.
+	Unsupported
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Pass:
+		return "PASS"
+	case Fail:
+		return "FAIL"
+	case Unsupported:
+		return "UNSUPPORTED"
+	}
+	return fmt.Sprintf("Outcome(%d)", int(o))
+}
+
+// Result is the result of running a case.
+type Result struct {
+	Case     *Case
+	Outcome  Outcome
+	Detail   string // describes the failure
+	Duration time.Duration
+}
+
+// Report is the result of running the suite.
+type Report struct {
+	// Side is "server" or "client".
+	Side    string
+	Results []Result
+}
+
+// Count returns the number of results with outcome o.
+func (r *Report) Count(o Outcome) int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Outcome == o {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed returns the results of the failed cases.
+func (r *Report) Failed() []Result {
+	var failed []Result
+	for _, res := range r.Results {
+		if res.Outcome == Fail {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// WriteTo writes a table with one line per case to w.
+func (r *Report) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	fmt.Fprintf(cw, "%s conformance: %d passed, %d failed, %d unsupported\n",
+		r.Side, r.Count(Pass), r.Count(Fail), r.Count(Unsupported))
+	tw := tabwriter.NewWriter(cw, 0, 8, 2, ' ', 0)
+	for _, res := range r.Results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s", res.Case.ID, res.Outcome, res.Case.Category, res.Case.Description)
+		if res.Detail != "" {
+			fmt.Fprintf(tw, "\t%s", res.Detail)
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+	return cw.n, cw.err
+}
+
+func (r *Report) String() string {
+	var sb strings.Builder
+	r.WriteTo(&sb)
+	return sb.String()
+}
+
+type countWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
+
+// Options specifies options for running the suite.
+type Options struct {
+	// Timeout specifies the time limit for each case. If zero, ten seconds
+	// is used.
+	Timeout time.Duration
+
+	// Cases specifies the cases to run. An entry selects the case with that
+	// ID and the cases with IDs that start with the entry followed by a dot.
+	// If empty, all cases are run.
+	Cases []string
+}
+
+func (o *Options) timeout() time.Duration {
+	if o.Timeout > 0 {
+		return o.Timeout
+	}
+	return 10 * time.Second
+}
+
+func (o *Options) selected() []*Case {
+	if len(o.Cases) == 0 {
+		return Cases()
+	}
+	var selected []*Case
+	for _, c := range Cases() {
+		for _, id := range o.Cases {
+			if c.ID == id || strings.HasPrefix(c.ID, id+".") {
+				selected = append(selected, c)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// RunServer runs the suite against the WebSocket server implemented by h and
+// returns the report. The suite connects to h as a client. Compression cases
+// offer permessage-deflate with no context takeover and are reported as
+// unsupported if h does not accept the offer.
+func RunServer(h http.Handler, opts *Options) *Report {
+	if opts == nil {
+		opts = &Options{}
+	}
+	report := &Report{Side: "server"}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return report.failAll(opts.selected(), err)
+	}
+	srv := &http.Server{Handler: h}
+	go srv.Serve(l)
+	defer srv.Close()
+
+	for _, c := range opts.selected() {
+		start := time.Now()
+		res := Result{Case: c}
+		err := runServerCase(c, l.Addr().String(), start.Add(opts.timeout()))
+		res.Duration = time.Since(start)
+		res.Outcome, res.Detail = outcome(err)
+		report.Results = append(report.Results, res)
+	}
+	return report
+}
+
+func runServerCase(c *Case, addr string, deadline time.Time) error {
+	conn, err := net.DialTimeout("tcp", addr, time.Until(deadline))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadline)
+
+	p := &peer{conn: conn}
+	if err := p.clientHandshake("ws://"+addr+"/"+c.ID, c.compress); err != nil {
+		return err
+	}
+	if c.compress && !p.compress {
+		return errUnsupported
+	}
+	return c.run(p)
+}
+
+// RunClient runs the suite against the WebSocket client implemented by dial
+// and returns the report. The suite calls dial once per case with the URL of
+// a server run by the suite. The dial function should connect to the URL,
+// echo messages until the connection fails and then return. Compression cases
+// are reported as unsupported if the client does not offer permessage-deflate.
+func RunClient(dial func(url string) error, opts *Options) *Report {
+	if opts == nil {
+		opts = &Options{}
+	}
+	report := &Report{Side: "client"}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return report.failAll(opts.selected(), err)
+	}
+	defer l.Close()
+
+	conns := make(chan net.Conn)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			select {
+			case conns <- conn:
+			case <-stop:
+				conn.Close()
+				return
+			}
+		}
+	}()
+
+	for _, c := range opts.selected() {
+		start := time.Now()
+		res := Result{Case: c}
+		err := runClientCase(c, "ws://"+l.Addr().String()+"/"+c.ID, conns, dial, start.Add(opts.timeout()))
+		res.Duration = time.Since(start)
+		res.Outcome, res.Detail = outcome(err)
+		report.Results = append(report.Results, res)
+	}
+	return report
+}
+
+func runClientCase(c *Case, u string, conns <-chan net.Conn, dial func(url string) error, deadline time.Time) error {
+	done := make(chan error, 1)
+	go func() { done <- dial(u) }()
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	var conn net.Conn
+	select {
+	case conn = <-conns:
+	case err := <-done:
+		return fmt.Errorf("client did not connect: %v", err)
+	case <-timer.C:
+		return errors.New("client did not connect")
+	}
+	defer func() {
+		conn.Close()
+		select {
+		case <-done:
+		case <-timer.C:
+		}
+	}()
+	conn.SetDeadline(deadline)
+
+	p := &peer{conn: conn, server: true}
+	if err := p.serverHandshake(c.compress); err != nil {
+		return err
+	}
+	if c.compress && !p.compress {
+		return errUnsupported
+	}
+	return c.run(p)
+}
+
+var errUnsupported = errors.New("permessage-deflate not negotiated")
+
+func outcome(err error) (Outcome, string) {
+	switch err {
+	case nil:
+		return Pass, ""
+	case errUnsupported:
+		return Unsupported, err.Error()
+	}
+	return Fail, err.Error()
+}
+
+func (r *Report) failAll(cases []*Case, err error) *Report {
+	for _, c := range cases {
+		r.Results = append(r.Results, Result{Case: c, Outcome: Fail, Detail: err.Error()})
+	}
+	return r
+}
+
+// clientHandshake performs the client side of the opening handshake.
+func (p *peer) clientHandshake(rawURL string, compress bool) error {
+	resp, br, err := handshake.Client(p.conn, rawURL, nil, compress)
+	if err != nil {
+		return err
+	}
+	p.br = br
+	for _, ext := range resp.Header.Values("Sec-Websocket-Extensions") {
+		if !compress || !strings.HasPrefix(strings.TrimSpace(ext), "permessage-deflate") {
+			return fmt.Errorf("handshake: unexpected extension %q", ext)
+		}
+		p.compress = true
+	}
+	return nil
+}
+
+// serverHandshake performs the server side of the opening handshake.
+func (p *peer) serverHandshake(compress bool) error {
+	p.br = bufio.NewReader(p.conn)
+	req, err := http.ReadRequest(p.br)
+	if err != nil {
+		return fmt.Errorf("handshake: %v", err)
+	}
+	if req.Method != http.MethodGet ||
+		!strings.EqualFold(req.Header.Get("Upgrade"), "websocket") ||
+		req.Header.Get("Sec-Websocket-Version") != "13" {
+		return errors.New("handshake: invalid request")
+	}
+	key := req.Header.Get("Sec-Websocket-Key")
+	if key == "" {
+		return errors.New("handshake: missing Sec-WebSocket-Key")
+	}
+
+	resp := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + handshake.AcceptKey(key) + "\r\n"
+	if compress {
+		for _, ext := range req.Header.Values("Sec-Websocket-Extensions") {
+			if strings.Contains(ext, "permessage-deflate") {
+				p.compress = true
+			}
+		}
+		if p.compress {
+			resp += "Sec-WebSocket-Extensions: " + handshake.DeflateExtension + "\r\n"
+		}
+	}
+	_, err = io.WriteString(p.conn, resp+"\r\n")
+	return err
+}
@@ -0,0 +1,97 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package conformance
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func checkReport(t *testing.T, r *Report) {
+	t.Helper()
+	for _, res := range r.Results {
+		if res.Outcome != Pass {
+			t.Errorf("%s %s: %s: %s", res.Case.ID, res.Outcome, res.Case.Description, res.Detail)
+		}
+	}
+	if testing.Verbose() {
+		t.Log("\n" + r.String())
+	}
+}
+
+func TestServer(t *testing.T) {
+	checkReport(t, RunServer(EchoHandler(nil), nil))
+}
+
+func TestClient(t *testing.T) {
+	checkReport(t, RunClient(EchoClient(nil), nil))
+}
+
+func TestCases(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, c := range Cases() {
+		if seen[c.ID] {
+			t.Errorf("duplicate case ID %s", c.ID)
+		}
+		seen[c.ID] = true
+		if c.Category == "" || c.Description == "" {
+			t.Errorf("case %s is missing a category or description", c.ID)
+		}
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	opts := &Options{Cases: []string{"12.1"}}
+
+	u := &websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+	r := RunServer(EchoHandler(u), opts)
+	if len(r.Results) != 5 || r.Count(Unsupported) != len(r.Results) {
+		t.Errorf("server results = %v, want 5 unsupported", r)
+	}
+
+	r = RunClient(EchoClient(&websocket.Dialer{}), opts)
+	if len(r.Results) != 5 || r.Count(Unsupported) != len(r.Results) {
+		t.Errorf("client results = %v, want 5 unsupported", r)
+	}
+}
+
+// noValidate echoes messages without validating UTF-8.
+func noValidate(w http.ResponseWriter, r *http.Request) {
+	u := websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+	c, err := u.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer c.Close()
+	for {
+		mt, p, err := c.ReadMessage()
+		if err != nil {
+			return
+		}
+		if err := c.WriteMessage(mt, p); err != nil {
+			return
+		}
+	}
+}
+
+func TestFail(t *testing.T) {
+	r := RunServer(http.HandlerFunc(noValidate), &Options{Cases: []string{"6.1.1", "6.3.1"}})
+	if r.Count(Pass) != 1 || r.Count(Fail) != 1 {
+		t.Fatalf("results = %v, want 1 passed and 1 failed", r)
+	}
+	failed := r.Failed()
+	if failed[0].Case.ID != "6.3.1" {
+		t.Errorf("failed case = %s, want 6.3.1", failed[0].Case.ID)
+	}
+	s := r.String()
+	for _, want := range []string{"server conformance: 1 passed, 1 failed, 0 unsupported", "6.3.1  FAIL"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("report does not contain %q:\n%s", want, s)
+		}
+	}
+}
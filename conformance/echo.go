@@ -0,0 +1,70 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package conformance
+
+import (
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+)
+
+// EchoHandler returns a handler that upgrades requests with u and echoes
+// messages as expected by the suite. If u is nil, an upgrader with
+// compression enabled that accepts all origins is used.
+func EchoHandler(u *websocket.Upgrader) http.Handler {
+	if u == nil {
+		u = &websocket.Upgrader{
+			EnableCompression: true,
+			CheckOrigin:       func(r *http.Request) bool { return true },
+		}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := u.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		echoMessages(c)
+	})
+}
+
+// EchoClient returns a function that connects to a URL with d and echoes
+// messages as expected by the suite. The returned function has the
+// signature expected by RunClient. If d is nil, a dialer with compression
+// enabled is used.
+func EchoClient(d *websocket.Dialer) func(url string) error {
+	if d == nil {
+		d = &websocket.Dialer{EnableCompression: true}
+	}
+	return func(url string) error {
+		c, _, err := d.Dial(url, nil)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+		return echoMessages(c)
+	}
+}
+
+// echoMessages echoes messages until an error occurs. The connection is
+// failed with close code 1007 if a text message contains invalid UTF-8.
+func echoMessages(c *websocket.Conn) error {
+	for {
+		mt, p, err := c.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if mt == websocket.TextMessage && !utf8.Valid(p) {
+			return c.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseInvalidFramePayloadData, ""),
+				time.Now().Add(time.Second))
+		}
+		if err := c.WriteMessage(mt, p); err != nil {
+			return err
+		}
+	}
+}
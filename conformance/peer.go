@@ -0,0 +1,441 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package conformance
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Frame opcodes and header bits as defined in RFC 6455 section 5.2.
+const (
+	opContinuation = 0
+	opText         = 1
+	opBinary       = 2
+	opClose        = 8
+	opPing         = 9
+	opPong         = 10
+
+	finBit  = 1 << 7
+	rsv1Bit = 1 << 6
+	rsv2Bit = 1 << 5
+	rsv3Bit = 1 << 4
+	maskBit = 1 << 7
+)
+
+// frame is a frame sent by the suite.
+type frame struct {
+	fin     bool
+	rsv     byte // RSV bits in their header position
+	opcode  int
+	payload []byte
+	badMask bool // mask the frame if and only if the suite is the server
+}
+
+// event is a message or control frame received from the implementation.
+type event struct {
+	opcode  int
+	payload []byte
+}
+
+func (e event) String() string {
+	var name string
+	switch e.opcode {
+	case opText:
+		name = "text message"
+	case opBinary:
+		name = "binary message"
+	case opClose:
+		name = "close"
+		if len(e.payload) >= 2 {
+			return fmt.Sprintf("close %d", binary.BigEndian.Uint16(e.payload))
+		}
+	case opPing:
+		name = "ping"
+	case opPong:
+		name = "pong"
+	default:
+		name = fmt.Sprintf("opcode %d", e.opcode)
+	}
+	return fmt.Sprintf("%s (%d bytes)", name, len(e.payload))
+}
+
+// errClosed is returned by peer.next when the implementation closes the
+// connection.
+var errClosed = errors.New("connection closed by implementation")
+
+// errTimeout is returned by peer.next when the case timeout expires.
+var errTimeout = errors.New("timeout waiting for implementation")
+
+// peer is the suite's end of a connection to the implementation.
+type peer struct {
+	conn     net.Conn
+	br       *bufio.Reader
+	server   bool // the suite is the server
+	compress bool // permessage-deflate was negotiated
+
+	// Message from the implementation in progress.
+	msg           []byte
+	msgOpcode     int
+	msgCompressed bool
+}
+
+// encode returns the wire format of f.
+func (p *peer) encode(f frame) []byte {
+	b0 := byte(f.opcode) | f.rsv
+	if f.fin {
+		b0 |= finBit
+	}
+	buf := []byte{b0, 0}
+	n := len(f.payload)
+	switch {
+	case n <= 125:
+		buf[1] = byte(n)
+	case n <= 65535:
+		buf[1] = 126
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf[1] = 127
+		buf = binary.BigEndian.AppendUint64(buf, uint64(n))
+	}
+
+	// Clients mask frames and servers do not.
+	if masked := p.server == f.badMask; !masked {
+		return append(buf, f.payload...)
+	}
+	buf[1] |= maskBit
+	var key [4]byte
+	_, _ = io.ReadFull(rand.Reader, key[:])
+	buf = append(buf, key[:]...)
+	for i, b := range f.payload {
+		buf = append(buf, b^key[i&3])
+	}
+	return buf
+}
+
+// send writes frames to the connection.
+func (p *peer) send(frames ...frame) error {
+	var buf []byte
+	for _, f := range frames {
+		buf = append(buf, p.encode(f)...)
+	}
+	_, err := p.conn.Write(buf)
+	return err
+}
+
+// sendChopped writes frames to the connection in chunks of at most n bytes.
+func (p *peer) sendChopped(n int, frames ...frame) error {
+	var buf []byte
+	for _, f := range frames {
+		buf = append(buf, p.encode(f)...)
+	}
+	for len(buf) > 0 {
+		m := n
+		if m > len(buf) {
+			m = len(buf)
+		}
+		if _, err := p.conn.Write(buf[:m]); err != nil {
+			return err
+		}
+		buf = buf[m:]
+	}
+	return nil
+}
+
+// sendMessage writes a data message in a single frame.
+func (p *peer) sendMessage(opcode int, payload []byte) error {
+	return p.send(frame{fin: true, opcode: opcode, payload: payload})
+}
+
+// sendClose writes a close frame with the given code and reason. If code is
+// zero, the close frame has an empty payload.
+func (p *peer) sendClose(code int, reason string) error {
+	return p.send(frame{fin: true, opcode: opClose, payload: closePayload(code, reason)})
+}
+
+func closePayload(code int, reason string) []byte {
+	if code == 0 {
+		return nil
+	}
+	return append(binary.BigEndian.AppendUint16(nil, uint16(code)), reason...)
+}
+
+// readFull reads exactly len(b) bytes from the connection. Timeouts are
+// reported as errTimeout and all other errors as errClosed.
+func (p *peer) readFull(b []byte) error {
+	_, err := io.ReadFull(p.br, b)
+	var ne net.Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &ne) && ne.Timeout():
+		return errTimeout
+	default:
+		return errClosed
+	}
+}
+
+// readFrame reads a frame from the implementation.
+func (p *peer) readFrame() (frame, error) {
+	var h [2]byte
+	if err := p.readFull(h[:]); err != nil {
+		return frame{}, err
+	}
+	f := frame{
+		fin:    h[0]&finBit != 0,
+		rsv:    h[0] & (rsv1Bit | rsv2Bit | rsv3Bit),
+		opcode: int(h[0] & 0xf),
+	}
+	masked := h[1]&maskBit != 0
+	if masked != p.server {
+		return f, fmt.Errorf("implementation sent frame with mask bit %v", masked)
+	}
+
+	n := uint64(h[1] & 0x7f)
+	switch n {
+	case 126:
+		var b [2]byte
+		if err := p.readFull(b[:]); err != nil {
+			return f, err
+		}
+		n = uint64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if err := p.readFull(b[:]); err != nil {
+			return f, err
+		}
+		n = binary.BigEndian.Uint64(b[:])
+		if n > 1<<31 {
+			return f, fmt.Errorf("implementation sent frame with length %d", n)
+		}
+	}
+
+	var key [4]byte
+	if masked {
+		if err := p.readFull(key[:]); err != nil {
+			return f, err
+		}
+	}
+	f.payload = make([]byte, n)
+	if err := p.readFull(f.payload); err != nil {
+		return f, err
+	}
+	if masked {
+		for i := range f.payload {
+			f.payload[i] ^= key[i&3]
+		}
+	}
+	return f, nil
+}
+
+// next returns the next message or control frame from the implementation.
+// Fragmented messages are reassembled and decompressed. Frames that violate
+// the protocol are reported as errors.
+func (p *peer) next() (event, error) {
+	for {
+		f, err := p.readFrame()
+		if err != nil {
+			return event{}, err
+		}
+		rsv := f.rsv
+		if rsv&rsv1Bit != 0 && p.compress && (f.opcode == opText || f.opcode == opBinary) {
+			rsv &^= rsv1Bit
+		}
+		if rsv != 0 {
+			return event{}, fmt.Errorf("implementation sent frame with RSV bits %#x", f.rsv)
+		}
+
+		switch f.opcode {
+		case opClose, opPing, opPong:
+			if !f.fin || len(f.payload) > 125 {
+				return event{}, errors.New("implementation sent invalid control frame")
+			}
+			if f.opcode == opClose {
+				if err := checkClosePayload(f.payload); err != nil {
+					return event{}, err
+				}
+			}
+			return event{opcode: f.opcode, payload: f.payload}, nil
+		case opText, opBinary:
+			if p.msgOpcode != 0 {
+				return event{}, errors.New("implementation started a message before finishing the previous message")
+			}
+			p.msgOpcode = f.opcode
+			p.msgCompressed = f.rsv&rsv1Bit != 0
+		case opContinuation:
+			if p.msgOpcode == 0 {
+				return event{}, errors.New("implementation sent continuation frame without a message")
+			}
+		default:
+			return event{}, fmt.Errorf("implementation sent frame with opcode %d", f.opcode)
+		}
+
+		p.msg = append(p.msg, f.payload...)
+		if !f.fin {
+			continue
+		}
+		e := event{opcode: p.msgOpcode, payload: p.msg}
+		compressed := p.msgCompressed
+		p.msg, p.msgOpcode, p.msgCompressed = nil, 0, false
+		if compressed {
+			e.payload, err = inflate(e.payload)
+			if err != nil {
+				return event{}, fmt.Errorf("implementation sent invalid compressed message: %v", err)
+			}
+		}
+		if e.opcode == opText && !utf8.Valid(e.payload) {
+			return event{}, errors.New("implementation sent text message with invalid UTF-8")
+		}
+		return e, nil
+	}
+}
+
+func checkClosePayload(p []byte) error {
+	switch {
+	case len(p) == 1:
+		return errors.New("implementation sent close frame with 1 byte payload")
+	case len(p) >= 2 && !validCloseCode(int(binary.BigEndian.Uint16(p))):
+		return fmt.Errorf("implementation sent close frame with code %d", binary.BigEndian.Uint16(p))
+	case len(p) >= 2 && !utf8.Valid(p[2:]):
+		return errors.New("implementation sent close frame with invalid UTF-8 reason")
+	}
+	return nil
+}
+
+// validCloseCode reports whether code may be sent in a close frame.
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003:
+		return true
+	case code >= 1007 && code <= 1014:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+// expectMessage reads the next event and checks that it is a data message
+// with the given opcode and payload.
+func (p *peer) expectMessage(opcode int, payload []byte) error {
+	e, err := p.next()
+	if err != nil {
+		return err
+	}
+	if e.opcode != opcode || !bytes.Equal(e.payload, payload) {
+		return fmt.Errorf("expected %v, got %v", event{opcode, payload}, e)
+	}
+	return nil
+}
+
+// expectPong reads the next event and checks that it is a pong with the
+// given payload.
+func (p *peer) expectPong(payload []byte) error {
+	return p.expectMessage(opPong, payload)
+}
+
+// closeHandshake sends a close frame with the given code and checks that the
+// implementation echoes the code and then closes the connection. If code is
+// zero, the suite sends an empty close frame and accepts an empty close
+// frame or code 1000 in response.
+func (p *peer) closeHandshake(code int) error {
+	if err := p.sendClose(code, ""); err != nil {
+		return err
+	}
+	return p.expectClose(code)
+}
+
+// expectClose reads the next event and checks that it is a close frame with
+// the given code. The connection must then be closed by the implementation.
+func (p *peer) expectClose(code int) error {
+	e, err := p.next()
+	if err != nil {
+		return err
+	}
+	if e.opcode != opClose {
+		return fmt.Errorf("expected close, got %v", e)
+	}
+	if got := closeCode(e.payload); got != code && !(code == 0 && got == 1000) {
+		return fmt.Errorf("expected close %d, got %v", code, e)
+	}
+	return p.expectEOF()
+}
+
+// expectFail checks that the implementation fails the connection. The
+// implementation can close the connection with or without sending a close
+// frame. If the implementation sends a close frame, the code must be one of
+// codes.
+func (p *peer) expectFail(codes ...int) error {
+	e, err := p.next()
+	if err == errClosed {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if e.opcode != opClose {
+		return fmt.Errorf("expected connection to fail, got %v", e)
+	}
+	got := closeCode(e.payload)
+	for _, code := range codes {
+		if got == code {
+			_ = p.sendClose(got, "")
+			return p.expectEOF()
+		}
+	}
+	return fmt.Errorf("expected close with code in %v, got %v", codes, e)
+}
+
+// expectEOF checks that the implementation closes the connection without
+// sending more frames.
+func (p *peer) expectEOF() error {
+	e, err := p.next()
+	if err == errClosed {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("expected connection to be closed, got %v", e)
+}
+
+func closeCode(p []byte) int {
+	if len(p) < 2 {
+		return 0
+	}
+	return int(binary.BigEndian.Uint16(p))
+}
+
+// deflate compresses p as a permessage-deflate message payload.
+func deflate(p []byte) []byte {
+	var buf bytes.Buffer
+	fw, _ := flate.NewWriter(&buf, flate.BestSpeed)
+	fw.Write(p)
+	fw.Flush()
+	return bytes.TrimSuffix(buf.Bytes(), []byte{0, 0, 0xff, 0xff})
+}
+
+// inflate decompresses a permessage-deflate message payload.
+func inflate(p []byte) ([]byte, error) {
+	const tail = "\x00\x00\xff\xff\x01\x00\x00\xff\xff"
+	fr := flate.NewReader(io.MultiReader(bytes.NewReader(p), strings.NewReader(tail)))
+	defer fr.Close()
+	return io.ReadAll(fr)
+}
+
+// setDeadline sets the read and write deadline for the connection.
+func (p *peer) setDeadline(t time.Time) {
+	p.conn.SetDeadline(t)
+}
@@ -10,6 +10,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"io"
+	"log/slog"
 	"net"
 	"strconv"
 	"strings"
@@ -279,6 +280,30 @@ type Conn struct {
 
 	readDecompress         bool // whether last read frame had RSV1 set
 	newDecompressionReader func(io.Reader) io.ReadCloser
+
+	recorder FrameRecorder // receives a copy of the frames, may be nil
+
+	metrics    Metrics      // receives measurements, may be nil
+	trace      *ConnTrace   // receives trace events, may be nil
+	readStats  MessageStats // the message being read, when observed
+	writeStats MessageStats // the message being written, when observed
+
+	codec            Codec        // set with SetCodec, may be nil
+	subprotocolCodec Codec        // codec for the negotiated subprotocol, may be nil
+	jsonOptions      *JSONOptions // set with SetJSONOptions, may be nil
+
+	logger        *slog.Logger // receives log records, may be nil
+	readErrLogged bool         // the read error was logged
+
+	readErrTraced bool // ReadError was called
+
+	pingMu      sync.Mutex             // protects the fields below
+	pingData    string                 // payload of the last ping sent, when traced
+	pingTime    time.Time              // time the last ping was sent, when traced
+	pingSeq     uint64                 // payload of the last ping sent by Ping
+	pingWaiters map[string]*pingWaiter // calls to Ping waiting for a pong
+	pingErr     error                  // read error returned by Ping
+	srtt        time.Duration          // smoothed round trip time
 }
 
 func newConn(conn net.Conn, isServer bool, readBufferSize, writeBufferSize int, writeBufferPool BufferPool, br *bufio.Reader, writeBuf []byte) *Conn {
@@ -338,9 +363,19 @@ func (c *Conn) Subprotocol() string {
 	return c.subprotocol
 }
 
+// IsServer returns true if c is the server side of the connection, as
+// returned from Upgrader.Upgrade, and false if c is the client side, as
+// returned from a Dialer.
+func (c *Conn) IsServer() bool {
+	return c.isServer
+}
+
 // Close closes the underlying network connection without sending or waiting
 // for a close message.
 func (c *Conn) Close() error {
+	if rc, ok := c.recorder.(io.Closer); ok {
+		_ = rc.Close()
+	}
 	return c.conn.Close()
 }
 
@@ -358,10 +393,14 @@ func (c *Conn) RemoteAddr() net.Addr {
 
 func (c *Conn) writeFatal(err error) error {
 	c.writeErrMu.Lock()
-	if c.writeErr == nil {
+	first := c.writeErr == nil
+	if first {
 		c.writeErr = err
 	}
 	c.writeErrMu.Unlock()
+	if first && err != ErrCloseSent && c.trace != nil && c.trace.WriteError != nil {
+		c.trace.WriteError(err)
+	}
 	return err
 }
 
@@ -390,6 +429,10 @@ func (c *Conn) write(frameType int, deadline time.Time, buf0, buf1 []byte) error
 	if err := c.conn.SetWriteDeadline(deadline); err != nil {
 		return c.writeFatal(err)
 	}
+	var start time.Time
+	if c.observed() {
+		start = time.Now()
+	}
 	if len(buf1) == 0 {
 		_, err = c.conn.Write(buf0)
 	} else {
@@ -398,6 +441,12 @@ func (c *Conn) write(frameType int, deadline time.Time, buf0, buf1 []byte) error
 	if err != nil {
 		return c.writeFatal(err)
 	}
+	if c.recorder != nil {
+		c.recordSent(buf0, buf1)
+	}
+	if c.observed() {
+		c.frameWritten(frameType, int64(len(buf0)+len(buf1)), time.Since(start))
+	}
 	if frameType == CloseMessage {
 		_ = c.writeFatal(ErrCloseSent)
 	}
@@ -471,9 +520,20 @@ func (c *Conn) WriteControl(messageType int, data []byte, deadline time.Time) er
 	if err := c.conn.SetWriteDeadline(deadline); err != nil {
 		return c.writeFatal(err)
 	}
+	var start time.Time
+	if c.observed() {
+		start = time.Now()
+	}
 	if _, err = c.conn.Write(buf); err != nil {
 		return c.writeFatal(err)
 	}
+	if c.recorder != nil {
+		c.recordSent(buf, nil)
+	}
+	if c.observed() {
+		c.frameWritten(messageType, int64(len(buf)), time.Since(start))
+		c.controlSent(messageType, data)
+	}
 	if messageType == CloseMessage {
 		_ = c.writeFatal(ErrCloseSent)
 	}
@@ -505,6 +565,10 @@ func (c *Conn) beginMessage(mw *messageWriter, messageType int) error {
 	mw.frameType = messageType
 	mw.pos = maxFrameHeaderSize
 
+	if c.observed() && isData(messageType) {
+		c.messageStart(FrameSent, messageType)
+	}
+
 	if c.writeBuf == nil {
 		wpd, ok := c.writePool.Get().(writePoolData)
 		if ok {
@@ -534,6 +598,10 @@ func (c *Conn) NextWriter(messageType int) (io.WriteCloser, error) {
 		w := c.newCompressionWriter(c.writer, c.compressionLevel)
 		mw.compress = true
 		c.writer = w
+		c.writeStats.Compressed = true
+	}
+	if c.observed() && isData(messageType) {
+		c.writer = &statsWriter{c: c, w: c.writer}
 	}
 	return c.writer, nil
 }
@@ -609,6 +677,12 @@ func (w *messageWriter) flushFrame(final bool, extra []byte) error {
 		c.writeBuf[framePos+1] = b1 | byte(length)
 	}
 
+	// Copy the control frame payload for observers before masking.
+	var control []byte
+	if isControl(w.frameType) && c.observed() {
+		control = append([]byte(nil), c.writeBuf[maxFrameHeaderSize:w.pos]...)
+	}
+
 	if !c.isServer {
 		key := newMaskKey()
 		copy(c.writeBuf[maxFrameHeaderSize-4:], key[:])
@@ -638,6 +712,10 @@ func (w *messageWriter) flushFrame(final bool, extra []byte) error {
 		return w.endMessage(err)
 	}
 
+	if isControl(w.frameType) && c.observed() {
+		c.controlSent(w.frameType, control)
+	}
+
 	if final {
 		_ = w.endMessage(errWriteClosed)
 		return nil
@@ -742,11 +820,12 @@ func (w *messageWriter) Close() error {
 
 // WritePreparedMessage writes prepared message into connection.
 func (c *Conn) WritePreparedMessage(pm *PreparedMessage) error {
-	frameType, frameData, err := pm.frame(prepareKey{
+	key := prepareKey{
 		isServer:         c.isServer,
 		compress:         c.newCompressionWriter != nil && c.enableWriteCompression && isData(pm.messageType),
 		compressionLevel: c.compressionLevel,
-	})
+	}
+	frameType, frameData, err := pm.frame(key)
 	if err != nil {
 		return err
 	}
@@ -754,11 +833,18 @@ func (c *Conn) WritePreparedMessage(pm *PreparedMessage) error {
 		panic("concurrent write to websocket connection")
 	}
 	c.isWriting = true
+	if c.observed() && isData(pm.messageType) {
+		c.messageStart(FrameSent, pm.messageType)
+		c.writeStats.Compressed = key.compress
+	}
 	err = c.write(frameType, c.writeDeadline, frameData, nil)
 	if !c.isWriting {
 		panic("concurrent write to websocket connection")
 	}
 	c.isWriting = false
+	if err == nil && c.observed() && isData(pm.messageType) {
+		c.reportMessageWritten(int64(len(pm.data)))
+	}
 	return err
 }
 
@@ -773,10 +859,15 @@ func (c *Conn) WriteMessage(messageType int, data []byte) error {
 		if err := c.beginMessage(&mw, messageType); err != nil {
 			return err
 		}
+		size := len(data)
 		n := copy(c.writeBuf[mw.pos:], data)
 		mw.pos += n
 		data = data[n:]
-		return mw.flushFrame(true, data)
+		err := mw.flushFrame(true, data)
+		if err == nil && c.observed() && isData(messageType) {
+			c.reportMessageWritten(int64(size))
+		}
+		return err
 	}
 
 	w, err := c.NextWriter(messageType)
@@ -805,6 +896,9 @@ func (c *Conn) advanceFrame() (int, error) {
 
 	if c.readRemaining > 0 {
 		if _, err := io.CopyN(io.Discard, c.br, c.readRemaining); err != nil {
+			if err == io.EOF {
+				err = errUnexpectedEOF
+			}
 			return noFrame, err
 		}
 	}
@@ -827,10 +921,13 @@ func (c *Conn) advanceFrame() (int, error) {
 	rsv3 := p[0]&rsv3Bit != 0
 	mask := p[1]&maskBit != 0
 	_ = c.setReadRemaining(int64(p[1] & 0x7f)) // will not fail because argument is >= 0
+	headerSize := 2
 
 	c.readDecompress = false
 	if rsv1 {
-		if c.newDecompressionReader != nil {
+		// RSV1 marks the first frame of a compressed message. It is not
+		// valid on control or continuation frames.
+		if c.newDecompressionReader != nil && isData(frameType) {
 			c.readDecompress = true
 		} else {
 			errors = append(errors, "RSV1 set")
@@ -872,7 +969,11 @@ func (c *Conn) advanceFrame() (int, error) {
 	}
 
 	if len(errors) > 0 {
-		return noFrame, c.handleProtocolError(strings.Join(errors, ", "))
+		message := strings.Join(errors, ", ")
+		if c.logger != nil {
+			c.logProtocolError(message, frameAttr(frameType, final, rsv1, rsv2, rsv3, mask, c.readRemaining))
+		}
+		return noFrame, c.handleProtocolError(message)
 	}
 
 	// 3. Read and parse frame length as per
@@ -897,6 +998,7 @@ func (c *Conn) advanceFrame() (int, error) {
 		if err := c.setReadRemaining(int64(binary.BigEndian.Uint16(p))); err != nil {
 			return noFrame, err
 		}
+		headerSize += 2
 	case 127:
 		p, err := c.read(8)
 		if err != nil {
@@ -906,6 +1008,7 @@ func (c *Conn) advanceFrame() (int, error) {
 		if err := c.setReadRemaining(int64(binary.BigEndian.Uint64(p))); err != nil {
 			return noFrame, err
 		}
+		headerSize += 8
 	}
 
 	// 4. Handle frame masking.
@@ -917,6 +1020,11 @@ func (c *Conn) advanceFrame() (int, error) {
 			return noFrame, err
 		}
 		copy(c.readMaskKey[:], p)
+		headerSize += 4
+	}
+
+	if c.observed() {
+		c.frameRead(frameType, int64(headerSize)+c.readRemaining)
 	}
 
 	// 5. For text and binary messages, enforce read limit and return.
@@ -927,10 +1035,16 @@ func (c *Conn) advanceFrame() (int, error) {
 		// Don't allow readLength to overflow in the presence of a large readRemaining
 		// counter.
 		if c.readLength < 0 {
+			if c.logger != nil {
+				c.logReadLimit()
+			}
 			return noFrame, ErrReadLimit
 		}
 
 		if c.readLimit > 0 && c.readLength > c.readLimit {
+			if c.logger != nil {
+				c.logReadLimit()
+			}
 			// Make a best effort to send a close message describing the problem.
 			_ = c.WriteControl(CloseMessage, FormatCloseMessage(CloseMessageTooBig, ""), time.Now().Add(writeWait))
 			return noFrame, ErrReadLimit
@@ -957,6 +1071,10 @@ func (c *Conn) advanceFrame() (int, error) {
 
 	switch frameType {
 	case PongMessage:
+		c.pingReplied(payload)
+		if c.trace != nil && c.trace.PongReceived != nil {
+			c.pongReceived(payload)
+		}
 		if err := c.handlePong(string(payload)); err != nil {
 			return noFrame, err
 		}
@@ -967,16 +1085,22 @@ func (c *Conn) advanceFrame() (int, error) {
 	case CloseMessage:
 		closeCode := CloseNoStatusReceived
 		closeText := ""
+		if len(payload) == 1 {
+			return noFrame, c.handleCloseProtocolError("close payload too short", payload)
+		}
 		if len(payload) >= 2 {
 			closeCode = int(binary.BigEndian.Uint16(payload))
 			if !isValidReceivedCloseCode(closeCode) {
-				return noFrame, c.handleProtocolError("bad close code " + strconv.Itoa(closeCode))
+				return noFrame, c.handleCloseProtocolError("bad close code "+strconv.Itoa(closeCode), payload)
 			}
 			closeText = string(payload[2:])
 			if !utf8.ValidString(closeText) {
-				return noFrame, c.handleProtocolError("invalid utf8 payload in close frame")
+				return noFrame, c.handleCloseProtocolError("invalid utf8 payload in close frame", payload)
 			}
 		}
+		if c.observed() {
+			c.closeReceived(closeCode, closeText)
+		}
 		if err := c.handleClose(closeCode, closeText); err != nil {
 			return noFrame, err
 		}
@@ -986,6 +1110,14 @@ func (c *Conn) advanceFrame() (int, error) {
 	return frameType, nil
 }
 
+// handleCloseProtocolError handles an invalid close frame payload.
+func (c *Conn) handleCloseProtocolError(message string, payload []byte) error {
+	if c.logger != nil {
+		c.logProtocolError(message, frameAttr(CloseMessage, true, false, false, false, c.isServer, int64(len(payload))))
+	}
+	return c.handleProtocolError(message)
+}
+
 func (c *Conn) handleProtocolError(message string) error {
 	data := FormatCloseMessage(CloseProtocolError, message)
 	if len(data) > maxControlFramePayloadSize {
@@ -1029,6 +1161,9 @@ func (c *Conn) NextReader() (messageType int, r io.Reader, err error) {
 			if c.readDecompress {
 				c.reader = c.newDecompressionReader(c.reader)
 			}
+			if c.observed() {
+				c.reader = &statsReader{c: c, r: c.reader}
+			}
 			return frameType, c.reader, nil
 		}
 	}
@@ -1041,6 +1176,14 @@ func (c *Conn) NextReader() (messageType int, r io.Reader, err error) {
 		panic("repeated read on failed websocket connection")
 	}
 
+	if c.trace != nil {
+		c.readError(c.readErr)
+	}
+	if c.logger != nil {
+		c.logReadError(c.readErr)
+	}
+	c.failPings(c.readErr)
+
 	return noFrame, nil, c.readErr
 }
 
@@ -1069,6 +1212,12 @@ func (r *messageReader) Read(b []byte) (int, error) {
 			if c.readRemaining > 0 && c.readErr == io.EOF {
 				c.readErr = errUnexpectedEOF
 			}
+			if c.readErr != nil {
+				if c.logger != nil {
+					c.logReadError(c.readErr)
+				}
+				c.failPings(c.readErr)
+			}
 			return n, c.readErr
 		}
 
@@ -1090,6 +1239,10 @@ func (r *messageReader) Read(b []byte) (int, error) {
 	if err == io.EOF && c.messageReader == r {
 		err = errUnexpectedEOF
 	}
+	if c.logger != nil {
+		c.logReadError(err)
+	}
+	c.failPings(err)
 	return 0, err
 }
 
@@ -16,6 +16,8 @@ import (
 	"testing"
 	"testing/iotest"
 	"time"
+
+	"github.com/gorilla/websocket/wstest/faultconn"
 )
 
 var _ net.Error = errWriteTimeout
@@ -476,6 +478,35 @@ func TestEOFBeforeFinalFrame(t *testing.T) {
 	}
 }
 
+func TestBadFrame(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{"RSV1 on ping", []byte{0xc9, 0x00}},
+		{"RSV1 on close", []byte{0xc8, 0x02, 0x03, 0xe8}},
+		{"RSV1 on continuation", []byte{0x41, 0x00, 0xc0, 0x00}},
+		{"close payload length 1", []byte{0x88, 0x01, 0x03}},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		rc := newTestConn(bytes.NewReader(tt.input), &b, false)
+		rc.newDecompressionReader = decompressNoContextTakeover
+
+		_, _, err := rc.ReadMessage()
+		if err == nil {
+			t.Errorf("%s: ReadMessage() returned nil error", tt.name)
+			continue
+		}
+		if _, ok := err.(*CloseError); ok {
+			t.Errorf("%s: ReadMessage() returned %v, want protocol error", tt.name, err)
+		}
+		if b.Len() == 0 {
+			t.Errorf("%s: close frame not written", tt.name)
+		}
+	}
+}
+
 func TestWriteAfterMessageWriterClose(t *testing.T) {
 	wc := newTestConn(nil, &bytes.Buffer{}, false)
 	w, _ := wc.NextWriter(BinaryMessage)
@@ -744,3 +775,217 @@ func TestFailedConnectionReadPanic(t *testing.T) {
 	}
 	t.Fatal("should not get here")
 }
+
+// newFaultConn creates a connection backed by a fake network connection
+// wrapped with fault injection.
+func newFaultConn(r io.Reader, w io.Writer, isServer bool, f faultconn.Faults) (*Conn, *faultconn.Conn) {
+	fc := faultconn.New(fakeNetConn{Reader: r, Writer: w}, f)
+	return newConn(fc, isServer, 1024, 1024, nil, nil, nil), fc
+}
+
+// clientFrames returns client frames exercising each frame header form and
+// the number of data messages in the frames.
+func clientFrames(t *testing.T) ([]byte, int) {
+	var b bytes.Buffer
+	wc := newConn(fakeNetConn{Writer: &b}, false, 1024, 70000, nil, nil, nil)
+	if err := wc.WriteControl(PingMessage, []byte("ping"), time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{10, 200, 65536} {
+		if err := wc.WriteMessage(BinaryMessage, make([]byte, n)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return b.Bytes(), 3
+}
+
+func nextOffset(n, sampleAfter int) int {
+	if n < sampleAfter {
+		return n + 1
+	}
+	return n + 4093
+}
+
+// readMessages reads all data messages from c.
+func readMessages(c *Conn) (int, error) {
+	n := 0
+	for {
+		_, r, err := c.NextReader()
+		if err != nil {
+			return n, err
+		}
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			return n, err
+		}
+		n++
+	}
+}
+
+func TestAdvanceFrameReadError(t *testing.T) {
+	frames, _ := clientFrames(t)
+	errTest := errors.New("test")
+	// Test every offset in the frames before the payload of the last
+	// message and sample offsets in that payload.
+	for n := 0; n < len(frames); n = nextOffset(n, len(frames)-65536) {
+		rc, _ := newFaultConn(bytes.NewReader(frames), io.Discard, true,
+			faultconn.Faults{ReadErrorAfter: int64(n), ReadError: errTest})
+		if _, err := readMessages(rc); err != errTest {
+			t.Fatalf("%d: read returned %v, want %v", n, err, errTest)
+		}
+		// The error is sticky.
+		if _, _, err := rc.NextReader(); err != errTest {
+			t.Fatalf("%d: NextReader() after error returned %v, want %v", n, err, errTest)
+		}
+	}
+}
+
+func TestAdvanceFrameShortReads(t *testing.T) {
+	frames, want := clientFrames(t)
+	for _, size := range []int{1, 2, 3, 7} {
+		rc, _ := newFaultConn(bytes.NewReader(frames), io.Discard, true, faultconn.Faults{MaxReadSize: size})
+		n, err := readMessages(rc)
+		if n != want || err != errUnexpectedEOF {
+			t.Errorf("%d: read %d messages with error %v, want %d, %v", size, n, err, want, errUnexpectedEOF)
+		}
+	}
+}
+
+func TestAdvanceFrameSkipTruncated(t *testing.T) {
+	frames, _ := clientFrames(t)
+	// Truncate the stream within the payload of the last message and skip
+	// the payload without reading it.
+	rc := newTestConn(bytes.NewReader(frames[:len(frames)-100]), io.Discard, true)
+	for i := 0; i < 3; i++ {
+		if _, _, err := rc.NextReader(); err != nil {
+			t.Fatalf("%d: NextReader() returned %v", i, err)
+		}
+	}
+	if _, _, err := rc.NextReader(); err != errUnexpectedEOF {
+		t.Errorf("NextReader() returned %v, want %v", err, errUnexpectedEOF)
+	}
+}
+
+func TestAdvanceFrameDeadline(t *testing.T) {
+	frames, _ := clientFrames(t)
+	rc, fc := newFaultConn(bytes.NewReader(frames), io.Discard, true, faultconn.Faults{ReadBytesPerSecond: 100000})
+	if err := rc.SetReadDeadline(time.Now().Add(50 * time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	_, err := readMessages(rc)
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Fatalf("read returned %v, want timeout", err)
+	}
+	if n := fc.BytesRead(); n == 0 || n >= int64(len(frames)) {
+		t.Errorf("read %d bytes before the deadline, want partial read of %d bytes", n, len(frames))
+	}
+	if _, _, err2 := rc.NextReader(); err2 != err {
+		t.Errorf("NextReader() after timeout returned %v, want %v", err2, err)
+	}
+}
+
+func TestMessageWriterWriteError(t *testing.T) {
+	const size = 3000 // spans multiple frames with 1024 byte write buffer
+	message := make([]byte, size)
+	errTest := errors.New("test")
+
+	for _, isServer := range []bool{false, true} {
+		var b bytes.Buffer
+		wc := newTestConn(nil, &b, isServer)
+		if err := wc.WriteMessage(BinaryMessage, message); err != nil {
+			t.Fatal(err)
+		}
+		total := b.Len()
+
+		for n := 0; n < total; n += 97 {
+			wc, _ := newFaultConn(nil, io.Discard, isServer,
+				faultconn.Faults{WriteErrorAfter: int64(n), WriteError: errTest})
+			w, err := wc.NextWriter(BinaryMessage)
+			if err != nil {
+				t.Fatalf("server=%v, %d: NextWriter() returned %v", isServer, n, err)
+			}
+			if _, err = w.Write(message); err == nil {
+				err = w.Close()
+			}
+			if err != errTest {
+				t.Fatalf("server=%v, %d: write returned %v, want %v", isServer, n, err, errTest)
+			}
+			if _, err := w.Write(message); err != errTest {
+				t.Errorf("server=%v, %d: Write() after error returned %v, want %v", isServer, n, err, errTest)
+			}
+			if err := w.Close(); err != errTest {
+				t.Errorf("server=%v, %d: Close() after error returned %v, want %v", isServer, n, err, errTest)
+			}
+			if _, err := wc.NextWriter(BinaryMessage); err != errTest {
+				t.Errorf("server=%v, %d: NextWriter() after error returned %v, want %v", isServer, n, err, errTest)
+			}
+			if err := wc.WriteControl(PingMessage, nil, time.Time{}); err != errTest {
+				t.Errorf("server=%v, %d: WriteControl() after error returned %v, want %v", isServer, n, err, errTest)
+			}
+		}
+	}
+}
+
+func TestMessageWriterShortWrites(t *testing.T) {
+	message := make([]byte, 3000)
+	for i := range message {
+		message[i] = byte(i)
+	}
+	for _, isServer := range []bool{false, true} {
+		var b bytes.Buffer
+		wc, _ := newFaultConn(nil, &b, isServer, faultconn.Faults{MaxWriteSize: 3})
+		w, _ := wc.NextWriter(BinaryMessage)
+		if _, err := w.Write(message); err != nil {
+			t.Fatalf("server=%v: Write() returned %v", isServer, err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("server=%v: Close() returned %v", isServer, err)
+		}
+		rc := newTestConn(&b, nil, !isServer)
+		_, p, err := rc.ReadMessage()
+		if err != nil || !bytes.Equal(p, message) {
+			t.Errorf("server=%v: ReadMessage() returned %d bytes, %v", isServer, len(p), err)
+		}
+	}
+}
+
+func TestMessageWriterDeadline(t *testing.T) {
+	wc, fc := newFaultConn(nil, io.Discard, false, faultconn.Faults{WriteBytesPerSecond: 100000})
+	if err := wc.SetWriteDeadline(time.Now().Add(50 * time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	err := wc.WriteMessage(BinaryMessage, make([]byte, 100000))
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Fatalf("WriteMessage() returned %v, want timeout", err)
+	}
+	if n := fc.BytesWritten(); n == 0 || n >= 100000 {
+		t.Errorf("wrote %d bytes before the deadline, want partial write", n)
+	}
+	if err2 := wc.WriteMessage(BinaryMessage, []byte("hello")); err2 != err {
+		t.Errorf("WriteMessage() after timeout returned %v, want %v", err2, err)
+	}
+}
+
+func TestReadAfterReset(t *testing.T) {
+	s, c := net.Pipe()
+	fc := faultconn.New(s, faultconn.Faults{})
+	rc := newConn(fc, true, 1024, 1024, nil, nil, nil)
+	wc := newConn(c, false, 1024, 1024, nil, nil, nil)
+
+	go func() {
+		w, _ := wc.NextWriter(BinaryMessage)
+		w.Write(make([]byte, 2000))
+		// Reset the connection mid-message.
+		fc.Reset()
+	}()
+
+	_, r, err := rc.NextReader()
+	if err != nil {
+		t.Fatalf("NextReader() returned %v", err)
+	}
+	if _, err := io.Copy(io.Discard, r); err != faultconn.ErrReset {
+		t.Errorf("io.Copy() returned %v, want %v", err, faultconn.ErrReset)
+	}
+	wc.Close()
+}
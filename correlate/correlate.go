@@ -0,0 +1,271 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package correlate matches responses to requests on a WebSocket connection
+// for protocols that carry a correlation ID in each message.
+//
+// The application supplies functions to assign an ID to an outgoing request
+// and to extract the ID from a received response. Call sends a request and
+// waits for the response with the same ID. Received messages that do not
+// match a pending call are passed to a handler.
+//
+// Example:
+//
+//	type Message struct {
+//		ID      string `json:"id,omitempty"`
+//		ReplyTo string `json:"reply_to,omitempty"`
+//		Body    string `json:"body"`
+//	}
+//
+//	c := correlate.New(conn, &correlate.Options[Message]{
+//		SetID:      func(m *Message, id string) { m.ID = id },
+//		ResponseID: func(m *Message) (string, bool) { return m.ReplyTo, m.ReplyTo != "" },
+//		Handler:    func(m Message) { log.Println("event:", m.Body) },
+//	})
+//	go c.Run(ctx)
+//	reply, err := c.Call(ctx, Message{Body: "hello"})
+package correlate
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/internal/lifecycle"
+)
+
+// ErrClosed is returned from Correlator methods after the correlator is closed
+// or the connection fails.
+var ErrClosed = errors.New("correlate: connection closed")
+
+// Options specifies the message format and handlers for a Correlator.
+type Options[M any] struct {
+	// Codec specifies the codec for encoding and decoding messages. If
+	// Codec is nil, the connection's codec is used.
+	Codec websocket.Codec
+
+	// SetID assigns the correlation ID id to the request m. SetID must not
+	// be nil.
+	SetID func(m *M, id string)
+
+	// ResponseID returns the correlation ID of the received message m and
+	// true if m is a response. ResponseID must not be nil.
+	ResponseID func(m *M) (id string, ok bool)
+
+	// NewID returns a new correlation ID. The ID must be unique among the
+	// pending calls. If NewID is nil, IDs are decimal sequence numbers.
+	NewID func() string
+
+	// Handler is called with each received message that does not match a
+	// pending call, including responses that arrive after the call
+	// returned. The handler is called from the goroutine executing Run and
+	// must not call Call. If Handler is nil, unmatched messages are
+	// discarded.
+	Handler func(m M)
+
+	// OnDecodeError is called with messages that cannot be decoded. The
+	// messages are skipped.
+	OnDecodeError func(err *websocket.DecodeError)
+
+	// WriteTimeout specifies the write deadline for each message sent by
+	// the correlator. A write that times out fails the connection. If zero,
+	// writes have no deadline.
+	WriteTimeout time.Duration
+}
+
+// Correlator sends requests and matches received responses to the requests.
+//
+// Call, Send and Close can be called concurrently.
+type Correlator[M any] struct {
+	conn *websocket.TypedConn[M, M]
+	opts Options[M]
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	seq     uint64
+	pending map[string]chan M // calls waiting for a response by ID
+	closed  bool
+	done    chan struct{} // closed when the correlator is closed
+}
+
+// New returns a correlator for conn. The correlator reads messages from conn
+// in Run and writes messages to conn in Call and Send. The application must
+// not otherwise read from or write to conn.
+//
+// The opts argument must not be nil because SetID and ResponseID have no
+// defaults. New panics if opts, opts.SetID or opts.ResponseID is nil.
+func New[M any](conn *websocket.Conn, opts *Options[M]) *Correlator[M] {
+	if opts == nil || opts.SetID == nil || opts.ResponseID == nil {
+		panic("correlate: New called without SetID and ResponseID options")
+	}
+	c := &Correlator[M]{
+		opts:    *opts,
+		pending: make(map[string]chan M),
+		done:    make(chan struct{}),
+	}
+	c.conn = websocket.NewTypedConn(conn, &websocket.TypedConnOptions[M, M]{
+		Codec:             opts.Codec,
+		DecodeErrorAction: websocket.DecodeErrorSkip,
+		OnDecodeError:     opts.OnDecodeError,
+	})
+	return c
+}
+
+// Conn returns the WebSocket connection used by c.
+func (c *Correlator[M]) Conn() *websocket.Conn {
+	return c.conn.Conn()
+}
+
+// Run reads messages from the connection until the connection fails, ctx is
+// done or Close is called. When Run returns, the correlator is closed and
+// pending calls return ErrClosed.
+//
+// Run returns nil if the correlator was closed with Close or the peer closed
+// the connection normally, ctx.Err() if ctx is done and the read error
+// otherwise.
+func (c *Correlator[M]) Run(ctx context.Context) error {
+	stop := lifecycle.Watch(ctx, func() { c.Conn().Close() })
+	err := c.readLoop()
+	stop()
+	c.shutdown()
+	return lifecycle.RunError(ctx, c.isClosed(), err)
+}
+
+func (c *Correlator[M]) readLoop() error {
+	for {
+		m, err := c.conn.Read()
+		if err != nil {
+			return err
+		}
+		if id, ok := c.opts.ResponseID(&m); ok {
+			c.mu.Lock()
+			ch, ok := c.pending[id]
+			delete(c.pending, id)
+			c.mu.Unlock()
+			if ok {
+				ch <- m
+				continue
+			}
+		}
+		if c.opts.Handler != nil {
+			c.opts.Handler(m)
+		}
+	}
+}
+
+func (c *Correlator[M]) shutdown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+	c.pending = make(map[string]chan M)
+}
+
+func (c *Correlator[M]) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// Call assigns a new correlation ID to req, sends req and waits for the
+// response with the same ID.
+//
+// Call returns ctx.Err() if ctx is done before the response is received and
+// ErrClosed if the correlator is closed. The context bounds the wait for the
+// response only; sending the request is bounded by Options.WriteTimeout.
+func (c *Correlator[M]) Call(ctx context.Context, req M) (M, error) {
+	var zero M
+	c.mu.Lock()
+	select {
+	case <-c.done:
+		c.mu.Unlock()
+		return zero, ErrClosed
+	default:
+	}
+	var id string
+	if c.opts.NewID != nil {
+		id = c.opts.NewID()
+	} else {
+		c.seq++
+		id = strconv.FormatUint(c.seq, 10)
+	}
+	ch := make(chan M, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	c.opts.SetID(&req, id)
+	if err := c.write(req); err != nil {
+		c.cancel(id)
+		return zero, err
+	}
+
+	select {
+	case m := <-ch:
+		return m, nil
+	case <-ctx.Done():
+		c.cancel(id)
+		return zero, ctx.Err()
+	case <-c.done:
+		return zero, ErrClosed
+	}
+}
+
+func (c *Correlator[M]) cancel(id string) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+// Send sends m without waiting for a response. Send does not assign a
+// correlation ID to m. Use Send for responses to requests from the peer and
+// for one-way messages. Send returns ctx.Err() without sending m if ctx is
+// done; sending is bounded by Options.WriteTimeout.
+func (c *Correlator[M]) Send(ctx context.Context, m M) error {
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.write(m)
+}
+
+// write sends m with the write timeout.
+func (c *Correlator[M]) write(m M) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	var deadline time.Time
+	if c.opts.WriteTimeout > 0 {
+		deadline = time.Now().Add(c.opts.WriteTimeout)
+	}
+	if err := c.Conn().SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+	return c.conn.Write(m)
+}
+
+// Close sends a close message to the peer, closes the connection and stops
+// Run. Pending calls return ErrClosed.
+func (c *Correlator[M]) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	err := lifecycle.Close(c.Conn(), websocket.CloseNormalClosure, "")
+	c.shutdown()
+	return err
+}
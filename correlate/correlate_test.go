@@ -0,0 +1,198 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package correlate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/wstest"
+)
+
+type message struct {
+	ID      string `json:"id,omitempty"`
+	ReplyTo string `json:"reply_to,omitempty"`
+	Body    string `json:"body"`
+}
+
+func testOptions(handler func(message)) *Options[message] {
+	return &Options[message]{
+		SetID:      func(m *message, id string) { m.ID = id },
+		ResponseID: func(m *message) (string, bool) { return m.ReplyTo, m.ReplyTo != "" },
+		Handler:    handler,
+	}
+}
+
+// serve replies to each request from conn with the body reversed. Requests
+// with the body "ignore" are not answered. Before each reply, serve sends an
+// event that is not a response.
+func serve(conn *websocket.Conn) {
+	for {
+		var m message
+		if err := conn.ReadJSON(&m); err != nil {
+			return
+		}
+		if m.Body == "ignore" {
+			continue
+		}
+		_ = conn.WriteJSON(message{Body: "event"})
+		_ = conn.WriteJSON(message{ReplyTo: m.ID, Body: reverse(m.Body)})
+	}
+}
+
+func reverse(s string) string {
+	b := []rune(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+func TestCall(t *testing.T) {
+	cc, sc, err := wstest.Pair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+	go serve(sc)
+
+	var mu sync.Mutex
+	events := 0
+	c := New(cc, testOptions(func(m message) {
+		mu.Lock()
+		defer mu.Unlock()
+		if m.Body == "event" {
+			events++
+		}
+	}))
+	defer c.Close()
+	go c.Run(context.Background())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body := fmt.Sprintf("abc%d", i)
+			want := reverse(body)
+			reply, err := c.Call(context.Background(), message{Body: body})
+			if err != nil || reply.Body != want {
+				t.Errorf("Call(%s) = %+v, %v, want %s", body, reply, err, want)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if events != 20 {
+		t.Errorf("handler received %d events, want 20", events)
+	}
+}
+
+func TestCallTimeoutAndClose(t *testing.T) {
+	cc, sc, err := wstest.Pair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go serve(sc)
+	c := New(cc, testOptions(nil))
+	runErr := make(chan error, 1)
+	go func() { runErr <- c.Run(context.Background()) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.Call(ctx, message{Body: "ignore"}); err != context.DeadlineExceeded {
+		t.Errorf("Call() returned %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// Pending calls return ErrClosed when the connection fails.
+	errc := make(chan error, 1)
+	go func() {
+		_, err := c.Call(context.Background(), message{Body: "ignore"})
+		errc <- err
+	}()
+	for {
+		c.mu.Lock()
+		n := len(c.pending)
+		c.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	sc.Close()
+	if err := <-errc; err != ErrClosed {
+		t.Errorf("Call() returned %v after connection failure, want %v", err, ErrClosed)
+	}
+	if err := <-runErr; err == nil {
+		t.Error("Run() returned nil after connection failure, want error")
+	}
+	if _, err := c.Call(context.Background(), message{Body: "abc"}); err != ErrClosed {
+		t.Errorf("Call() returned %v on closed correlator, want %v", err, ErrClosed)
+	}
+}
+
+func TestCallSlowWrite(t *testing.T) {
+	cc, sc, err := wstest.Pair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+	c := New(cc, testOptions(nil))
+	defer c.Close()
+	go c.Run(context.Background())
+
+	// The peer does not read until after the deadline of the first call.
+	// The expired deadline must not fail the connection.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	time.AfterFunc(100*time.Millisecond, func() { go serve(sc) })
+	if _, err := c.Call(ctx, message{Body: "abc"}); err != context.DeadlineExceeded {
+		t.Errorf("Call() returned %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if m, err := c.Call(context.Background(), message{Body: "def"}); err != nil || m.Body != "fed" {
+		t.Errorf("Call() = %q, %v after slow write, want fed", m.Body, err)
+	}
+}
+
+func TestWriteTimeout(t *testing.T) {
+	cc, sc, err := wstest.Pair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+	opts := testOptions(nil)
+	opts.WriteTimeout = 20 * time.Millisecond
+	c := New(cc, opts)
+	defer c.Close()
+
+	// The peer does not read.
+	err = c.Send(context.Background(), message{Body: "abc"})
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Errorf("Send() returned %v, want timeout error", err)
+	}
+}
+
+func TestNewRequiresOptions(t *testing.T) {
+	for _, opts := range []*Options[message]{nil, {SetID: testOptions(nil).SetID}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("New(%+v) did not panic", opts)
+				}
+			}()
+			New(nil, opts)
+		}()
+	}
+}
@@ -100,6 +100,10 @@
 // If an application sends ping messages, then the application should set a
 // pong handler to receive the corresponding pong.
 //
+// The Ping method sends a ping and waits for the corresponding pong to measure
+// the round trip time to the peer. The RTT method returns the smoothed round
+// trip time of the pings sent with Ping.
+//
 // The control message handler functions are called from the NextReader,
 // ReadMessage and message reader Read methods. The default close and ping
 // handlers can block these methods for a short time when the handler writes to
@@ -130,8 +134,8 @@
 // SetReadDeadline, ReadMessage, ReadJSON, SetPongHandler, SetPingHandler)
 // concurrently.
 //
-// The Close and WriteControl methods can be called concurrently with all other
-// methods.
+// The Close, WriteControl, Ping and RTT methods can be called concurrently
+// with all other methods.
 //
 // Origin Considerations
 //
@@ -0,0 +1,50 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var socket = flag.String("socket", "/tmp/websocket-echo.sock", "path of the Unix domain socket")
+
+func main() {
+	flag.Parse()
+	log.SetFlags(0)
+
+	u := "ws+unix://" + *socket + ":/echo"
+	log.Printf("connecting to %s", u)
+
+	c, _, err := websocket.DefaultDialer.Dial(u, nil)
+	if err != nil {
+		log.Fatal("dial:", err)
+	}
+	defer c.Close()
+
+	for i := 0; i < 3; i++ {
+		message := time.Now().String()
+		if err := c.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+			log.Fatal("write:", err)
+		}
+		_, p, err := c.ReadMessage()
+		if err != nil {
+			log.Fatal("read:", err)
+		}
+		log.Printf("recv: %s", p)
+		time.Sleep(time.Second)
+	}
+
+	err = c.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	if err != nil {
+		log.Println("write close:", err)
+	}
+}
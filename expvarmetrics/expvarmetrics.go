@@ -0,0 +1,185 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package expvarmetrics implements websocket.Metrics with counters from the
+// expvar package.
+//
+// The counters are kept in an expvar.Map with the following keys:
+//
+//   - frames_read, frames_written: frames by type ("text", "binary",
+//     "continuation", "close", "ping" or "pong")
+//   - messages_read, messages_written: messages by type ("text" or "binary")
+//   - wire_bytes_read, wire_bytes_written: size of all frames on the
+//     network, including frame headers
+//   - app_bytes_read, app_bytes_written: message bytes read and written by
+//     the application
+//   - compressed_messages_read, compressed_messages_written,
+//     compressed_wire_bytes_read, compressed_wire_bytes_written,
+//     compressed_app_bytes_read, compressed_app_bytes_written: the same
+//     counters for compressed messages only
+//   - compression_ratio_read, compression_ratio_written: application bytes
+//     divided by wire bytes for compressed messages
+//   - write_blocked_ns: total time spent writing frames to the network
+//   - close_codes_received, close_codes_sent: close frames by close code
+//
+// Example:
+//
+//	upgrader := websocket.Upgrader{Metrics: expvarmetrics.Publish("websocket")}
+//
+// The counters are then served by the expvar handler at /debug/vars.
+package expvarmetrics
+
+import (
+	"expvar"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Metrics is a websocket.Metrics backed by expvar counters. Metrics is also
+// an expvar.Var that formats all counters as a JSON object.
+type Metrics struct {
+	m *expvar.Map
+
+	framesRead, framesWritten     *expvar.Map
+	messagesRead, messagesWritten *expvar.Map
+	closeReceived, closeSent      *expvar.Map
+	wireRead, wireWritten         *expvar.Int
+	appRead, appWritten           *expvar.Int
+	compressedRead                *expvar.Int
+	compressedWritten             *expvar.Int
+	compressedWireRead            *expvar.Int
+	compressedWireWritten         *expvar.Int
+	compressedAppRead             *expvar.Int
+	compressedAppWritten          *expvar.Int
+	writeBlocked                  *expvar.Int
+}
+
+var _ websocket.Metrics = (*Metrics)(nil)
+
+// New returns new metrics. The metrics are not published. Use expvar.Publish
+// or Publish to publish the metrics.
+func New() *Metrics {
+	m := &Metrics{m: new(expvar.Map).Init()}
+	m.framesRead = m.newMap("frames_read")
+	m.framesWritten = m.newMap("frames_written")
+	m.messagesRead = m.newMap("messages_read")
+	m.messagesWritten = m.newMap("messages_written")
+	m.wireRead = m.newInt("wire_bytes_read")
+	m.wireWritten = m.newInt("wire_bytes_written")
+	m.appRead = m.newInt("app_bytes_read")
+	m.appWritten = m.newInt("app_bytes_written")
+	m.compressedRead = m.newInt("compressed_messages_read")
+	m.compressedWritten = m.newInt("compressed_messages_written")
+	m.compressedWireRead = m.newInt("compressed_wire_bytes_read")
+	m.compressedWireWritten = m.newInt("compressed_wire_bytes_written")
+	m.compressedAppRead = m.newInt("compressed_app_bytes_read")
+	m.compressedAppWritten = m.newInt("compressed_app_bytes_written")
+	m.m.Set("compression_ratio_read", ratio(m.compressedAppRead, m.compressedWireRead))
+	m.m.Set("compression_ratio_written", ratio(m.compressedAppWritten, m.compressedWireWritten))
+	m.writeBlocked = m.newInt("write_blocked_ns")
+	m.closeReceived = m.newMap("close_codes_received")
+	m.closeSent = m.newMap("close_codes_sent")
+	return m
+}
+
+// Publish returns new metrics published with expvar under name. Publish
+// panics if the name is already registered.
+func Publish(name string) *Metrics {
+	m := New()
+	expvar.Publish(name, m)
+	return m
+}
+
+func (m *Metrics) newMap(key string) *expvar.Map {
+	v := new(expvar.Map).Init()
+	m.m.Set(key, v)
+	return v
+}
+
+func (m *Metrics) newInt(key string) *expvar.Int {
+	v := new(expvar.Int)
+	m.m.Set(key, v)
+	return v
+}
+
+func ratio(num, den *expvar.Int) expvar.Func {
+	return func() interface{} {
+		d := den.Value()
+		if d == 0 {
+			return 0.0
+		}
+		return float64(num.Value()) / float64(d)
+	}
+}
+
+// Map returns the map containing the counters.
+func (m *Metrics) Map() *expvar.Map { return m.m }
+
+// String returns the counters as a JSON object.
+func (m *Metrics) String() string { return m.m.String() }
+
+// FrameRead implements websocket.Metrics.
+func (m *Metrics) FrameRead(frameType int, size int64) {
+	m.framesRead.Add(frameTypeName(frameType), 1)
+	m.wireRead.Add(size)
+}
+
+// FrameWritten implements websocket.Metrics.
+func (m *Metrics) FrameWritten(frameType int, size int64, blocked time.Duration) {
+	m.framesWritten.Add(frameTypeName(frameType), 1)
+	m.wireWritten.Add(size)
+	m.writeBlocked.Add(int64(blocked))
+}
+
+// MessageRead implements websocket.Metrics.
+func (m *Metrics) MessageRead(s websocket.MessageStats) {
+	m.messagesRead.Add(frameTypeName(s.Type), 1)
+	m.appRead.Add(s.AppBytes)
+	if s.Compressed {
+		m.compressedRead.Add(1)
+		m.compressedWireRead.Add(s.WireBytes)
+		m.compressedAppRead.Add(s.AppBytes)
+	}
+}
+
+// MessageWritten implements websocket.Metrics.
+func (m *Metrics) MessageWritten(s websocket.MessageStats) {
+	m.messagesWritten.Add(frameTypeName(s.Type), 1)
+	m.appWritten.Add(s.AppBytes)
+	if s.Compressed {
+		m.compressedWritten.Add(1)
+		m.compressedWireWritten.Add(s.WireBytes)
+		m.compressedAppWritten.Add(s.AppBytes)
+	}
+}
+
+// CloseReceived implements websocket.Metrics.
+func (m *Metrics) CloseReceived(code int) {
+	m.closeReceived.Add(strconv.Itoa(code), 1)
+}
+
+// CloseSent implements websocket.Metrics.
+func (m *Metrics) CloseSent(code int) {
+	m.closeSent.Add(strconv.Itoa(code), 1)
+}
+
+func frameTypeName(frameType int) string {
+	switch frameType {
+	case 0:
+		return "continuation"
+	case websocket.TextMessage:
+		return "text"
+	case websocket.BinaryMessage:
+		return "binary"
+	case websocket.CloseMessage:
+		return "close"
+	case websocket.PingMessage:
+		return "ping"
+	case websocket.PongMessage:
+		return "pong"
+	}
+	return strconv.Itoa(frameType)
+}
@@ -0,0 +1,94 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package expvarmetrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/wstest"
+)
+
+func get(t *testing.T, m *Metrics, keys ...string) string {
+	t.Helper()
+	var v expvar.Var = m.Map()
+	for _, k := range keys {
+		v = v.(*expvar.Map).Get(k)
+		if v == nil {
+			t.Fatalf("%s not found", strings.Join(keys, "."))
+		}
+	}
+	return v.String()
+}
+
+func TestMetrics(t *testing.T) {
+	sm, cm := New(), New()
+	client, server, err := wstest.Pair(&wstest.PairOptions{
+		Upgrader: &websocket.Upgrader{EnableCompression: true, Metrics: sm},
+		Dialer:   &websocket.Dialer{EnableCompression: true, Metrics: cm},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	defer server.Close()
+
+	data := []byte(strings.Repeat("hello ", 1000))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = client.WriteMessage(websocket.TextMessage, data)
+		_ = client.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		_, _, _ = client.ReadMessage()
+	}()
+	if _, p, err := server.ReadMessage(); err != nil || len(p) != len(data) {
+		t.Fatalf("ReadMessage() returned %d bytes, %v", len(p), err)
+	}
+	if _, _, err := server.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Fatalf("ReadMessage() returned %v, want close error", err)
+	}
+	<-done
+
+	tests := []struct {
+		m    *Metrics
+		keys []string
+		want string
+	}{
+		{cm, []string{"messages_written", "text"}, "1"},
+		{cm, []string{"compressed_messages_written"}, "1"},
+		{cm, []string{"app_bytes_written"}, "6000"},
+		{cm, []string{"close_codes_sent", "1000"}, "1"},
+		{sm, []string{"messages_read", "text"}, "1"},
+		{sm, []string{"frames_read", "text"}, "1"},
+		{sm, []string{"frames_read", "close"}, "1"},
+		{sm, []string{"app_bytes_read"}, "6000"},
+		{sm, []string{"close_codes_received", "1000"}, "1"},
+		{sm, []string{"close_codes_sent", "1000"}, "1"},
+		{sm, []string{"compressed_wire_bytes_read"}, get(t, cm, "compressed_wire_bytes_written")},
+	}
+	for _, tt := range tests {
+		if got := get(t, tt.m, tt.keys...); got != tt.want {
+			t.Errorf("%s = %s, want %s", strings.Join(tt.keys, "."), got, tt.want)
+		}
+	}
+
+	var v map[string]interface{}
+	if err := json.Unmarshal([]byte(sm.String()), &v); err != nil {
+		t.Fatalf("String() returned invalid JSON: %v", err)
+	}
+	if r, _ := v["compression_ratio_read"].(float64); r <= 1 {
+		t.Errorf("compression_ratio_read = %v, want > 1", v["compression_ratio_read"])
+	}
+}
+
+func TestPublish(t *testing.T) {
+	m := Publish("websocket_test")
+	if expvar.Get("websocket_test") != m {
+		t.Error("metrics not published")
+	}
+}
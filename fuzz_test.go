@@ -0,0 +1,209 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// fuzzFrames returns a stream of frames written by a client or server
+// connection for use as a seed.
+func fuzzFrames(isServer, compress bool) []byte {
+	var b bytes.Buffer
+	c := newTestConn(nil, &b, isServer)
+	if compress {
+		c.newCompressionWriter = compressNoContextTakeover
+		c.enableWriteCompression = true
+	}
+	c.WriteMessage(TextMessage, []byte("hello"))
+	// The message spans multiple frames.
+	c.WriteMessage(BinaryMessage, bytes.Repeat([]byte("abc"), 1000))
+	c.WriteControl(PingMessage, []byte("ping"), time.Time{})
+	c.WriteControl(PongMessage, nil, time.Time{})
+	c.WriteMessage(CloseMessage, FormatCloseMessage(CloseGoingAway, "bye"))
+	return b.Bytes()
+}
+
+func FuzzFrameReader(f *testing.F) {
+	for _, isServer := range []bool{false, true} {
+		for _, compress := range []bool{false, true} {
+			// The frames are read by the peer of the writer.
+			f.Add(fuzzFrames(!isServer, compress), isServer, compress)
+		}
+	}
+	f.Add([]byte{0x81, 0x7e, 0x00}, false, false)
+	f.Add([]byte{0x82, 0x7f, 0x80, 0, 0, 0, 0, 0, 0, 0}, false, false)
+	f.Add([]byte{0x01, 0x01, 'a', 0x89, 0x00, 0x80, 0x01, 'b'}, false, false)
+	f.Add([]byte{0xc1, 0x03, 0xff, 0xff, 0xff}, false, true)
+
+	f.Fuzz(func(t *testing.T, data []byte, isServer, compress bool) {
+		c := newTestConn(bytes.NewReader(data), io.Discard, isServer)
+		if compress {
+			c.newDecompressionReader = decompressNoContextTakeover
+		}
+		c.SetReadLimit(1 << 20)
+		for i := 0; ; i++ {
+			_, r, err := c.NextReader()
+			if err != nil {
+				break
+			}
+			n, err := io.Copy(io.Discard, r)
+			if n > 1<<20 && !compress {
+				t.Fatalf("message %d: read %d bytes, more than read limit", i, n)
+			}
+			if err != nil {
+				break
+			}
+			if i > len(data) {
+				t.Fatalf("read %d messages from %d bytes", i, len(data))
+			}
+		}
+	})
+}
+
+func FuzzClosePayload(f *testing.F) {
+	f.Add([]byte{})
+	f.Add(FormatCloseMessage(CloseNormalClosure, ""))
+	f.Add(FormatCloseMessage(CloseGoingAway, "bye"))
+	f.Add(FormatCloseMessage(CloseProtocolError, "\xff"))
+	f.Add(FormatCloseMessage(999, ""))
+	f.Add([]byte{0x03})
+
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		if len(payload) > maxControlFramePayloadSize {
+			payload = payload[:maxControlFramePayloadSize]
+		}
+		frame := append([]byte{0x88, byte(len(payload))}, payload...)
+		c := newTestConn(bytes.NewReader(frame), io.Discard, false)
+		_, _, err := c.NextReader()
+		var ce *CloseError
+		if !errors.As(err, &ce) {
+			if err == nil || !strings.HasPrefix(err.Error(), "websocket: ") {
+				t.Fatalf("NextReader() returned %v, want close or protocol error", err)
+			}
+			return
+		}
+		if len(payload) < 2 {
+			if ce.Code != CloseNoStatusReceived || ce.Text != "" {
+				t.Fatalf("NextReader() returned %v, want code %d", ce, CloseNoStatusReceived)
+			}
+			return
+		}
+		if !isValidReceivedCloseCode(ce.Code) || !utf8.ValidString(ce.Text) {
+			t.Fatalf("NextReader() returned invalid close error %v", ce)
+		}
+		if p := FormatCloseMessage(ce.Code, ce.Text); !bytes.Equal(p, payload) {
+			t.Fatalf("FormatCloseMessage(%d, %q) = %x, want %x", ce.Code, ce.Text, p, payload)
+		}
+	})
+}
+
+func FuzzParseExtensions(f *testing.F) {
+	for _, tt := range parseExtensionTests {
+		f.Add(tt.value)
+	}
+	f.Add(`permessage-deflate; x="a\"b"`)
+	f.Add(`a; b="\`)
+
+	f.Fuzz(func(t *testing.T, value string) {
+		h := http.Header{"Sec-Websocket-Extensions": {value}}
+		for _, ext := range parseExtensions(h) {
+			for k, v := range ext {
+				if k == "" && v == "" {
+					t.Fatalf("parseExtensions(%q) returned empty extension token", value)
+				}
+				if !isToken(k) && k != "" {
+					t.Fatalf("parseExtensions(%q) returned invalid parameter name %q", value, k)
+				}
+			}
+		}
+	})
+}
+
+func isToken(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isTokenOctet[s[i]] {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func FuzzTokens(f *testing.F) {
+	for _, tt := range tokenListContainsValueTests {
+		f.Add(tt.value)
+	}
+	for _, tt := range equalASCIIFoldTests {
+		f.Add(tt.t)
+	}
+	for _, tt := range isValidChallengeKeyTests {
+		f.Add(tt.key)
+	}
+	f.Add(`"quoted\"string" rest`)
+	f.Add(`"unterminated\`)
+
+	f.Fuzz(func(t *testing.T, s string) {
+		token, rest := nextToken(s)
+		if token+rest != s {
+			t.Fatalf("nextToken(%q) = %q, %q", s, token, rest)
+		}
+		value, rest := nextTokenOrQuoted(s)
+		if !strings.HasSuffix(s, rest) || len(value) > len(s) {
+			t.Fatalf("nextTokenOrQuoted(%q) = %q, %q", s, value, rest)
+		}
+		if !equalASCIIFold(s, s) {
+			t.Fatalf("equalASCIIFold(%q, %q) = false", s, s)
+		}
+		h := http.Header{"Connection": {s}}
+		if tokenListContainsValue(h, "Connection", "upgrade") && !strings.Contains(strings.ToLower(s), "upgrade") {
+			t.Fatalf("tokenListContainsValue(%q, upgrade) = true", s)
+		}
+		if isValidChallengeKey(s) && len(s) != 24 {
+			t.Fatalf("isValidChallengeKey(%q) = true", s)
+		}
+	})
+}
+
+func FuzzCheckResponse(f *testing.F) {
+	const key = "dGhlIHNhbXBsZSBub25jZQ=="
+	accept := computeAcceptKey(key)
+	f.Add("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: " + accept + "\r\n\r\n")
+	f.Add("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: " + accept +
+		"\r\nSec-WebSocket-Extensions: permessage-deflate; server_no_context_takeover; client_no_context_takeover\r\n\r\n")
+	f.Add("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: " + accept +
+		"\r\nSec-WebSocket-Extensions: permessage-deflate\r\n\r\n")
+	f.Add("HTTP/1.1 403 Forbidden\r\nContent-Length: 3\r\n\r\nno\n")
+	f.Add("HTTP/1.1 101 Switching Protocols\r\nUpgrade: h2c\r\n\r\n")
+
+	f.Fuzz(func(t *testing.T, raw string) {
+		req := &http.Request{Method: http.MethodGet}
+		resp, err := http.ReadResponse(bufio.NewReader(strings.NewReader(raw)), req)
+		if err != nil {
+			return
+		}
+		compress, err := checkResponse(resp, key)
+		if err != nil {
+			var he *BadHandshakeError
+			if !errors.As(err, &he) || !errors.Is(err, ErrBadHandshake) || he.Response != resp {
+				t.Fatalf("checkResponse() returned %v, want *BadHandshakeError", err)
+			}
+			return
+		}
+		if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-Websocket-Accept") != accept {
+			t.Fatalf("checkResponse() accepted response with status %d", resp.StatusCode)
+		}
+		if compress && !strings.Contains(strings.Join(resp.Header["Sec-Websocket-Extensions"], ","), "permessage-deflate") {
+			t.Fatal("checkResponse() returned compress without permessage-deflate")
+		}
+	})
+}
@@ -1,6 +1,6 @@
 module github.com/gorilla/websocket
 
-go 1.20
+go 1.21
 
 retract (
     v1.5.2 // tag accidentally overwritten
@@ -0,0 +1,361 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphqlws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/internal/lifecycle"
+)
+
+// ClientOptions specifies options for Connect.
+type ClientOptions struct {
+	// InitPayload is the payload of the connection_init message. The
+	// payload is omitted if nil.
+	InitPayload interface{}
+}
+
+// Client is the client side of a GraphQL over WebSocket connection.
+//
+// Subscription handlers are called from the goroutine executing Run. They
+// must not call Execute.
+//
+// It is safe to call Client methods concurrently.
+type Client struct {
+	conn       *websocket.Conn
+	ackPayload json.RawMessage
+
+	writeMu sync.Mutex
+
+	mu     sync.Mutex
+	seq    uint64
+	subs   map[string]*Subscription
+	closed bool
+}
+
+// Connect sends the connection_init message on conn and waits for the
+// connection_ack message. The deadline of ctx applies to the handshake. If
+// the server rejects the connection, Connect returns the
+// *websocket.CloseError sent by the server. Call Run to receive messages
+// after Connect returns.
+func Connect(ctx context.Context, conn *websocket.Conn, opts *ClientOptions) (*Client, error) {
+	var o ClientOptions
+	if opts != nil {
+		o = *opts
+	}
+	init := &message{Type: typeConnectionInit}
+	if o.InitPayload != nil {
+		p, err := json.Marshal(o.InitPayload)
+		if err != nil {
+			return nil, err
+		}
+		init.Payload = p
+	}
+
+	c := &Client{conn: conn, subs: make(map[string]*Subscription)}
+	stop := lifecycle.Watch(ctx, func() { conn.Close() })
+	err := c.handshake(ctx, init)
+	if stop() {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// handshake sends the connection_init message and waits for the
+// connection_ack message.
+func (c *Client) handshake(ctx context.Context, init *message) error {
+	deadline, _ := ctx.Deadline()
+	_ = c.conn.SetWriteDeadline(deadline)
+	_ = c.conn.SetReadDeadline(deadline)
+	if err := c.write(init); err != nil {
+		return handshakeError(ctx, err)
+	}
+	for {
+		m, err := c.read()
+		if err != nil {
+			c.conn.Close()
+			return handshakeError(ctx, err)
+		}
+		switch m.Type {
+		case typeConnectionAck:
+			_ = c.conn.SetWriteDeadline(time.Time{})
+			_ = c.conn.SetReadDeadline(time.Time{})
+			c.ackPayload = m.Payload
+			return nil
+		case typePing:
+			if err := c.write(&message{Type: typePong}); err != nil {
+				return handshakeError(ctx, err)
+			}
+		case typePong:
+		default:
+			c.closeWith(CloseBadRequest, "Unexpected message "+m.Type)
+			return ErrInvalidMessage
+		}
+	}
+}
+
+// handshakeError returns ctx.Err() if the handshake failed because ctx is
+// done.
+func handshakeError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// Conn returns the WebSocket connection used by c.
+func (c *Client) Conn() *websocket.Conn {
+	return c.conn
+}
+
+// AckPayload returns the payload of the connection_ack message.
+func (c *Client) AckPayload() json.RawMessage {
+	return c.ackPayload
+}
+
+// Run receives messages until the connection fails, ctx is done or the
+// client is closed. Run answers ping messages from the server.
+//
+// Run returns nil if the client was closed with Close or the server closed
+// the connection normally, ctx.Err() if ctx is done and the read error
+// otherwise. When the server closes the connection because of a protocol
+// violation, the error is a *websocket.CloseError with one of the close
+// codes of the protocol.
+func (c *Client) Run(ctx context.Context) error {
+	stop := lifecycle.Watch(ctx, func() { c.conn.Close() })
+	err := c.readLoop()
+	stop()
+	closed := c.isClosed()
+	c.shutdown()
+	c.conn.Close()
+	return lifecycle.RunError(ctx, closed, err)
+}
+
+func (c *Client) readLoop() error {
+	for {
+		m, err := c.read()
+		if err != nil {
+			return err
+		}
+		switch m.Type {
+		case typeNext:
+			var r Result
+			if err := json.Unmarshal(m.Payload, &r); err != nil {
+				c.closeWith(CloseBadRequest, "Invalid message received")
+				return ErrInvalidMessage
+			}
+			if sub := c.subscription(m.ID, false); sub != nil && sub.handler != nil {
+				sub.handler(&r)
+			}
+		case typeError:
+			var errs Errors
+			if err := json.Unmarshal(m.Payload, &errs); err != nil {
+				c.closeWith(CloseBadRequest, "Invalid message received")
+				return ErrInvalidMessage
+			}
+			if sub := c.subscription(m.ID, true); sub != nil {
+				sub.finish(errs)
+			}
+		case typeComplete:
+			if sub := c.subscription(m.ID, true); sub != nil {
+				sub.finish(nil)
+			}
+		case typePing:
+			if err := c.write(&message{Type: typePong}); err != nil {
+				return err
+			}
+		case typePong:
+		default:
+			c.closeWith(CloseBadRequest, "Unexpected message "+m.Type)
+			return ErrInvalidMessage
+		}
+	}
+}
+
+// read reads a message from the connection.
+func (c *Client) read() (*message, error) {
+	messageType, p, err := c.conn.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+	var m message
+	if messageType != websocket.TextMessage || json.Unmarshal(p, &m) != nil || m.Type == "" {
+		c.closeWith(CloseBadRequest, "Invalid message received")
+		return nil, ErrInvalidMessage
+	}
+	return &m, nil
+}
+
+func (c *Client) write(m *message) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteJSON(m)
+}
+
+// subscription returns the subscription with the ID and removes it if
+// remove is true.
+func (c *Client) subscription(id string, remove bool) *Subscription {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sub := c.subs[id]
+	if remove {
+		delete(c.subs, id)
+	}
+	return sub
+}
+
+// Subscribe starts executing the operation on the server. The handler is
+// called with each result. The subscription is done when the server
+// completes the operation, the server reports an error, Unsubscribe is
+// called or the connection is closed.
+func (c *Client) Subscribe(req *Request, handler func(*Result)) (*Subscription, error) {
+	p, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, ErrClosed
+	}
+	c.seq++
+	sub := &Subscription{
+		c:       c,
+		id:      strconv.FormatUint(c.seq, 10),
+		handler: handler,
+		done:    make(chan struct{}),
+	}
+	c.subs[sub.id] = sub
+	c.mu.Unlock()
+
+	if err := c.write(&message{Type: typeSubscribe, ID: sub.id, Payload: p}); err != nil {
+		c.subscription(sub.id, true)
+		return nil, err
+	}
+	return sub, nil
+}
+
+// Execute executes a query or mutation and returns its result. If the
+// server reports an error, Execute returns Errors. If ctx is done before
+// the operation completes, Execute unsubscribes and returns ctx.Err().
+func (c *Client) Execute(ctx context.Context, req *Request) (*Result, error) {
+	var result *Result
+	sub, err := c.Subscribe(req, func(r *Result) {
+		if result == nil {
+			result = r
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case <-sub.Done():
+	case <-ctx.Done():
+		sub.Unsubscribe()
+		return nil, ctx.Err()
+	}
+	if err := sub.Err(); err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, errors.New("graphqlws: operation completed without a result")
+	}
+	return result, nil
+}
+
+// Close closes the connection. Subscriptions in progress are done with
+// ErrClosed.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+	c.closeWith(websocket.CloseNormalClosure, "")
+	c.shutdown()
+	return nil
+}
+
+func (c *Client) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// shutdown marks the client closed and finishes the subscriptions.
+func (c *Client) shutdown() {
+	c.mu.Lock()
+	c.closed = true
+	subs := c.subs
+	c.subs = make(map[string]*Subscription)
+	c.mu.Unlock()
+	for _, sub := range subs {
+		sub.finish(ErrClosed)
+	}
+}
+
+// closeWith sends a close message with the code and reason and closes the
+// connection.
+func (c *Client) closeWith(code int, reason string) {
+	_ = lifecycle.Close(c.conn, code, reason)
+}
+
+// Subscription is an operation started with Client.Subscribe.
+type Subscription struct {
+	c       *Client
+	id      string
+	handler func(*Result)
+
+	once sync.Once
+	err  error
+	done chan struct{}
+}
+
+// ID returns the operation ID.
+func (s *Subscription) ID() string {
+	return s.id
+}
+
+// Done returns a channel that is closed when the subscription is done.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the error that ended the subscription after Done is closed.
+// Err returns nil if the server completed the operation or Unsubscribe was
+// called, Errors if the server reported an error and ErrClosed if the
+// connection was closed.
+func (s *Subscription) Err() error {
+	<-s.done
+	return s.err
+}
+
+func (s *Subscription) finish(err error) {
+	s.once.Do(func() {
+		s.err = err
+		close(s.done)
+	})
+}
+
+// Unsubscribe sends the complete message for the operation to the server.
+// Unsubscribe does nothing if the subscription is already done.
+func (s *Subscription) Unsubscribe() error {
+	if s.c.subscription(s.id, true) == nil {
+		return nil
+	}
+	s.finish(nil)
+	return s.c.write(&message{Type: typeComplete, ID: s.id})
+}
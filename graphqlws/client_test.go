@@ -0,0 +1,213 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphqlws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/wstest"
+)
+
+func newTestServer(canceled chan<- struct{}) *Server {
+	return NewServer(&ServerOptions{
+		Executor: testExecutor(canceled),
+		OnConnect: func(ctx context.Context, payload json.RawMessage) (context.Context, error) {
+			var p struct{ Token string }
+			if err := json.Unmarshal(payload, &p); err != nil || p.Token == "" {
+				return nil, errors.New("no token")
+			}
+			return context.WithValue(ctx, userKey{}, p.Token), nil
+		},
+	})
+}
+
+func dial(t *testing.T, srv *Server, opts *ClientOptions) (*Client, error) {
+	t.Helper()
+	s := wstest.NewServer(srv)
+	t.Cleanup(func() { s.Close() })
+	d := s.Dialer(&websocket.Dialer{Subprotocols: []string{Subprotocol}})
+	ws, _, err := d.Dial(wstest.DefaultURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Subprotocol() != Subprotocol {
+		t.Errorf("Subprotocol() = %q, want %q", ws.Subprotocol(), Subprotocol)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return Connect(ctx, ws, opts)
+}
+
+// connect connects a client and runs it until the test ends.
+func connect(t *testing.T, srv *Server) (*Client, <-chan error) {
+	t.Helper()
+	c, err := dial(t, srv, &ClientOptions{InitPayload: map[string]string{"token": "alice"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- c.Run(context.Background()) }()
+	t.Cleanup(func() { c.Close() })
+	return c, errc
+}
+
+func TestExecute(t *testing.T) {
+	c, _ := connect(t, newTestServer(nil))
+	ctx := context.Background()
+	for _, tt := range []struct {
+		query, data string
+	}{
+		{"{ hello }", `{"hello":"world"}`},
+		{"{ user }", `{"user":"alice"}`},
+	} {
+		r, err := c.Execute(ctx, &Request{Query: tt.query})
+		if err != nil {
+			t.Errorf("Execute(%q) returned error %v", tt.query, err)
+			continue
+		}
+		if string(r.Data) != tt.data {
+			t.Errorf("Execute(%q) data = %s, want %s", tt.query, r.Data, tt.data)
+		}
+	}
+
+	_, err := c.Execute(ctx, &Request{Query: "{"})
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Message != "syntax error" || len(errs[0].Locations) != 1 {
+		t.Errorf("Execute() returned %v, want syntax error", err)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	canceled := make(chan struct{}, 1)
+	c, _ := connect(t, newTestServer(canceled))
+
+	var data []string
+	req := &Request{Query: "subscription { count }", Variables: map[string]interface{}{"n": 3}}
+	sub, err := c.Subscribe(req, func(r *Result) { data = append(data, string(r.Data)) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil", err)
+	}
+	if len(data) != 3 || data[2] != `{"count":3}` {
+		t.Errorf("results = %v, want 3 counts", data)
+	}
+
+	// Unsubscribing cancels the operation on the server.
+	results := make(chan *Result, 1)
+	sub, err = c.Subscribe(&Request{Query: "subscription { count }"}, func(r *Result) {
+		select {
+		case results <- r:
+		default:
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-results:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for result")
+	}
+	if err := sub.Unsubscribe(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("operation was not canceled")
+	}
+	if err := sub.Err(); err != nil {
+		t.Errorf("Err() = %v after Unsubscribe, want nil", err)
+	}
+}
+
+func TestConnectForbidden(t *testing.T) {
+	_, err := dial(t, newTestServer(nil), nil)
+	if !websocket.IsCloseError(err, CloseForbidden) {
+		t.Errorf("Connect() returned %v, want close error %d", err, CloseForbidden)
+	}
+}
+
+func TestClientClose(t *testing.T) {
+	c, errc := connect(t, newTestServer(nil))
+	sub, err := c.Subscribe(&Request{Query: "subscription { count }"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	if err := <-errc; err != nil {
+		t.Errorf("Run() returned %v after Close, want nil", err)
+	}
+	if err := sub.Err(); err != ErrClosed {
+		t.Errorf("Err() = %v, want %v", err, ErrClosed)
+	}
+	if _, err := c.Subscribe(&Request{Query: "{ hello }"}, nil); err != ErrClosed {
+		t.Errorf("Subscribe() returned %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestClientPing(t *testing.T) {
+	cc, sc, err := wstest.Pair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+	go func() {
+		var m message
+		if err := sc.ReadJSON(&m); err != nil || m.Type != typeConnectionInit {
+			return
+		}
+		sc.WriteJSON(&message{Type: typeConnectionAck, Payload: json.RawMessage(`{"v":1}`)})
+		sc.WriteJSON(&message{Type: typePing})
+	}()
+	c, err := Connect(context.Background(), cc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if p := string(c.AckPayload()); p != `{"v":1}` {
+		t.Errorf("AckPayload() = %s", p)
+	}
+	go c.Run(context.Background())
+	var m message
+	if err := sc.ReadJSON(&m); err != nil || m.Type != typePong {
+		t.Errorf("reply = %+v, %v, want pong", m, err)
+	}
+	// Read the close message sent by Close.
+	go sc.ReadMessage()
+}
+
+func TestClientRunServerClose(t *testing.T) {
+	c, errc := connect(t, newTestServer(nil))
+	// The server closes the connection because of the invalid message.
+	if err := c.Conn().WriteMessage(websocket.TextMessage, []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; !websocket.IsCloseError(err, CloseBadRequest) {
+		t.Errorf("Run() returned %v, want close error %d", err, CloseBadRequest)
+	}
+}
+
+func TestConnectContext(t *testing.T) {
+	cc, sc, err := wstest.Pair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+	// The server reads the connection_init message and does not reply.
+	go sc.ReadMessage()
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if _, err := Connect(ctx, cc, nil); err != context.Canceled {
+		t.Errorf("Connect() returned %v, want %v", err, context.Canceled)
+	}
+}
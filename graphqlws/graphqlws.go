@@ -0,0 +1,169 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package graphqlws implements the GraphQL over WebSocket protocol with the
+// graphql-transport-ws subprotocol.
+//
+// A Server serves connections with an Executor supplied by the application,
+// so any GraphQL engine can be used. A Client executes operations on a
+// server.
+//
+// The protocol is specified at
+// https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md.
+package graphqlws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Subprotocol is the WebSocket subprotocol name of the protocol.
+const Subprotocol = "graphql-transport-ws"
+
+// Close codes specified by the protocol.
+const (
+	// CloseBadRequest is used when a message is invalid or has an
+	// unexpected type.
+	CloseBadRequest = 4400
+
+	// CloseUnauthorized is used when the client subscribes before the
+	// connection is acknowledged.
+	CloseUnauthorized = 4401
+
+	// CloseForbidden is used when the server rejects the connection_init
+	// message.
+	CloseForbidden = 4403
+
+	// CloseInitTimeout is used when the client does not send the
+	// connection_init message in time.
+	CloseInitTimeout = 4408
+
+	// CloseSubscriberExists is used when the client subscribes with the ID
+	// of an operation in progress.
+	CloseSubscriberExists = 4409
+
+	// CloseTooManyInitRequests is used when the client sends more than one
+	// connection_init message.
+	CloseTooManyInitRequests = 4429
+)
+
+var (
+	// ErrClosed is returned from Client methods after the connection is
+	// closed.
+	ErrClosed = errors.New("graphqlws: connection closed")
+
+	// ErrInvalidMessage is returned when the client receives an invalid
+	// message.
+	ErrInvalidMessage = errors.New("graphqlws: invalid message")
+)
+
+// Message types.
+const (
+	typeConnectionInit = "connection_init"
+	typeConnectionAck  = "connection_ack"
+	typePing           = "ping"
+	typePong           = "pong"
+	typeSubscribe      = "subscribe"
+	typeNext           = "next"
+	typeError          = "error"
+	typeComplete       = "complete"
+)
+
+type message struct {
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Request is a GraphQL operation requested by the client.
+type Request struct {
+	OperationName string                 `json:"operationName,omitempty"`
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Result is a GraphQL execution result.
+type Result struct {
+	Data       json.RawMessage        `json:"data,omitempty"`
+	Errors     Errors                 `json:"errors,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Location is a location in a GraphQL document.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error is a GraphQL error.
+type Error struct {
+	Message    string                 `json:"message"`
+	Locations  []Location             `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return "graphql: " + e.Message
+}
+
+// Errors is a list of GraphQL errors. An executor returns Errors to report
+// errors that prevented the execution of an operation, such as validation
+// errors. The client returns Errors when the server reports such errors.
+type Errors []*Error
+
+func (e Errors) Error() string {
+	if len(e) == 0 {
+		return "graphql: no errors"
+	}
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Message
+	}
+	return "graphql: " + strings.Join(msgs, "; ")
+}
+
+// Executor executes GraphQL operations.
+type Executor interface {
+	// Execute starts executing the operation and returns a channel of
+	// results. For queries and mutations the channel yields one result; for
+	// subscriptions it yields results until the subscription ends. The
+	// executor closes the channel when the operation is complete.
+	//
+	// The context is canceled when the client completes the operation or the
+	// connection is closed. The executor must then stop sending results and
+	// close the channel. The server does not wait for the channel to be
+	// closed after the context is canceled; results received after that are
+	// discarded.
+	//
+	// If the operation cannot be executed, Execute returns an error. The
+	// error is sent to the client in an error message. If the error is not
+	// Errors, the message has one error with the text of the error.
+	Execute(ctx context.Context, req *Request) (<-chan *Result, error)
+}
+
+// ExecutorFunc is an adapter to allow the use of ordinary functions as
+// executors.
+type ExecutorFunc func(ctx context.Context, req *Request) (<-chan *Result, error)
+
+// Execute calls f(ctx, req).
+func (f ExecutorFunc) Execute(ctx context.Context, req *Request) (<-chan *Result, error) {
+	return f(ctx, req)
+}
+
+// toErrors converts an error returned by an executor to Errors.
+func toErrors(err error) Errors {
+	var errs Errors
+	if errors.As(err, &errs) {
+		return errs
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return Errors{e}
+	}
+	return Errors{{Message: err.Error()}}
+}
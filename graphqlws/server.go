@@ -0,0 +1,281 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphqlws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/internal/lifecycle"
+)
+
+// ServerOptions specifies options for a Server.
+type ServerOptions struct {
+	// Executor executes the operations. Executor must not be nil.
+	Executor Executor
+
+	// Upgrader upgrades HTTP requests in ServeHTTP. If nil, an upgrader
+	// with the Subprotocol is used.
+	Upgrader *websocket.Upgrader
+
+	// ConnectionInitTimeout specifies how long the server waits for the
+	// connection_init message. If zero, a default of 3 seconds is used.
+	ConnectionInitTimeout time.Duration
+
+	// OnConnect is called with the payload of the connection_init message.
+	// If OnConnect returns an error, the server closes the connection with
+	// the code CloseForbidden. Otherwise the returned context is used for
+	// the operations on the connection; return ctx to use it unchanged. If
+	// nil, all connections are acknowledged.
+	OnConnect func(ctx context.Context, payload json.RawMessage) (context.Context, error)
+}
+
+const defaultConnectionInitTimeout = 3 * time.Second
+
+// Server serves GraphQL over WebSocket connections.
+type Server struct {
+	opts     ServerOptions
+	upgrader *websocket.Upgrader
+}
+
+// NewServer returns a new server.
+func NewServer(opts *ServerOptions) *Server {
+	srv := &Server{opts: *opts}
+	if srv.opts.ConnectionInitTimeout <= 0 {
+		srv.opts.ConnectionInitTimeout = defaultConnectionInitTimeout
+	}
+	srv.upgrader = srv.opts.Upgrader
+	if srv.upgrader == nil {
+		srv.upgrader = &websocket.Upgrader{Subprotocols: []string{Subprotocol}}
+	}
+	return srv
+}
+
+// ServeHTTP upgrades the request to the WebSocket protocol and serves the
+// connection until it is closed.
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := srv.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	_ = srv.ServeConn(r.Context(), conn)
+}
+
+// ServeConn serves the connection until the connection fails, the server
+// closes the connection because of a protocol violation or ctx is done.
+// The application must not read from or write to conn.
+//
+// ServeConn returns nil if the client closed the connection normally,
+// ctx.Err() if ctx is done and the error otherwise. If the server closed the
+// connection, the error is a *websocket.CloseError with the close code and
+// reason sent to the client.
+func (srv *Server) ServeConn(ctx context.Context, conn *websocket.Conn) error {
+	stop := lifecycle.Watch(ctx, func() { conn.Close() })
+	opctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s := &session{
+		srv:  srv,
+		conn: conn,
+		ctx:  opctx,
+		ops:  make(map[string]context.CancelFunc),
+	}
+	timer := time.AfterFunc(srv.opts.ConnectionInitTimeout, func() {
+		s.mu.Lock()
+		initialized := s.initialized
+		s.mu.Unlock()
+		if !initialized {
+			s.close(CloseInitTimeout, "Connection initialisation timeout")
+		}
+	})
+	defer timer.Stop()
+
+	err := s.readLoop()
+	stop()
+	conn.Close()
+	cancel()
+	s.mu.Lock()
+	for _, cancel := range s.ops {
+		cancel()
+	}
+	closeErr := s.closeErr
+	s.mu.Unlock()
+	s.wg.Wait()
+
+	if closeErr != nil {
+		return closeErr
+	}
+	return lifecycle.RunError(ctx, false, err)
+}
+
+// session is the state of a connection.
+type session struct {
+	srv  *Server
+	conn *websocket.Conn
+	ctx  context.Context // context for operations
+	wg   sync.WaitGroup  // counts operation goroutines
+
+	writeMu sync.Mutex
+
+	mu          sync.Mutex
+	initialized bool // connection_init received
+	acked       bool // connection_ack sent
+	ops         map[string]context.CancelFunc
+	closeErr    *websocket.CloseError // set when the server closes the connection
+}
+
+func (s *session) readLoop() error {
+	for {
+		messageType, p, err := s.conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		var m message
+		if messageType != websocket.TextMessage || json.Unmarshal(p, &m) != nil || m.Type == "" {
+			s.close(CloseBadRequest, "Invalid message received")
+			continue
+		}
+		s.handle(&m)
+	}
+}
+
+func (s *session) handle(m *message) {
+	switch m.Type {
+	case typeConnectionInit:
+		s.mu.Lock()
+		initialized := s.initialized
+		s.initialized = true
+		s.mu.Unlock()
+		if initialized {
+			s.close(CloseTooManyInitRequests, "Too many initialisation requests")
+			return
+		}
+		if f := s.srv.opts.OnConnect; f != nil {
+			ctx, err := f(s.ctx, m.Payload)
+			if err != nil {
+				s.close(CloseForbidden, "Forbidden")
+				return
+			}
+			s.ctx = ctx
+		}
+		s.mu.Lock()
+		s.acked = true
+		s.mu.Unlock()
+		s.write(&message{Type: typeConnectionAck})
+	case typePing:
+		s.write(&message{Type: typePong})
+	case typePong:
+	case typeSubscribe:
+		s.subscribe(m)
+	case typeComplete:
+		s.mu.Lock()
+		if cancel, ok := s.ops[m.ID]; ok {
+			cancel()
+			delete(s.ops, m.ID)
+		}
+		s.mu.Unlock()
+	default:
+		s.close(CloseBadRequest, "Invalid message received")
+	}
+}
+
+func (s *session) subscribe(m *message) {
+	var req Request
+	if m.ID == "" || json.Unmarshal(m.Payload, &req) != nil || req.Query == "" {
+		s.close(CloseBadRequest, "Invalid message received")
+		return
+	}
+	s.mu.Lock()
+	if !s.acked {
+		s.mu.Unlock()
+		s.close(CloseUnauthorized, "Unauthorized")
+		return
+	}
+	if _, ok := s.ops[m.ID]; ok {
+		s.mu.Unlock()
+		s.close(CloseSubscriberExists, "Subscriber for "+m.ID+" already exists")
+		return
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.ops[m.ID] = cancel
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.execute(ctx, m.ID, &req)
+		s.mu.Lock()
+		// Remove the operation unless the client completed it and reused
+		// the ID.
+		if ctx.Err() == nil {
+			delete(s.ops, m.ID)
+		}
+		s.mu.Unlock()
+		cancel()
+	}()
+}
+
+// execute executes an operation and sends the results to the client.
+func (s *session) execute(ctx context.Context, id string, req *Request) {
+	results, err := s.srv.opts.Executor.Execute(ctx, req)
+	if err != nil {
+		if ctx.Err() == nil {
+			p, _ := json.Marshal(toErrors(err))
+			s.write(&message{Type: typeError, ID: id, Payload: p})
+		}
+		return
+	}
+	for {
+		var r *Result
+		var ok bool
+		select {
+		case r, ok = <-results:
+		case <-ctx.Done():
+			// Do not wait for an executor that ignores the cancellation.
+			// Drain the results until the executor closes the channel.
+			go func() {
+				for range results {
+				}
+			}()
+			return
+		}
+		if !ok {
+			break
+		}
+		if ctx.Err() != nil {
+			continue
+		}
+		p, err := json.Marshal(r)
+		if err != nil {
+			p, _ = json.Marshal(&Result{Errors: Errors{{Message: err.Error()}}})
+		}
+		s.write(&message{Type: typeNext, ID: id, Payload: p})
+	}
+	if ctx.Err() == nil {
+		s.write(&message{Type: typeComplete, ID: id})
+	}
+}
+
+func (s *session) write(m *message) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	// Write errors are detected by the read loop.
+	_ = s.conn.WriteJSON(m)
+}
+
+// close closes the connection with the close code and reason.
+func (s *session) close(code int, reason string) {
+	s.mu.Lock()
+	if s.closeErr != nil {
+		s.mu.Unlock()
+		return
+	}
+	s.closeErr = &websocket.CloseError{Code: code, Text: reason}
+	s.mu.Unlock()
+	_ = lifecycle.Close(s.conn, code, reason)
+}
@@ -0,0 +1,182 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphqlws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/wstest"
+)
+
+type userKey struct{}
+
+// testExecutor executes the queries "{ hello }", "{ user }" and
+// "subscription { count }". The count subscription sends the variable n
+// results or runs until canceled if n is zero.
+func testExecutor(canceled chan<- struct{}) Executor {
+	return ExecutorFunc(func(ctx context.Context, req *Request) (<-chan *Result, error) {
+		results := make(chan *Result, 1)
+		switch req.Query {
+		case "{ hello }":
+			results <- &Result{Data: json.RawMessage(`{"hello":"world"}`)}
+			close(results)
+		case "{ user }":
+			user, _ := ctx.Value(userKey{}).(string)
+			data, _ := json.Marshal(map[string]string{"user": user})
+			results <- &Result{Data: data}
+			close(results)
+		case "subscription { count }":
+			n, _ := req.Variables["n"].(float64)
+			go func() {
+				defer close(results)
+				for i := 1; n == 0 || i <= int(n); i++ {
+					select {
+					case results <- &Result{Data: json.RawMessage(`{"count":` + strconv.Itoa(i) + `}`)}:
+					case <-ctx.Done():
+						if canceled != nil {
+							canceled <- struct{}{}
+						}
+						return
+					}
+				}
+			}()
+		default:
+			return nil, Errors{{Message: "syntax error", Locations: []Location{{Line: 1, Column: 1}}}}
+		}
+		return results, nil
+	})
+}
+
+func TestServerCloseCodes(t *testing.T) {
+	init := `{"type":"connection_init"}`
+	sub := `{"type":"subscribe","id":"1","payload":{"query":"subscription { count }"}}`
+	tests := []struct {
+		name     string
+		opts     ServerOptions
+		messages []string
+		code     int
+		reason   string
+	}{
+		{"init timeout", ServerOptions{ConnectionInitTimeout: 50 * time.Millisecond}, nil,
+			CloseInitTimeout, "Connection initialisation timeout"},
+		{"invalid json", ServerOptions{}, []string{`{"type":`},
+			CloseBadRequest, "Invalid message received"},
+		{"unknown type", ServerOptions{}, []string{init, `{"type":"next","id":"1"}`},
+			CloseBadRequest, "Invalid message received"},
+		{"no query", ServerOptions{}, []string{init, `{"type":"subscribe","id":"1","payload":{}}`},
+			CloseBadRequest, "Invalid message received"},
+		{"unauthorized", ServerOptions{}, []string{sub},
+			CloseUnauthorized, "Unauthorized"},
+		{"forbidden", ServerOptions{OnConnect: func(ctx context.Context, payload json.RawMessage) (context.Context, error) {
+			return nil, errors.New("no token")
+		}}, []string{init},
+			CloseForbidden, "Forbidden"},
+		{"subscriber exists", ServerOptions{}, []string{init, sub, sub},
+			CloseSubscriberExists, "Subscriber for 1 already exists"},
+		{"too many init", ServerOptions{}, []string{init, init},
+			CloseTooManyInitRequests, "Too many initialisation requests"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc, sc, err := wstest.Pair(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer cc.Close()
+			tt.opts.Executor = testExecutor(nil)
+			srv := NewServer(&tt.opts)
+			serveErr := make(chan error, 1)
+			go func() { serveErr <- srv.ServeConn(context.Background(), sc) }()
+
+			// Read concurrently with the writes because the pipe is
+			// synchronous.
+			readErr := make(chan error, 1)
+			go func() {
+				for {
+					if _, _, err := cc.ReadMessage(); err != nil {
+						readErr <- err
+						return
+					}
+				}
+			}()
+			for _, m := range tt.messages {
+				if err := cc.WriteMessage(websocket.TextMessage, []byte(m)); err != nil {
+					break
+				}
+			}
+
+			var ce *websocket.CloseError
+			if err := <-readErr; !errors.As(err, &ce) || ce.Code != tt.code || ce.Text != tt.reason {
+				t.Errorf("read returned %v, want close %d %q", err, tt.code, tt.reason)
+			}
+			if err := <-serveErr; !websocket.IsCloseError(err, tt.code) {
+				t.Errorf("ServeConn() returned %v, want close error %d", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestServerPing(t *testing.T) {
+	cc, sc, err := wstest.Pair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	srv := NewServer(&ServerOptions{Executor: testExecutor(nil)})
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.ServeConn(context.Background(), sc) }()
+
+	// Pings are answered before the connection is initialized.
+	if err := cc.WriteJSON(&message{Type: typePing}); err != nil {
+		t.Fatal(err)
+	}
+	var m message
+	if err := cc.ReadJSON(&m); err != nil || m.Type != typePong {
+		t.Fatalf("reply = %+v, %v, want pong", m, err)
+	}
+	cc.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	// Read the close message sent in reply.
+	cc.ReadMessage()
+	if err := <-serveErr; err != nil {
+		t.Errorf("ServeConn() returned %v, want nil", err)
+	}
+}
+
+func TestServerExecutorIgnoresCancel(t *testing.T) {
+	cc, sc, err := wstest.Pair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	srv := NewServer(&ServerOptions{Executor: ExecutorFunc(func(ctx context.Context, req *Request) (<-chan *Result, error) {
+		// The channel is never closed.
+		close(started)
+		return make(chan *Result), nil
+	})})
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.ServeConn(context.Background(), sc) }()
+
+	cc.WriteJSON(&message{Type: typeConnectionInit})
+	var m message
+	if err := cc.ReadJSON(&m); err != nil || m.Type != typeConnectionAck {
+		t.Fatalf("reply = %+v, %v, want connection_ack", m, err)
+	}
+	cc.WriteJSON(&message{Type: typeSubscribe, ID: "1", Payload: json.RawMessage(`{"query":"{ hello }"}`)})
+	<-started
+	cc.Close()
+
+	select {
+	case <-serveErr:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ServeConn() did not return after the connection was closed")
+	}
+}
@@ -0,0 +1,428 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package hub broadcasts messages to WebSocket connections grouped by topic.
+//
+// Each subscriber has a bounded queue of outgoing messages and a goroutine
+// that writes the queued messages to the connection. A message published to
+// a topic is encoded once with websocket.NewPreparedMessage and the encoded
+// frames are shared by all subscribers with the same compression setting.
+//
+// The application reads the connection of each subscriber as usual and calls
+// Subscriber.Close when reading fails:
+//
+//	s, err := h.Subscribe(conn, "news")
+//	if err != nil {
+//		return
+//	}
+//	defer s.Close()
+//	for {
+//		if _, _, err := conn.ReadMessage(); err != nil {
+//			return
+//		}
+//	}
+package hub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ErrClosed is returned when subscribing or publishing to a hub after
+// Shutdown is called.
+var ErrClosed = errors.New("hub: closed")
+
+// Policy specifies how a hub handles a subscriber whose queue is full.
+type Policy int
+
+const (
+	// Disconnect closes the subscriber with the close code
+	// websocket.CloseTryAgainLater. Queued messages are discarded.
+	Disconnect Policy = iota
+
+	// DropNewest discards the message that does not fit in the queue.
+	DropNewest
+
+	// DropOldest discards the oldest queued message to make room for the
+	// new message.
+	DropOldest
+)
+
+// Options specifies options for a Hub.
+type Options struct {
+	// QueueSize specifies the maximum number of queued messages for each
+	// subscriber. If zero, a default of 64 is used.
+	QueueSize int
+
+	// SlowConsumerPolicy specifies how the hub handles a subscriber whose
+	// queue is full. The default is Disconnect.
+	SlowConsumerPolicy Policy
+
+	// OnSlowConsumer is called when a message is not queued for a
+	// subscriber because the subscriber's queue is full. The function is
+	// called from the goroutine publishing the message.
+	OnSlowConsumer func(s *Subscriber)
+
+	// WriteTimeout specifies the deadline for writing each message to a
+	// subscriber. If zero, a default of 10 seconds is used.
+	WriteTimeout time.Duration
+}
+
+const (
+	defaultQueueSize    = 64
+	defaultWriteTimeout = 10 * time.Second
+)
+
+// Hub maintains a set of subscribers and broadcasts messages to the
+// subscribers of a topic.
+//
+// It is safe to call Hub and Subscriber methods concurrently.
+type Hub struct {
+	opts Options
+
+	mu     sync.RWMutex
+	subs   map[*Subscriber]struct{}
+	topics map[string]map[*Subscriber]struct{}
+	closed bool
+	wg     sync.WaitGroup // counts running subscriber write loops
+}
+
+// New returns a new hub. If opts is nil, default options are used.
+func New(opts *Options) *Hub {
+	h := &Hub{
+		subs:   make(map[*Subscriber]struct{}),
+		topics: make(map[string]map[*Subscriber]struct{}),
+	}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.QueueSize <= 0 {
+		h.opts.QueueSize = defaultQueueSize
+	}
+	if h.opts.WriteTimeout <= 0 {
+		h.opts.WriteTimeout = defaultWriteTimeout
+	}
+	return h
+}
+
+// Subscriber is a connection subscribed to topics in a hub.
+type Subscriber struct {
+	hub  *Hub
+	conn *websocket.Conn
+	send chan *websocket.PreparedMessage
+	done chan struct{} // closed when the write loop exits
+
+	// The following fields are protected by hub.mu.
+	topics map[string]struct{}
+
+	mu        sync.Mutex // protects the fields below and sends on send
+	closed    bool       // send is closed
+	discard   bool       // discard queued messages on close
+	closeCode int        // close code sent when the write loop exits
+}
+
+// Subscribe adds conn to the hub and subscribes it to the topics. The hub
+// writes messages to conn until the subscriber is closed or writing fails.
+// The application must not write to conn except with the Subscriber Send
+// method and the connection WriteControl method.
+func (h *Hub) Subscribe(conn *websocket.Conn, topics ...string) (*Subscriber, error) {
+	s := &Subscriber{
+		hub:       h,
+		conn:      conn,
+		send:      make(chan *websocket.PreparedMessage, h.opts.QueueSize),
+		done:      make(chan struct{}),
+		topics:    make(map[string]struct{}),
+		closeCode: websocket.CloseNormalClosure,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, ErrClosed
+	}
+	h.subs[s] = struct{}{}
+	for _, topic := range topics {
+		h.joinLocked(s, topic)
+	}
+	h.wg.Add(1)
+	go s.writeLoop()
+	return s, nil
+}
+
+func (h *Hub) joinLocked(s *Subscriber, topic string) {
+	m := h.topics[topic]
+	if m == nil {
+		m = make(map[*Subscriber]struct{})
+		h.topics[topic] = m
+	}
+	m[s] = struct{}{}
+	s.topics[topic] = struct{}{}
+}
+
+func (h *Hub) leaveLocked(s *Subscriber, topic string) {
+	if m := h.topics[topic]; m != nil {
+		delete(m, s)
+		if len(m) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+	delete(s.topics, topic)
+}
+
+// remove removes s from the hub.
+func (h *Hub) remove(s *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for topic := range s.topics {
+		h.leaveLocked(s, topic)
+	}
+	delete(h.subs, s)
+}
+
+// Publish sends a message to the subscribers of topic. The message is
+// encoded once for each compression setting used by the subscribers.
+func (h *Hub) Publish(topic string, messageType int, data []byte) error {
+	pm, err := websocket.NewPreparedMessage(messageType, data)
+	if err != nil {
+		return err
+	}
+	return h.PublishPrepared(topic, pm)
+}
+
+// PublishPrepared sends a prepared message to the subscribers of topic.
+func (h *Hub) PublishPrepared(topic string, pm *websocket.PreparedMessage) error {
+	h.mu.RLock()
+	if h.closed {
+		h.mu.RUnlock()
+		return ErrClosed
+	}
+	subs := make([]*Subscriber, 0, len(h.topics[topic]))
+	for s := range h.topics[topic] {
+		subs = append(subs, s)
+	}
+	h.mu.RUnlock()
+
+	for _, s := range subs {
+		s.enqueue(pm)
+	}
+	return nil
+}
+
+// Broadcast sends a message to all subscribers.
+func (h *Hub) Broadcast(messageType int, data []byte) error {
+	pm, err := websocket.NewPreparedMessage(messageType, data)
+	if err != nil {
+		return err
+	}
+	h.mu.RLock()
+	if h.closed {
+		h.mu.RUnlock()
+		return ErrClosed
+	}
+	subs := make([]*Subscriber, 0, len(h.subs))
+	for s := range h.subs {
+		subs = append(subs, s)
+	}
+	h.mu.RUnlock()
+
+	for _, s := range subs {
+		s.enqueue(pm)
+	}
+	return nil
+}
+
+// Subscribers returns the number of subscribers to topic.
+func (h *Hub) Subscribers(topic string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.topics[topic])
+}
+
+// Topics returns the topics with at least one subscriber.
+func (h *Hub) Topics() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	topics := make([]string, 0, len(h.topics))
+	for topic := range h.topics {
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+// Shutdown stops the hub. Each subscriber is sent its queued messages
+// followed by a close message with the code websocket.CloseGoingAway, and
+// its connection is closed. Shutdown waits for the messages to be written
+// until ctx is done. If ctx is done first, Shutdown closes the remaining
+// connections and returns ctx.Err().
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	h.closed = true
+	subs := make([]*Subscriber, 0, len(h.subs))
+	for s := range h.subs {
+		subs = append(subs, s)
+	}
+	h.mu.Unlock()
+
+	for _, s := range subs {
+		s.close(websocket.CloseGoingAway, false)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		for _, s := range subs {
+			s.conn.Close()
+		}
+		return ctx.Err()
+	}
+}
+
+// Conn returns the connection of the subscriber.
+func (s *Subscriber) Conn() *websocket.Conn {
+	return s.conn
+}
+
+// Join subscribes s to topic.
+func (s *Subscriber) Join(topic string) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	if _, ok := s.hub.subs[s]; ok {
+		s.hub.joinLocked(s, topic)
+	}
+}
+
+// Leave unsubscribes s from topic.
+func (s *Subscriber) Leave(topic string) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.leaveLocked(s, topic)
+}
+
+// Send queues a message for s only. The slow consumer policy of the hub
+// applies.
+func (s *Subscriber) Send(messageType int, data []byte) error {
+	pm, err := websocket.NewPreparedMessage(messageType, data)
+	if err != nil {
+		return err
+	}
+	s.enqueue(pm)
+	return nil
+}
+
+// SendPrepared queues a prepared message for s only. The slow consumer
+// policy of the hub applies.
+func (s *Subscriber) SendPrepared(pm *websocket.PreparedMessage) {
+	s.enqueue(pm)
+}
+
+// Close removes s from the hub, discards the queued messages, sends a close
+// message with the code websocket.CloseNormalClosure and closes the
+// connection.
+func (s *Subscriber) Close() {
+	s.close(websocket.CloseNormalClosure, true)
+}
+
+// Done returns a channel that is closed when the hub stops writing to the
+// subscriber's connection.
+func (s *Subscriber) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Subscriber) close(code int, discard bool) {
+	s.hub.remove(s)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.discard = discard
+	s.closeCode = code
+	close(s.send)
+}
+
+func (s *Subscriber) enqueue(pm *websocket.PreparedMessage) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	select {
+	case s.send <- pm:
+		s.mu.Unlock()
+		return
+	default:
+	}
+
+	policy := s.hub.opts.SlowConsumerPolicy
+	switch policy {
+	case DropOldest:
+		select {
+		case <-s.send:
+		default:
+		}
+		// The write loop only receives from the queue, so there is room
+		// for the message.
+		select {
+		case s.send <- pm:
+		default:
+		}
+	case DropNewest:
+	default:
+		s.closed = true
+		s.discard = true
+		s.closeCode = websocket.CloseTryAgainLater
+		close(s.send)
+	}
+	s.mu.Unlock()
+
+	if policy != DropOldest && policy != DropNewest {
+		s.hub.remove(s)
+	}
+	if s.hub.opts.OnSlowConsumer != nil {
+		s.hub.opts.OnSlowConsumer(s)
+	}
+}
+
+func (s *Subscriber) writeLoop() {
+	defer s.hub.wg.Done()
+	defer close(s.done)
+	defer s.conn.Close()
+	defer s.hub.remove(s)
+
+	timeout := s.hub.opts.WriteTimeout
+	for pm := range s.send {
+		s.mu.Lock()
+		discard := s.discard
+		s.mu.Unlock()
+		if discard {
+			break
+		}
+		err := s.conn.SetWriteDeadline(time.Now().Add(timeout))
+		if err == nil {
+			err = s.conn.WritePreparedMessage(pm)
+		}
+		if err != nil {
+			// Stop queuing messages for the failed connection.
+			s.close(websocket.CloseAbnormalClosure, true)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	code := s.closeCode
+	s.mu.Unlock()
+	_ = s.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(code, ""), time.Now().Add(timeout))
+}
@@ -0,0 +1,195 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hub
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/wstest"
+)
+
+// subscribe returns the client connection of a new subscriber to topics.
+func subscribe(t *testing.T, h *Hub, compress bool, topics ...string) (*websocket.Conn, *Subscriber) {
+	t.Helper()
+	client, server, err := wstest.Pair(&wstest.PairOptions{
+		Upgrader: &websocket.Upgrader{EnableCompression: compress},
+		Dialer:   &websocket.Dialer{EnableCompression: compress},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := h.Subscribe(server, topics...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		client.Close()
+		s.Close()
+	})
+	return client, s
+}
+
+func readText(t *testing.T, c *websocket.Conn) string {
+	t.Helper()
+	_ = c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, p, err := c.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(p)
+}
+
+func TestPublish(t *testing.T) {
+	h := New(nil)
+	a, _ := subscribe(t, h, false, "news", "sports")
+	b, _ := subscribe(t, h, true, "news")
+	c, sc := subscribe(t, h, false, "sports")
+
+	if n := h.Subscribers("news"); n != 2 {
+		t.Errorf("Subscribers(news) = %d, want 2", n)
+	}
+	_ = h.Publish("news", websocket.TextMessage, []byte("n1"))
+	_ = h.Publish("sports", websocket.TextMessage, []byte("s1"))
+	_ = h.Publish("weather", websocket.TextMessage, []byte("w1"))
+	_ = h.Broadcast(websocket.TextMessage, []byte("all"))
+
+	for _, tt := range []struct {
+		c    *websocket.Conn
+		want []string
+	}{
+		{a, []string{"n1", "s1", "all"}},
+		{b, []string{"n1", "all"}},
+		{c, []string{"s1", "all"}},
+	} {
+		for _, want := range tt.want {
+			if got := readText(t, tt.c); got != want {
+				t.Errorf("message = %q, want %q", got, want)
+			}
+		}
+	}
+
+	sc.Leave("sports")
+	sc.Join("news")
+	_ = h.Publish("news", websocket.TextMessage, []byte("n2"))
+	if got := readText(t, c); got != "n2" {
+		t.Errorf("message after Join = %q, want n2", got)
+	}
+	if n := h.Subscribers("sports"); n != 1 {
+		t.Errorf("Subscribers(sports) = %d after Leave, want 1", n)
+	}
+}
+
+func TestSlowConsumer(t *testing.T) {
+	const n = 10
+	tests := []struct {
+		policy    Policy
+		wantFirst string
+		wantLast  string
+	}{
+		{DropNewest, "0", ""},
+		{DropOldest, "", strconv.Itoa(n - 1)},
+		{Disconnect, "", ""},
+	}
+	for _, tt := range tests {
+		var slow int32
+		h := New(&Options{
+			QueueSize:          2,
+			SlowConsumerPolicy: tt.policy,
+			OnSlowConsumer:     func(s *Subscriber) { atomic.AddInt32(&slow, 1) },
+		})
+		c, s := subscribe(t, h, false, "t")
+		for i := 0; i < n; i++ {
+			_ = h.Publish("t", websocket.TextMessage, []byte(strconv.Itoa(i)))
+		}
+		if atomic.LoadInt32(&slow) == 0 {
+			t.Errorf("policy %d: OnSlowConsumer not called", tt.policy)
+		}
+
+		if tt.policy == Disconnect {
+			if h.Subscribers("t") != 0 {
+				t.Errorf("policy %d: slow subscriber not removed", tt.policy)
+			}
+			var err error
+			for err == nil {
+				_, _, err = c.ReadMessage()
+			}
+			if !websocket.IsCloseError(err, websocket.CloseTryAgainLater) {
+				t.Errorf("policy %d: ReadMessage() returned %v, want close error", tt.policy, err)
+			}
+			<-s.Done()
+			continue
+		}
+
+		// Shutdown writes the queued messages before the close message.
+		go h.Shutdown(context.Background())
+		var got []string
+		for {
+			_, p, err := c.ReadMessage()
+			if err != nil {
+				break
+			}
+			got = append(got, string(p))
+		}
+		if len(got) == 0 || len(got) == n {
+			t.Errorf("policy %d: received %v, want some messages dropped", tt.policy, got)
+			continue
+		}
+		if tt.wantFirst != "" && got[0] != tt.wantFirst {
+			t.Errorf("policy %d: first message = %q, want %q", tt.policy, got[0], tt.wantFirst)
+		}
+		if tt.wantLast != "" && got[len(got)-1] != tt.wantLast {
+			t.Errorf("policy %d: last message = %q, want %q", tt.policy, got[len(got)-1], tt.wantLast)
+		}
+		<-s.Done()
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	h := New(nil)
+	c, s := subscribe(t, h, false, "t")
+	_ = h.Publish("t", websocket.TextMessage, []byte("last"))
+
+	done := make(chan error)
+	go func() { done <- h.Shutdown(context.Background()) }()
+	if got := readText(t, c); got != "last" {
+		t.Errorf("message = %q, want last", got)
+	}
+	if _, _, err := c.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("ReadMessage() returned %v, want close error with CloseGoingAway", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Shutdown() returned %v", err)
+	}
+	<-s.Done()
+
+	if err := h.Publish("t", websocket.TextMessage, nil); err != ErrClosed {
+		t.Errorf("Publish() after Shutdown returned %v, want %v", err, ErrClosed)
+	}
+	client, server, _ := wstest.Pair(nil)
+	defer client.Close()
+	defer server.Close()
+	if _, err := h.Subscribe(server); err != ErrClosed {
+		t.Errorf("Subscribe() after Shutdown returned %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	h := New(nil)
+	_, s := subscribe(t, h, false, "t")
+	// The client does not read, so the write blocks.
+	_ = h.Publish("t", websocket.TextMessage, []byte("blocked"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := h.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Shutdown() returned %v, want %v", err, context.DeadlineExceeded)
+	}
+	<-s.Done()
+}
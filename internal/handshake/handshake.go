@@ -0,0 +1,87 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package handshake implements the client side of the WebSocket opening
+// handshake on a raw network connection. It is used by test tools that
+// write frames to the connection directly.
+package handshake
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// DeflateExtension is the permessage-deflate offer without context takeover.
+const DeflateExtension = "permessage-deflate; server_no_context_takeover; client_no_context_takeover"
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// AcceptKey returns the Sec-WebSocket-Accept value for the key.
+func AcceptKey(key string) string {
+	h := sha1.New()
+	io.WriteString(h, key+acceptGUID)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// Client performs the client side of the opening handshake on conn. The
+// header specifies additional request headers and may be nil. If compress
+// is true, the request offers DeflateExtension. Client returns the response
+// and a reader for the data received after the response.
+func Client(conn net.Conn, rawURL string, header http.Header, compress bool) (*http.Response, *bufio.Reader, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	u.Scheme = "http"
+
+	var b [16]byte
+	if _, err := io.ReadFull(rand.Reader, b[:]); err != nil {
+		return nil, nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(b[:])
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     header.Clone(),
+		Host:       u.Host,
+	}
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	req.Header["Upgrade"] = []string{"websocket"}
+	req.Header["Connection"] = []string{"Upgrade"}
+	req.Header["Sec-WebSocket-Key"] = []string{key}
+	req.Header["Sec-WebSocket-Version"] = []string{"13"}
+	if compress {
+		req.Header["Sec-WebSocket-Extensions"] = []string{DeflateExtension}
+	}
+	if err := req.Write(conn); err != nil {
+		return nil, nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("handshake: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, nil, fmt.Errorf("handshake: unexpected status %s", resp.Status)
+	}
+	if resp.Header.Get("Sec-Websocket-Accept") != AcceptKey(key) {
+		return nil, nil, errors.New("handshake: invalid Sec-WebSocket-Accept")
+	}
+	return resp, br, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package handshake
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestAcceptKey(t *testing.T) {
+	// Example from RFC 6455, section 1.3.
+	if got, want := AcceptKey("dGhlIHNhbXBsZSBub25jZQ=="), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; got != want {
+		t.Errorf("AcceptKey() = %q, want %q", got, want)
+	}
+}
+
+func TestClient(t *testing.T) {
+	upgrader := websocket.Upgrader{EnableCompression: true}
+	tests := []struct {
+		handler http.HandlerFunc
+		wantErr string
+	}{
+		{func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Test") != "yes" {
+				http.Error(w, "missing header", http.StatusBadRequest)
+				return
+			}
+			c, err := upgrader.Upgrade(w, r, nil)
+			if err == nil {
+				c.Close()
+			}
+		}, ""},
+		{func(w http.ResponseWriter, r *http.Request) {
+			http.NotFound(w, r)
+		}, "unexpected status 404"},
+		{func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Upgrade", "websocket")
+			w.Header().Set("Connection", "Upgrade")
+			w.Header().Set("Sec-WebSocket-Accept", "bad")
+			w.WriteHeader(http.StatusSwitchingProtocols)
+		}, "invalid Sec-WebSocket-Accept"},
+	}
+	for i, tt := range tests {
+		s := httptest.NewServer(tt.handler)
+		conn, err := net.Dial("tcp", s.Listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, _, err := Client(conn, "ws://"+s.Listener.Addr().String()+"/", http.Header{"X-Test": {"yes"}}, true)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%d: Client() returned %v", i, err)
+		case tt.wantErr == "" && !strings.HasPrefix(resp.Header.Get("Sec-Websocket-Extensions"), "permessage-deflate"):
+			t.Errorf("%d: extensions = %q, want permessage-deflate", i, resp.Header.Get("Sec-Websocket-Extensions"))
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%d: Client() returned %v, want error containing %q", i, err, tt.wantErr)
+		}
+		conn.Close()
+		s.Close()
+	}
+}
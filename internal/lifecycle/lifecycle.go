@@ -0,0 +1,62 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lifecycle implements the connection lifecycle shared by the
+// protocol packages: closing a connection when a context is done, closing a
+// connection with a close message and choosing the error returned from Run.
+package lifecycle
+
+import (
+	"context"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// CloseTimeout is the time allowed to send a close message.
+const CloseTimeout = time.Second
+
+// Watch calls f when ctx is done. The returned function stops watching and
+// reports whether f was called. After the function returns, f is not called
+// and any running call to f has returned. The function must be called
+// exactly once.
+func Watch(ctx context.Context, f func()) (stop func() bool) {
+	done := make(chan struct{})
+	fired := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			f()
+			fired <- true
+		case <-done:
+			fired <- false
+		}
+	}()
+	return func() bool {
+		close(done)
+		return <-fired
+	}
+}
+
+// Close makes a best effort to send a close message with the code and text
+// and then closes conn.
+func Close(conn *websocket.Conn, code int, text string) error {
+	_ = conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(code, text), time.Now().Add(CloseTimeout))
+	return conn.Close()
+}
+
+// RunError returns the error for Run to return after the read loop ended
+// with err. RunError returns ctx.Err() if ctx is done, nil if the connection
+// was closed by the application or closed normally by the peer and err
+// otherwise.
+func RunError(ctx context.Context, closed bool, err error) error {
+	switch {
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case closed, websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway):
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/wstest"
+)
+
+func TestWatch(t *testing.T) {
+	cc, sc, err := wstest.Pair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	defer sc.Close()
+
+	if stop := Watch(context.Background(), func() { cc.Close() }); stop() {
+		t.Error("stop() = true, want false")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stop := Watch(ctx, func() { cc.Close() })
+	cancel()
+	if _, _, err := cc.ReadMessage(); err == nil {
+		t.Fatal("ReadMessage() returned nil error after the context was canceled")
+	}
+	if !stop() {
+		t.Error("stop() = false after the watcher closed the connection, want true")
+	}
+}
+
+func TestClose(t *testing.T) {
+	cc, sc, err := wstest.Pair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	errc := make(chan error, 1)
+	go func() {
+		_, _, err := sc.ReadMessage()
+		errc <- err
+	}()
+	if err := Close(cc, websocket.CloseGoingAway, "bye"); err != nil {
+		t.Errorf("Close() returned %v", err)
+	}
+	err = <-errc
+	var ce *websocket.CloseError
+	if !errors.As(err, &ce) || ce.Code != websocket.CloseGoingAway || ce.Text != "bye" {
+		t.Errorf("ReadMessage() returned %v, want close error 1001 bye", err)
+	}
+}
+
+func TestRunError(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	readErr := errors.New("read error")
+	tests := []struct {
+		ctx    context.Context
+		closed bool
+		err    error
+		want   error
+	}{
+		{context.Background(), false, readErr, readErr},
+		{context.Background(), true, readErr, nil},
+		{context.Background(), false, &websocket.CloseError{Code: websocket.CloseNormalClosure}, nil},
+		{context.Background(), false, &websocket.CloseError{Code: websocket.CloseGoingAway}, nil},
+		{canceled, true, readErr, context.Canceled},
+	}
+	for _, tt := range tests {
+		if got := RunError(tt.ctx, tt.closed, tt.err); got != tt.want {
+			t.Errorf("RunError(%v, %v, %v) = %v, want %v", tt.ctx.Err(), tt.closed, tt.err, got, tt.want)
+		}
+	}
+	closeErr := &websocket.CloseError{Code: websocket.CloseProtocolError}
+	if got := RunError(context.Background(), false, closeErr); got != closeErr {
+		t.Errorf("RunError(protocol error) = %v, want %v", got, closeErr)
+	}
+}
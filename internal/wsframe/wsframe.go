@@ -0,0 +1,73 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package wsframe splits a stream of raw WebSocket frames into frames. It is
+// shared by the frame recorder and the transcript package.
+package wsframe
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Len returns the length in bytes of the frame at the start of p, including
+// the header, and the length of the header. The length is -1 if p does not
+// contain the complete frame header. If the payload length is too large to
+// represent as an int, the length is math.MaxInt.
+func Len(p []byte) (n, hdr int) {
+	if len(p) < 2 {
+		return -1, 0
+	}
+	hdr = 2
+	var length uint64
+	switch b := p[1] & 0x7f; b {
+	case 126:
+		if len(p) < 4 {
+			return -1, 0
+		}
+		length = uint64(binary.BigEndian.Uint16(p[2:]))
+		hdr += 2
+	case 127:
+		if len(p) < 10 {
+			return -1, 0
+		}
+		length = binary.BigEndian.Uint64(p[2:])
+		hdr += 8
+	default:
+		length = uint64(b)
+	}
+	if p[1]&0x80 != 0 {
+		hdr += 4
+	}
+	if length > uint64(math.MaxInt-hdr) {
+		return math.MaxInt, hdr
+	}
+	return hdr + int(length), hdr
+}
+
+// Splitter splits the byte stream written to it into frames and calls
+// Record with each complete frame. The frame passed to Record is valid only
+// until Record returns.
+type Splitter struct {
+	Record func(frame []byte)
+	buf    []byte
+}
+
+func (s *Splitter) Write(p []byte) (int, error) {
+	s.buf = append(s.buf, p...)
+	b := s.buf
+	for {
+		n, _ := Len(b)
+		if n < 0 || n > len(b) {
+			break
+		}
+		s.Record(b[:n])
+		b = b[n:]
+	}
+	if len(b) < len(s.buf) {
+		// Move the partial frame to the start of the buffer.
+		s.buf = s.buf[:copy(s.buf, b)]
+	}
+	return len(p), nil
+}
@@ -0,0 +1,32 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wsframe
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLen(t *testing.T) {
+	tests := []struct {
+		p         []byte
+		want, hdr int
+	}{
+		{nil, -1, 0},
+		{[]byte{0x82}, -1, 0},
+		{[]byte{0x82, 0x05}, 7, 2},
+		{[]byte{0x82, 0x85}, 11, 6},
+		{[]byte{0x82, 126, 1}, -1, 0},
+		{[]byte{0x82, 126, 1, 0}, 260, 4},
+		{[]byte{0x82, 127, 0, 0, 0, 0, 0, 1, 0}, -1, 0},
+		{[]byte{0x82, 127, 0, 0, 0, 0, 0, 1, 0, 0}, 65546, 10},
+		{[]byte{0x82, 127, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, math.MaxInt, 10},
+	}
+	for _, tt := range tests {
+		if n, hdr := Len(tt.p); n != tt.want || hdr != tt.hdr {
+			t.Errorf("Len(%x) = %d, %d, want %d, %d", tt.p, n, hdr, tt.want, tt.hdr)
+		}
+	}
+}
@@ -6,9 +6,129 @@ package websocket
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 )
 
+// JSONOptions specifies options for decoding JSON messages. The zero value
+// decodes messages as the encoding/json Decoder does by default.
+type JSONOptions struct {
+	// DisallowUnknownFields causes decoding to fail when a JSON object
+	// contains a key that does not match a field of the destination struct.
+	DisallowUnknownFields bool
+
+	// UseNumber causes numbers decoded into an interface{} to be stored as
+	// json.Number instead of float64.
+	UseNumber bool
+
+	// DisallowTrailingData causes decoding to fail when the message contains
+	// data other than white space after the first JSON value.
+	DisallowTrailingData bool
+
+	// MaxDepth specifies the maximum nesting depth of arrays and objects in
+	// a message. If zero, the depth is not limited.
+	MaxDepth int
+}
+
+var (
+	errJSONTrailingData = errors.New("websocket: unexpected data after JSON value")
+	errJSONMaxDepth     = errors.New("websocket: JSON nesting depth exceeds limit")
+)
+
+// SetJSONOptions sets the options for decoding JSON messages with ReadJSON
+// and with ReadValue when the connection's codec is JSONCodec. If opts is
+// nil, the default options are used.
+func (c *Conn) SetJSONOptions(opts *JSONOptions) {
+	c.jsonOptions = opts
+}
+
+// jsonCodec returns the JSON codec for the connection's options.
+func (c *Conn) jsonCodec() Codec {
+	if c.jsonOptions == nil {
+		return JSONCodec
+	}
+	return jsonCodec{opts: c.jsonOptions}
+}
+
+// decodeJSON decodes the first JSON value read from r into v as specified by
+// opts.
+func decodeJSON(r io.Reader, v interface{}, opts *JSONOptions) error {
+	if opts == nil {
+		return json.NewDecoder(r).Decode(v)
+	}
+	var dr *depthReader
+	if opts.MaxDepth > 0 {
+		dr = &depthReader{r: r, max: opts.MaxDepth}
+		r = dr
+	}
+	dec := json.NewDecoder(r)
+	if opts.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if opts.UseNumber {
+		dec.UseNumber()
+	}
+	err := dec.Decode(v)
+	if err == nil && opts.DisallowTrailingData {
+		if _, terr := dec.Token(); terr != io.EOF {
+			err = errJSONTrailingData
+		}
+	}
+	if dr != nil && dr.exceeded {
+		err = errJSONMaxDepth
+	}
+	return err
+}
+
+// depthReader fails reads when the nesting depth of the first JSON value read
+// from r exceeds max. Data after the first value is not checked.
+type depthReader struct {
+	r        io.Reader
+	max      int
+	depth    int
+	inString bool
+	escape   bool
+	done     bool // the first value is complete
+	exceeded bool
+}
+
+func (r *depthReader) Read(p []byte) (int, error) {
+	if r.exceeded {
+		return 0, errJSONMaxDepth
+	}
+	n, err := r.r.Read(p)
+	for i := 0; i < n && !r.done; i++ {
+		b := p[i]
+		switch {
+		case r.escape:
+			r.escape = false
+		case r.inString:
+			switch b {
+			case '\\':
+				r.escape = true
+			case '"':
+				r.inString = false
+				r.done = r.depth == 0
+			}
+		case b == '"':
+			r.inString = true
+		case b == '{' || b == '[':
+			r.depth++
+			if r.depth > r.max {
+				r.exceeded = true
+				return i, errJSONMaxDepth
+			}
+		case b == '}' || b == ']':
+			r.depth--
+			r.done = r.depth == 0
+		case r.depth == 0 && b != ' ' && b != '\t' && b != '\n' && b != '\r':
+			// The first value is a number or literal.
+			r.done = true
+		}
+	}
+	return n, err
+}
+
 // WriteJSON writes the JSON encoding of v as a message.
 //
 // Deprecated: Use c.WriteJSON instead.
@@ -21,16 +141,7 @@ func WriteJSON(c *Conn, v interface{}) error {
 // See the documentation for encoding/json Marshal for details about the
 // conversion of Go values to JSON.
 func (c *Conn) WriteJSON(v interface{}) error {
-	w, err := c.NextWriter(TextMessage)
-	if err != nil {
-		return err
-	}
-	err1 := json.NewEncoder(w).Encode(v)
-	err2 := w.Close()
-	if err1 != nil {
-		return err1
-	}
-	return err2
+	return c.writeValue(JSONCodec, v)
 }
 
 // ReadJSON reads the next JSON-encoded message from the connection and stores
@@ -46,15 +157,9 @@ func ReadJSON(c *Conn, v interface{}) error {
 //
 // See the documentation for the encoding/json Unmarshal function for details
 // about the conversion of JSON to a Go value.
+//
+// Use SetJSONOptions or the JSONOptions field of the Upgrader or Dialer to
+// configure decoding.
 func (c *Conn) ReadJSON(v interface{}) error {
-	_, r, err := c.NextReader()
-	if err != nil {
-		return err
-	}
-	err = json.NewDecoder(r).Decode(v)
-	if err == io.EOF {
-		// One value is expected in the message.
-		err = io.ErrUnexpectedEOF
-	}
-	return err
+	return c.readValue(c.jsonCodec(), v)
 }
@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"io"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -114,3 +115,71 @@ func TestDeprecatedJSON(t *testing.T) {
 		t.Fatal("equal", actual, expect)
 	}
 }
+
+func TestJSONOptions(t *testing.T) {
+	type value struct{ A interface{} }
+	tests := []struct {
+		opts    JSONOptions
+		message string
+		wantErr bool
+	}{
+		{JSONOptions{}, `{"A": 1, "B": 2}`, false},
+		{JSONOptions{DisallowUnknownFields: true}, `{"A": 1, "B": 2}`, true},
+		{JSONOptions{DisallowUnknownFields: true}, `{"A": 1}`, false},
+		{JSONOptions{}, `{"A": 1} garbage`, false},
+		{JSONOptions{DisallowTrailingData: true}, `{"A": 1} garbage`, true},
+		{JSONOptions{DisallowTrailingData: true}, `{"A": 1} {"A": 2}`, true},
+		{JSONOptions{DisallowTrailingData: true}, "{\"A\": 1}\n", false},
+		{JSONOptions{MaxDepth: 2}, `{"A": [1]}`, false},
+		{JSONOptions{MaxDepth: 2}, `{"A": [[1]]}`, true},
+		{JSONOptions{MaxDepth: 2}, `{"A": "[[[{{{"}`, false},
+		{JSONOptions{MaxDepth: 2}, `{"A": "\"[[["}`, false},
+		{JSONOptions{MaxDepth: 2}, `{"A": 1}[[[[`, false},
+		{JSONOptions{MaxDepth: 2, DisallowTrailingData: true}, `{"A": 1}[[[[`, true},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		wc := newTestConn(nil, &buf, true)
+		rc := newTestConn(&buf, nil, false)
+		opts := tt.opts
+		rc.SetJSONOptions(&opts)
+		_ = wc.WriteMessage(TextMessage, []byte(tt.message))
+		var v value
+		err := rc.ReadJSON(&v)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%+v %s: ReadJSON() returned %v, want error %v", tt.opts, tt.message, err, tt.wantErr)
+		}
+	}
+}
+
+func TestJSONMaxDepthTrailingData(t *testing.T) {
+	// Trailing data after the first value is ignored by the depth limit.
+	opts := &JSONOptions{MaxDepth: 1}
+	for _, message := range []string{`[1] [[[[`, `"a" [[[[`, `12 [[[[`, `true{{{{`} {
+		var v interface{}
+		if err := decodeJSON(strings.NewReader(message), &v, opts); err != nil {
+			t.Errorf("decodeJSON(%s) returned %v", message, err)
+		}
+	}
+	var v interface{}
+	if err := decodeJSON(strings.NewReader(`[[1]]`), &v, opts); err != errJSONMaxDepth {
+		t.Errorf("decodeJSON([[1]]) returned %v, want %v", err, errJSONMaxDepth)
+	}
+}
+
+func TestJSONOptionsUseNumber(t *testing.T) {
+	var buf bytes.Buffer
+	wc := newTestConn(nil, &buf, true)
+	rc := newTestConn(&buf, nil, false)
+	rc.SetJSONOptions(&JSONOptions{UseNumber: true})
+	_ = wc.WriteMessage(TextMessage, []byte(`12345678901234567890`))
+	_ = wc.WriteMessage(TextMessage, []byte(`12345678901234567890`))
+
+	var v interface{}
+	if err := rc.ReadJSON(&v); err != nil || v != json.Number("12345678901234567890") {
+		t.Errorf("ReadJSON() = %#v, %v", v, err)
+	}
+	if err := rc.ReadValue(&v); err != nil || v != json.Number("12345678901234567890") {
+		t.Errorf("ReadValue() = %#v, %v", v, err)
+	}
+}
@@ -0,0 +1,587 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package jsonrpc2 implements bidirectional JSON-RPC 2.0 over a WebSocket
+// connection.
+//
+// A Peer both serves requests from the remote peer with registered method
+// handlers and sends requests to the remote peer with Call, Notify and Batch.
+// Each JSON-RPC message or batch is sent as one WebSocket text message.
+//
+// Example:
+//
+//	peer := jsonrpc2.NewPeer(conn, nil)
+//	peer.Register("add", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+//		var args [2]int
+//		if err := json.Unmarshal(params, &args); err != nil {
+//			return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: err.Error()}
+//		}
+//		return args[0] + args[1], nil
+//	})
+//	go peer.Run(ctx)
+//
+//	var sum int
+//	err := peer.Call(ctx, "add", []int{1, 2}, &sum)
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/internal/lifecycle"
+)
+
+// ErrClosed is returned from Peer methods after the peer is closed or the
+// connection fails.
+var ErrClosed = errors.New("jsonrpc2: peer closed")
+
+// Error codes defined by the JSON-RPC 2.0 specification.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Error is a JSON-RPC error object. Handlers return an *Error to send a
+// specific error code to the caller. Call returns an *Error when the remote
+// peer responds with an error.
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return "jsonrpc2: " + e.Message + " (" + strconv.Itoa(e.Code) + ")"
+}
+
+// Handler handles a request or notification for a method. The params
+// argument is the raw parameters of the request, or nil if the request has no
+// parameters. The result is encoded as JSON in the response. If the handler
+// returns an error that is not an *Error, the response has the code
+// CodeInternalError and the error text as the message. The result and error
+// are discarded for notifications.
+//
+// The context is canceled when the peer is closed or the connection fails.
+type Handler func(ctx context.Context, params json.RawMessage) (result interface{}, err error)
+
+// Options specifies options for a Peer.
+type Options struct {
+	// MaxConcurrentRequests specifies the maximum number of requests and
+	// notifications handled concurrently. When the limit is reached, the
+	// peer stops reading messages until a handler returns. Handlers that
+	// wait for responses from the remote peer must therefore not use all
+	// of the slots. If zero, the number is not limited.
+	MaxConcurrentRequests int
+
+	// WriteTimeout specifies the write deadline for each message sent by
+	// the peer. A write that times out fails the connection. If zero,
+	// writes have no deadline.
+	WriteTimeout time.Duration
+
+	// OnError is called with errors that cannot be returned to a caller,
+	// such as failures to write a response. If nil, the errors are
+	// discarded.
+	OnError func(err error)
+}
+
+// Peer is a JSON-RPC 2.0 peer on a WebSocket connection.
+//
+// Register handlers before calling Run. All other methods can be called
+// concurrently.
+type Peer struct {
+	conn *websocket.Conn
+	opts Options
+	sem  chan struct{} // limits concurrent requests, nil if unlimited
+
+	handlers map[string]Handler
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[string]chan *response // calls waiting for a response by ID
+	closed  bool
+
+	ctx    context.Context // canceled when the peer is closed
+	cancel context.CancelFunc
+	done   chan struct{} // closed when the peer is closed
+}
+
+// NewPeer returns a JSON-RPC peer for conn. If opts is nil, default options
+// are used. The peer reads messages from conn with ReadJSON and writes
+// messages with WriteJSON. The application must not otherwise read from or
+// write to conn.
+func NewPeer(conn *websocket.Conn, opts *Options) *Peer {
+	p := &Peer{
+		conn:     conn,
+		handlers: make(map[string]Handler),
+		pending:  make(map[string]chan *response),
+		done:     make(chan struct{}),
+	}
+	if opts != nil {
+		p.opts = *opts
+	}
+	if p.opts.MaxConcurrentRequests > 0 {
+		p.sem = make(chan struct{}, p.opts.MaxConcurrentRequests)
+	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	return p
+}
+
+// Conn returns the WebSocket connection used by p.
+func (p *Peer) Conn() *websocket.Conn {
+	return p.conn
+}
+
+// Register sets the handler for method. Register must not be called
+// concurrently with Run.
+func (p *Peer) Register(method string, h Handler) {
+	p.handlers[method] = h
+}
+
+// request is a request, notification or response on the wire.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// response is a response on the wire.
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+var nullID = json.RawMessage("null")
+
+// Run reads and dispatches messages from the connection until the connection
+// fails, ctx is done or Close is called. Requests are handled concurrently.
+// When Run returns, the peer is closed: pending calls return ErrClosed and
+// the contexts of running handlers are canceled.
+//
+// Run returns nil if the peer was closed with Close or the remote peer closed
+// the connection normally, ctx.Err() if ctx is done and the read error
+// otherwise.
+func (p *Peer) Run(ctx context.Context) error {
+	stop := lifecycle.Watch(ctx, func() { p.conn.Close() })
+	err := p.readLoop(ctx)
+	stop()
+	p.shutdown()
+	return lifecycle.RunError(ctx, p.isClosed(), err)
+}
+
+func (p *Peer) readLoop(ctx context.Context) error {
+	for {
+		var raw json.RawMessage
+		err := p.conn.ReadJSON(&raw)
+		var se *json.SyntaxError
+		switch {
+		case errors.As(err, &se) || err == io.ErrUnexpectedEOF:
+			p.reply(&response{ID: nullID, Error: &Error{Code: CodeParseError, Message: "parse error"}})
+			continue
+		case err != nil:
+			return err
+		}
+
+		if len(raw) > 0 && raw[0] == '[' {
+			var batch []json.RawMessage
+			if err := json.Unmarshal(raw, &batch); err != nil || len(batch) == 0 {
+				p.reply(&response{ID: nullID, Error: &Error{Code: CodeInvalidRequest, Message: "invalid request"}})
+				continue
+			}
+			if err := p.handleBatch(ctx, batch); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var r request
+		if err := json.Unmarshal(raw, &r); err != nil {
+			p.reply(&response{ID: nullID, Error: &Error{Code: CodeInvalidRequest, Message: "invalid request"}})
+			continue
+		}
+		if r.Method == "" && r.ID != nil {
+			p.handleResponse(&r)
+			continue
+		}
+		if err := p.acquire(ctx); err != nil {
+			return err
+		}
+		go func() {
+			defer p.release()
+			if resp := p.handle(&r); resp != nil {
+				p.reply(resp)
+			}
+		}()
+	}
+}
+
+// handleResponse passes a response to the waiting call.
+func (p *Peer) handleResponse(r *request) {
+	p.mu.Lock()
+	ch, ok := p.pending[string(r.ID)]
+	delete(p.pending, string(r.ID))
+	p.mu.Unlock()
+	if ok {
+		ch <- &response{ID: r.ID, Result: r.Result, Error: r.Error}
+	}
+}
+
+// acquire waits until fewer than MaxConcurrentRequests requests are handled.
+// acquire returns an error if ctx is done or the peer is closed first.
+func (p *Peer) acquire(ctx context.Context) error {
+	if p.sem == nil {
+		return nil
+	}
+	select {
+	case p.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.ctx.Done():
+		return ErrClosed
+	}
+}
+
+// release releases a slot acquired with acquire.
+func (p *Peer) release() {
+	if p.sem != nil {
+		<-p.sem
+	}
+}
+
+// handleBatch starts handling the elements of a batch concurrently and
+// replies with an array of the responses when all elements are handled.
+// Like single requests, each element is started after acquiring a slot.
+func (p *Peer) handleBatch(ctx context.Context, batch []json.RawMessage) error {
+	responses := make([]*response, len(batch))
+	var wg sync.WaitGroup
+	for i, raw := range batch {
+		var r request
+		if err := json.Unmarshal(raw, &r); err != nil {
+			responses[i] = &response{ID: nullID, Error: &Error{Code: CodeInvalidRequest, Message: "invalid request"}}
+			continue
+		}
+		if r.Method == "" && r.ID != nil {
+			p.handleResponse(&r)
+			continue
+		}
+		if err := p.acquire(ctx); err != nil {
+			return err
+		}
+		wg.Add(1)
+		go func(i int, r *request) {
+			defer wg.Done()
+			defer p.release()
+			responses[i] = p.handle(r)
+		}(i, &r)
+	}
+
+	go func() {
+		wg.Wait()
+		var out []*response
+		for _, r := range responses {
+			if r != nil {
+				r.JSONRPC = "2.0"
+				out = append(out, r)
+			}
+		}
+		if len(out) > 0 {
+			p.write(out)
+		}
+	}()
+	return nil
+}
+
+// handle calls the handler for a request and returns the response, or nil for
+// notifications.
+func (p *Peer) handle(r *request) *response {
+	if r.JSONRPC != "2.0" || r.Method == "" {
+		return &response{ID: idOrNull(r.ID), Error: &Error{Code: CodeInvalidRequest, Message: "invalid request"}}
+	}
+	h, ok := p.handlers[r.Method]
+	if !ok {
+		if r.ID == nil {
+			return nil
+		}
+		return &response{ID: r.ID, Error: &Error{Code: CodeMethodNotFound, Message: "method not found: " + r.Method}}
+	}
+
+	result, err := h(p.ctx, r.Params)
+	if r.ID == nil {
+		return nil
+	}
+	resp := &response{ID: r.ID}
+	if err == nil {
+		resp.Result, err = json.Marshal(result)
+	}
+	if err != nil {
+		var e *Error
+		if !errors.As(err, &e) {
+			e = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		resp.Result = nil
+		resp.Error = e
+	}
+	return resp
+}
+
+func idOrNull(id json.RawMessage) json.RawMessage {
+	if id == nil {
+		return nullID
+	}
+	return id
+}
+
+// reply writes a response to a single request.
+func (p *Peer) reply(r *response) {
+	r.JSONRPC = "2.0"
+	p.write(r)
+}
+
+// write writes v as a message with the write timeout. Errors are reported to
+// OnError.
+func (p *Peer) write(v interface{}) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	var deadline time.Time
+	if p.opts.WriteTimeout > 0 {
+		deadline = time.Now().Add(p.opts.WriteTimeout)
+	}
+	err := p.conn.SetWriteDeadline(deadline)
+	if err == nil {
+		err = p.conn.WriteJSON(v)
+	}
+	if err != nil && p.opts.OnError != nil {
+		p.opts.OnError(err)
+	}
+	return err
+}
+
+// shutdown closes the peer and cancels pending calls and running handlers.
+func (p *Peer) shutdown() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	select {
+	case <-p.done:
+	default:
+		close(p.done)
+	}
+	p.pending = make(map[string]chan *response)
+	p.cancel()
+}
+
+func (p *Peer) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+// Close sends a close message to the remote peer, closes the connection and
+// stops Run.
+func (p *Peer) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	err := lifecycle.Close(p.conn, websocket.CloseNormalClosure, "")
+	p.shutdown()
+	return err
+}
+
+// Done returns a channel that is closed when the peer is closed.
+func (p *Peer) Done() <-chan struct{} {
+	return p.done
+}
+
+// newCall registers a call waiting for a response and returns the ID and the
+// channel for the response.
+func (p *Peer) newCall() (json.RawMessage, chan *response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	select {
+	case <-p.done:
+		return nil, nil, ErrClosed
+	default:
+	}
+	p.nextID++
+	id := json.RawMessage(strconv.FormatInt(p.nextID, 10))
+	ch := make(chan *response, 1)
+	p.pending[string(id)] = ch
+	return id, ch, nil
+}
+
+func (p *Peer) cancelCall(id json.RawMessage) {
+	p.mu.Lock()
+	delete(p.pending, string(id))
+	p.mu.Unlock()
+}
+
+func (p *Peer) wait(ctx context.Context, id json.RawMessage, ch chan *response) (*response, error) {
+	select {
+	case r := <-ch:
+		return r, nil
+	case <-ctx.Done():
+		p.cancelCall(id)
+		return nil, ctx.Err()
+	case <-p.done:
+		return nil, ErrClosed
+	}
+}
+
+// Call sends a request to the remote peer and waits for the response. The
+// params are encoded as JSON; use nil for no parameters. If result is not
+// nil, the result of the response is decoded into the value pointed to by
+// result.
+//
+// Call returns an *Error if the remote peer responds with an error, ctx.Err()
+// if ctx is done before the response is received and ErrClosed if the peer is
+// closed. The context bounds the wait for the response only; sending the
+// request is bounded by Options.WriteTimeout.
+func (p *Peer) Call(ctx context.Context, method string, params, result interface{}) error {
+	r := &request{JSONRPC: "2.0", Method: method}
+	if params != nil {
+		var err error
+		if r.Params, err = json.Marshal(params); err != nil {
+			return err
+		}
+	}
+	id, ch, err := p.newCall()
+	if err != nil {
+		return err
+	}
+	r.ID = id
+	if err := p.write(r); err != nil {
+		p.cancelCall(id)
+		return err
+	}
+	resp, err := p.wait(ctx, id, ch)
+	if err != nil {
+		return err
+	}
+	return resp.decode(result)
+}
+
+func (r *response) decode(result interface{}) error {
+	if r.Error != nil {
+		return r.Error
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(r.Result, result)
+}
+
+// Notify sends a notification to the remote peer. The remote peer does not
+// respond to notifications. Notify returns ctx.Err() without sending the
+// notification if ctx is done; sending is bounded by Options.WriteTimeout.
+func (p *Peer) Notify(ctx context.Context, method string, params interface{}) error {
+	select {
+	case <-p.done:
+		return ErrClosed
+	default:
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r := &request{JSONRPC: "2.0", Method: method}
+	if params != nil {
+		var err error
+		if r.Params, err = json.Marshal(params); err != nil {
+			return err
+		}
+	}
+	return p.write(r)
+}
+
+// BatchElem is an element of a batch sent with Batch.
+type BatchElem struct {
+	// Method is the method to call.
+	Method string
+
+	// Params specifies the parameters of the request, or nil for no
+	// parameters.
+	Params interface{}
+
+	// Result is decoded from the result of the response if not nil.
+	Result interface{}
+
+	// Notification specifies whether the element is a notification. The
+	// remote peer does not respond to notifications.
+	Notification bool
+
+	// Error is set by Batch to the error from the response, if any.
+	Error error
+}
+
+// Batch sends the elements as a batch and waits for the responses to all
+// elements that are not notifications. The Result and Error fields of the
+// elements are set from the responses.
+//
+// Batch returns an error if the batch cannot be sent or if ctx is done or the
+// peer is closed before all responses are received. As with Call, ctx bounds
+// the wait for the responses and Options.WriteTimeout bounds sending the
+// batch.
+func (p *Peer) Batch(ctx context.Context, elems []BatchElem) error {
+	batch := make([]*request, len(elems))
+	ids := make([]json.RawMessage, len(elems))
+	chs := make([]chan *response, len(elems))
+	defer func() {
+		for _, id := range ids {
+			if id != nil {
+				p.cancelCall(id)
+			}
+		}
+	}()
+	for i := range elems {
+		r := &request{JSONRPC: "2.0", Method: elems[i].Method}
+		if elems[i].Params != nil {
+			var err error
+			if r.Params, err = json.Marshal(elems[i].Params); err != nil {
+				return err
+			}
+		}
+		if !elems[i].Notification {
+			var err error
+			ids[i], chs[i], err = p.newCall()
+			if err != nil {
+				return err
+			}
+			r.ID = ids[i]
+		}
+		batch[i] = r
+	}
+	if err := p.write(batch); err != nil {
+		return err
+	}
+	for i := range elems {
+		if chs[i] == nil {
+			continue
+		}
+		resp, err := p.wait(ctx, ids[i], chs[i])
+		if err != nil {
+			return err
+		}
+		elems[i].Error = resp.decode(elems[i].Result)
+	}
+	return nil
+}
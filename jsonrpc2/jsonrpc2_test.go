@@ -0,0 +1,312 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/wstest"
+)
+
+func newPeers(t *testing.T, opts *Options) (client, server *Peer) {
+	t.Helper()
+	cc, sc, err := wstest.Pair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = NewPeer(cc, opts)
+	server = NewPeer(sc, opts)
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return client, server
+}
+
+func add(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var args [2]int
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	return args[0] + args[1], nil
+}
+
+func TestCall(t *testing.T) {
+	client, server := newPeers(t, nil)
+	server.Register("add", add)
+	server.Register("fail", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return nil, errors.New("failed")
+	})
+	client.Register("ping", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return "pong", nil
+	})
+	ctx := context.Background()
+	go server.Run(ctx)
+	go client.Run(ctx)
+
+	var sum int
+	if err := client.Call(ctx, "add", []int{1, 2}, &sum); err != nil || sum != 3 {
+		t.Errorf("Call(add) = %d, %v, want 3", sum, err)
+	}
+
+	var e *Error
+	if err := client.Call(ctx, "add", "x", nil); !errors.As(err, &e) || e.Code != CodeInvalidParams {
+		t.Errorf("Call(add, x) returned %v, want invalid params error", err)
+	}
+	if err := client.Call(ctx, "fail", nil, nil); !errors.As(err, &e) || e.Code != CodeInternalError || e.Message != "failed" {
+		t.Errorf("Call(fail) returned %v, want internal error", err)
+	}
+	if err := client.Call(ctx, "missing", nil, nil); !errors.As(err, &e) || e.Code != CodeMethodNotFound {
+		t.Errorf("Call(missing) returned %v, want method not found error", err)
+	}
+
+	// Calls in the other direction.
+	var s string
+	if err := server.Call(ctx, "ping", nil, &s); err != nil || s != "pong" {
+		t.Errorf("server Call(ping) = %q, %v, want pong", s, err)
+	}
+}
+
+func TestConcurrentCalls(t *testing.T) {
+	client, server := newPeers(t, &Options{MaxConcurrentRequests: 4})
+	server.Register("add", add)
+	ctx := context.Background()
+	go server.Run(ctx)
+	go client.Run(ctx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var sum int
+			if err := client.Call(ctx, "add", []int{i, i}, &sum); err != nil || sum != 2*i {
+				t.Errorf("Call(add, %d, %d) = %d, %v", i, i, sum, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestNotify(t *testing.T) {
+	client, server := newPeers(t, nil)
+	got := make(chan string, 1)
+	server.Register("log", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		got <- string(params)
+		return nil, nil
+	})
+	ctx := context.Background()
+	go server.Run(ctx)
+	go client.Run(ctx)
+
+	if err := client.Notify(ctx, "log", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if s := <-got; s != `"hello"` {
+		t.Errorf("params = %s, want \"hello\"", s)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	client, server := newPeers(t, nil)
+	server.Register("add", add)
+	notified := make(chan struct{})
+	server.Register("notify", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		close(notified)
+		return nil, nil
+	})
+	ctx := context.Background()
+	go server.Run(ctx)
+	go client.Run(ctx)
+
+	var a, b int
+	elems := []BatchElem{
+		{Method: "add", Params: []int{1, 2}, Result: &a},
+		{Method: "notify", Notification: true},
+		{Method: "add", Params: []int{3, 4}, Result: &b},
+		{Method: "missing"},
+	}
+	if err := client.Batch(ctx, elems); err != nil {
+		t.Fatal(err)
+	}
+	<-notified
+	if a != 3 || b != 7 || elems[0].Error != nil || elems[2].Error != nil {
+		t.Errorf("results = %d, %d, errors = %v, %v", a, b, elems[0].Error, elems[2].Error)
+	}
+	var e *Error
+	if !errors.As(elems[3].Error, &e) || e.Code != CodeMethodNotFound {
+		t.Errorf("missing method error = %v", elems[3].Error)
+	}
+}
+
+func TestInvalidMessages(t *testing.T) {
+	cc, sc, err := wstest.Pair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	server := NewPeer(sc, nil)
+	defer server.Close()
+	server.Register("add", add)
+	go server.Run(context.Background())
+
+	tests := []struct {
+		message string
+		code    int
+	}{
+		{`{"jsonrpc": "2.0", "method": "add", "params": [1, 2`, CodeParseError},
+		{`[]`, CodeInvalidRequest},
+		{`{"jsonrpc": "1.0", "method": "add", "id": 1}`, CodeInvalidRequest},
+		{`{"jsonrpc": "2.0", "id": 1, "method": 1}`, CodeInvalidRequest},
+	}
+	for _, tt := range tests {
+		if err := cc.WriteMessage(websocket.TextMessage, []byte(tt.message)); err != nil {
+			t.Fatal(err)
+		}
+		var r response
+		if err := cc.ReadJSON(&r); err != nil {
+			t.Fatal(err)
+		}
+		if r.Error == nil || r.Error.Code != tt.code {
+			t.Errorf("%s: response error = %v, want code %d", tt.message, r.Error, tt.code)
+		}
+	}
+}
+
+func TestCallContext(t *testing.T) {
+	client, server := newPeers(t, nil)
+	started := make(chan struct{}, 2)
+	canceled := make(chan struct{}, 2)
+	server.Register("block", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		canceled <- struct{}{}
+		return nil, ctx.Err()
+	})
+	go server.Run(context.Background())
+	go client.Run(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := client.Call(ctx, "block", nil, nil); err != context.DeadlineExceeded {
+		t.Errorf("Call() returned %v, want %v", err, context.DeadlineExceeded)
+	}
+	<-started
+
+	// Closing the peer cancels pending calls and running handlers.
+	errc := make(chan error)
+	go func() { errc <- client.Call(context.Background(), "block", nil, nil) }()
+	<-started
+	server.Close()
+	<-canceled
+	<-canceled
+	if err := <-errc; err != ErrClosed {
+		t.Errorf("Call() returned %v after close, want %v", err, ErrClosed)
+	}
+	if err := client.Call(context.Background(), "block", nil, nil); err != ErrClosed {
+		t.Errorf("Call() returned %v on closed peer, want %v", err, ErrClosed)
+	}
+}
+
+func TestCallContextSlowWrite(t *testing.T) {
+	cc, sc, err := wstest.Pair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewPeer(cc, nil)
+	server := NewPeer(sc, nil)
+	defer client.Close()
+	defer server.Close()
+	server.Register("add", add)
+	go client.Run(context.Background())
+
+	// The server does not read until after the deadline of the first call.
+	// The expired deadline must not fail the connection.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	time.AfterFunc(100*time.Millisecond, func() { go server.Run(context.Background()) })
+	if err := client.Call(ctx, "add", []int{1, 2}, nil); err != context.DeadlineExceeded {
+		t.Errorf("Call() returned %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	var sum int
+	if err := client.Call(context.Background(), "add", []int{3, 4}, &sum); err != nil || sum != 7 {
+		t.Errorf("Call() = %d, %v after slow write, want 7", sum, err)
+	}
+}
+
+func TestWriteTimeout(t *testing.T) {
+	cc, sc, err := wstest.Pair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+	client := NewPeer(cc, &Options{WriteTimeout: 20 * time.Millisecond})
+	defer client.Close()
+
+	// The remote peer does not read.
+	err = client.Notify(context.Background(), "ping", nil)
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Errorf("Notify() returned %v, want timeout error", err)
+	}
+}
+
+func TestMaxConcurrentRequestsFlood(t *testing.T) {
+	const limit = 4
+	for _, batch := range []bool{false, true} {
+		cc, sc, err := wstest.Pair(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		server := NewPeer(sc, &Options{MaxConcurrentRequests: limit})
+		started := make(chan struct{}, 100)
+		release := make(chan struct{})
+		server.Register("block", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			started <- struct{}{}
+			<-release
+			return nil, nil
+		})
+		base := runtime.NumGoroutine()
+		go server.Run(context.Background())
+
+		// Flood the server with notifications. The writes block when the
+		// server stops reading.
+		go func(batch bool) {
+			n := json.RawMessage(`{"jsonrpc":"2.0","method":"block"}`)
+			for i := 0; i < 100; i++ {
+				var err error
+				if batch {
+					err = cc.WriteJSON([]json.RawMessage{n, n, n, n, n, n, n, n, n, n})
+				} else {
+					err = cc.WriteJSON(n)
+				}
+				if err != nil {
+					return
+				}
+			}
+		}(batch)
+		for i := 0; i < limit; i++ {
+			<-started
+		}
+		time.Sleep(50 * time.Millisecond)
+		// The server runs Run, the handlers, the flooding writer and at
+		// most one batch reply goroutine.
+		if n := runtime.NumGoroutine() - base; n > limit+4 {
+			t.Errorf("batch %v: %d goroutines started, want at most %d", batch, n, limit+4)
+		}
+		close(release)
+		cc.Close()
+		server.Close()
+	}
+}
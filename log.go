@@ -0,0 +1,128 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+)
+
+// SetLogger sets the logger for protocol errors, read limit violations and
+// abnormal closes on the connection. The logger adds the remote address,
+// local address, role and subprotocol of the connection to each record. If
+// logger is nil, the connection does not log.
+//
+// The Upgrader and Dialer set the logger from their Logger fields.
+func (c *Conn) SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		c.logger = nil
+		return
+	}
+	role := "client"
+	if c.isServer {
+		role = "server"
+	}
+	attrs := []any{
+		slog.String("remote_addr", addrString(c.RemoteAddr())),
+		slog.String("local_addr", addrString(c.LocalAddr())),
+		slog.String("role", role),
+	}
+	if c.subprotocol != "" {
+		attrs = append(attrs, slog.String("subprotocol", c.subprotocol))
+	}
+	c.logger = logger.With(attrs...)
+}
+
+func addrString(a net.Addr) string {
+	if a == nil {
+		return ""
+	}
+	return a.String()
+}
+
+// logHandshakeError logs the rejection of a handshake request by the server.
+func (u *Upgrader) logHandshakeError(r *http.Request, err HandshakeError) {
+	level := slog.LevelInfo
+	if err.Check == HandshakeCheckNone {
+		level = slog.LevelError
+	}
+	u.Logger.LogAttrs(r.Context(), level, "websocket: handshake rejected",
+		slog.String("remote_addr", r.RemoteAddr),
+		slog.String("method", r.Method),
+		slog.String("uri", r.RequestURI),
+		slog.Int("status", err.Status),
+		slog.String("check", err.Check.String()),
+		slog.String("error", err.Error()))
+}
+
+// logHandshakeError logs an invalid handshake response from the server.
+func (d *Dialer) logHandshakeError(ctx context.Context, err *BadHandshakeError) {
+	d.Logger.LogAttrs(ctx, slog.LevelWarn, "websocket: handshake rejected",
+		slog.String("url", err.Request.URL.String()),
+		slog.Int("status", err.StatusCode),
+		slog.String("check", err.Check.String()),
+		slog.String("error", err.Error()))
+}
+
+// frameAttr returns the header fields of a frame as a log attribute.
+func frameAttr(frameType int, final, rsv1, rsv2, rsv3, mask bool, length int64) slog.Attr {
+	return slog.Group("frame",
+		slog.Int("opcode", frameType),
+		slog.Bool("fin", final),
+		slog.Bool("rsv1", rsv1),
+		slog.Bool("rsv2", rsv2),
+		slog.Bool("rsv3", rsv3),
+		slog.Bool("mask", mask),
+		slog.Int64("length", length))
+}
+
+// logProtocolError logs a protocol violation by the peer.
+func (c *Conn) logProtocolError(message string, attrs ...slog.Attr) {
+	c.readErrLogged = true
+	attrs = append([]slog.Attr{slog.String("error", message)}, attrs...)
+	c.logger.LogAttrs(context.Background(), slog.LevelWarn, "websocket: protocol error", attrs...)
+}
+
+// logReadLimit logs a message that exceeds the read limit.
+func (c *Conn) logReadLimit() {
+	c.readErrLogged = true
+	c.logger.LogAttrs(context.Background(), slog.LevelWarn, "websocket: read limit exceeded",
+		slog.Int64("limit", c.readLimit),
+		slog.Int64("length", c.readLength))
+}
+
+// logReadError logs the first error returned to the application from a read
+// method. Normal closes are logged at debug level. Errors that were already
+// logged as protocol errors or read limit violations are not logged again.
+func (c *Conn) logReadError(err error) {
+	if c.readErrLogged {
+		return
+	}
+	c.readErrLogged = true
+	var ce *CloseError
+	switch {
+	case errors.As(err, &ce):
+		level := slog.LevelWarn
+		msg := "websocket: connection closed abnormally"
+		switch ce.Code {
+		case CloseNormalClosure, CloseGoingAway, CloseNoStatusReceived:
+			level = slog.LevelDebug
+			msg = "websocket: connection closed"
+		}
+		c.logger.LogAttrs(context.Background(), level, msg,
+			slog.Int("code", ce.Code),
+			slog.String("text", ce.Text))
+	case errors.Is(err, net.ErrClosed):
+		c.logger.LogAttrs(context.Background(), slog.LevelDebug, "websocket: connection closed",
+			slog.String("error", err.Error()))
+	default:
+		c.logger.LogAttrs(context.Background(), slog.LevelWarn, "websocket: connection closed abnormally",
+			slog.Int("code", CloseAbnormalClosure),
+			slog.String("error", err.Error()))
+	}
+}
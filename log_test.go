@@ -0,0 +1,137 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// logRecorder returns a logger that writes JSON records to a buffer and a
+// function that returns the records written so far.
+func logRecorder(t *testing.T) (*slog.Logger, func() []map[string]interface{}) {
+	var b bytes.Buffer
+	h := slog.NewJSONHandler(&b, &slog.HandlerOptions{Level: slog.LevelDebug})
+	return slog.New(h), func() []map[string]interface{} {
+		var records []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var r map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &r); err != nil {
+				t.Fatal(err)
+			}
+			records = append(records, r)
+		}
+		return records
+	}
+}
+
+func TestLogProtocolError(t *testing.T) {
+	logger, records := logRecorder(t)
+	// Unmasked text frame with RSV2 set sent to a server.
+	c := newTestConn(bytes.NewReader([]byte{0x80 | 0x20 | TextMessage, 0}), &bytes.Buffer{}, true)
+	c.SetLogger(logger)
+	_, _, err := c.ReadMessage()
+	if err == nil {
+		t.Fatal("ReadMessage() succeeded, want error")
+	}
+	_, _, _ = c.ReadMessage()
+
+	rs := records()
+	if len(rs) != 1 {
+		t.Fatalf("records = %v, want one record", rs)
+	}
+	r := rs[0]
+	frame, _ := r["frame"].(map[string]interface{})
+	if r["level"] != "WARN" || r["msg"] != "websocket: protocol error" ||
+		r["error"] != "RSV2 set, bad MASK" || r["role"] != "server" ||
+		r["remote_addr"] != remoteAddr.String() ||
+		frame["opcode"] != float64(TextMessage) || frame["rsv2"] != true || frame["mask"] != false {
+		t.Errorf("record = %v", r)
+	}
+}
+
+func TestLogReadLimit(t *testing.T) {
+	logger, records := logRecorder(t)
+	var b bytes.Buffer
+	wc := newTestConn(nil, &b, false)
+	_ = wc.WriteMessage(BinaryMessage, make([]byte, 100))
+	rc := newTestConn(&b, &bytes.Buffer{}, true)
+	rc.SetLogger(logger)
+	rc.SetReadLimit(10)
+	if _, _, err := rc.ReadMessage(); err != ErrReadLimit {
+		t.Fatalf("ReadMessage() returned %v, want ErrReadLimit", err)
+	}
+
+	rs := records()
+	if len(rs) != 1 || rs[0]["msg"] != "websocket: read limit exceeded" ||
+		rs[0]["limit"] != float64(10) || rs[0]["length"] != float64(100) {
+		t.Errorf("records = %v", rs)
+	}
+}
+
+func TestLogClose(t *testing.T) {
+	tests := []struct {
+		data  []byte
+		level string
+		msg   string
+		code  int
+	}{
+		{FormatCloseMessage(CloseNormalClosure, ""), "DEBUG", "websocket: connection closed", CloseNormalClosure},
+		{FormatCloseMessage(CloseInternalServerErr, "oops"), "WARN", "websocket: connection closed abnormally", CloseInternalServerErr},
+		{nil, "WARN", "websocket: connection closed abnormally", CloseAbnormalClosure},
+	}
+	for _, tt := range tests {
+		logger, records := logRecorder(t)
+		var b bytes.Buffer
+		if tt.data != nil {
+			wc := newTestConn(nil, &b, false)
+			_ = wc.WriteMessage(CloseMessage, tt.data)
+		}
+		rc := newTestConn(&b, &bytes.Buffer{}, true)
+		rc.SetLogger(logger)
+		_, _, _ = rc.ReadMessage()
+		_, _, _ = rc.ReadMessage()
+
+		rs := records()
+		if len(rs) != 1 || rs[0]["level"] != tt.level || rs[0]["msg"] != tt.msg || rs[0]["code"] != float64(tt.code) {
+			t.Errorf("close %v: records = %v", tt.data, rs)
+		}
+	}
+}
+
+func TestLogHandshakeRejected(t *testing.T) {
+	logger, records := logRecorder(t)
+	upgrader := Upgrader{Logger: logger}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = upgrader.Upgrade(w, r, nil)
+	}))
+	defer s.Close()
+
+	dlogger, drecords := logRecorder(t)
+	d := Dialer{Logger: dlogger}
+	_, _, err := d.Dial(makeWsProto(s.URL), http.Header{"Origin": {"http://other.example"}})
+	if err == nil {
+		t.Fatal("Dial() succeeded, want error")
+	}
+
+	rs := records()
+	if len(rs) != 1 || rs[0]["level"] != "INFO" || rs[0]["msg"] != "websocket: handshake rejected" ||
+		rs[0]["status"] != float64(http.StatusForbidden) || rs[0]["check"] != "origin" {
+		t.Errorf("upgrader records = %v", rs)
+	}
+	rs = drecords()
+	if len(rs) != 1 || rs[0]["msg"] != "websocket: handshake rejected" ||
+		rs[0]["status"] != float64(http.StatusForbidden) || rs[0]["check"] != "status" {
+		t.Errorf("dialer records = %v", rs)
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// Metrics receives measurements from connections. Set the Metrics field of
+// Upgrader or Dialer to collect measurements. A connection without metrics
+// does not take measurements.
+//
+// The methods are called concurrently from the goroutines reading and
+// writing connections and should return quickly. A Metrics value is
+// typically shared by many connections.
+//
+// See the expvarmetrics package for an implementation that publishes the
+// measurements with the expvar package.
+type Metrics interface {
+	// FrameRead is called when the header of a frame is read. The size is
+	// the size of the frame on the network, including the header.
+	FrameRead(frameType int, size int64)
+
+	// FrameWritten is called after a frame is written to the network. The
+	// size is the size of the frame on the network, including the header.
+	// Blocked is the time spent in the network connection's Write method.
+	FrameWritten(frameType int, size int64, blocked time.Duration)
+
+	// MessageRead is called when the application reads the last byte of a
+	// text or binary message or when the application advances to the next
+	// message before reading the last byte.
+	MessageRead(m MessageStats)
+
+	// MessageWritten is called after the last frame of a text or binary
+	// message is written to the network.
+	MessageWritten(m MessageStats)
+
+	// CloseReceived is called when a valid close frame is received. The code
+	// is CloseNoStatusReceived if the close frame does not have a payload.
+	CloseReceived(code int)
+
+	// CloseSent is called after a close frame is written to the network. The
+	// code is CloseNoStatusReceived if the close frame does not have a
+	// payload.
+	CloseSent(code int)
+}
+
+// MessageStats describes a text or binary message read from or written to a
+// connection.
+type MessageStats struct {
+	// Type is TextMessage or BinaryMessage.
+	Type int
+
+	// Frames is the number of frames in the message.
+	Frames int
+
+	// WireBytes is the size of the message on the network, including frame
+	// headers. For a message read from the network, WireBytes includes the
+	// frames read at the time MessageRead is called.
+	WireBytes int64
+
+	// AppBytes is the number of bytes read from or written to the message
+	// by the application. When a message is compressed, AppBytes is the
+	// size of the uncompressed message.
+	AppBytes int64
+
+	// Compressed reports whether the message is compressed.
+	Compressed bool
+}
+
+// observed reports whether measurements or trace events are collected for
+// the connection.
+func (c *Conn) observed() bool {
+	return c.metrics != nil || c.trace != nil
+}
+
+// statsReader reports a message read by the application.
+type statsReader struct {
+	c    *Conn
+	r    io.ReadCloser
+	n    int64
+	done bool
+}
+
+func (r *statsReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	switch {
+	case err == io.EOF:
+		r.report()
+	case err != nil && r.c.trace != nil:
+		r.c.readError(err)
+	}
+	return n, err
+}
+
+func (r *statsReader) Close() error {
+	r.report()
+	return r.r.Close()
+}
+
+func (r *statsReader) report() {
+	if r.done {
+		return
+	}
+	r.done = true
+	m := r.c.readStats
+	m.AppBytes = r.n
+	if r.c.metrics != nil {
+		r.c.metrics.MessageRead(m)
+	}
+	if r.c.trace != nil && r.c.trace.MessageEnd != nil {
+		r.c.trace.MessageEnd(FrameReceived, m)
+	}
+}
+
+// statsWriter reports a message written by the application.
+type statsWriter struct {
+	c *Conn
+	w io.WriteCloser
+	n int64
+}
+
+func (w *statsWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+func (w *statsWriter) Close() error {
+	if err := w.w.Close(); err != nil {
+		return err
+	}
+	w.c.reportMessageWritten(w.n)
+	return nil
+}
+
+// messageStart starts measuring a text or binary message.
+func (c *Conn) messageStart(dir FrameDirection, messageType int) {
+	if dir == FrameSent {
+		c.writeStats = MessageStats{Type: messageType}
+	} else {
+		c.readStats = MessageStats{Type: messageType, Compressed: c.readDecompress}
+	}
+	if c.trace != nil && c.trace.MessageStart != nil {
+		c.trace.MessageStart(dir, messageType)
+	}
+}
+
+// reportMessageWritten reports the message in c.writeStats.
+func (c *Conn) reportMessageWritten(appBytes int64) {
+	m := c.writeStats
+	m.AppBytes = appBytes
+	if c.metrics != nil {
+		c.metrics.MessageWritten(m)
+	}
+	if c.trace != nil && c.trace.MessageEnd != nil {
+		c.trace.MessageEnd(FrameSent, m)
+	}
+}
+
+// payloadCloseCode returns the close code and text in a close frame payload.
+func payloadCloseCode(payload []byte) (int, string) {
+	if len(payload) < 2 {
+		return CloseNoStatusReceived, ""
+	}
+	return int(binary.BigEndian.Uint16(payload)), string(payload[2:])
+}
+
+// frameRead reports a frame read from the network.
+func (c *Conn) frameRead(frameType int, size int64) {
+	if c.metrics != nil {
+		c.metrics.FrameRead(frameType, size)
+	}
+	if c.trace != nil && c.trace.FrameRead != nil {
+		c.trace.FrameRead(frameType, size)
+	}
+	switch frameType {
+	case TextMessage, BinaryMessage:
+		c.messageStart(FrameReceived, frameType)
+		fallthrough
+	case continuationFrame:
+		c.readStats.Frames++
+		c.readStats.WireBytes += size
+	}
+}
+
+// frameWritten reports a frame written to the network.
+func (c *Conn) frameWritten(frameType int, size int64, blocked time.Duration) {
+	if c.metrics != nil {
+		c.metrics.FrameWritten(frameType, size, blocked)
+	}
+	if c.trace != nil && c.trace.FrameWritten != nil {
+		c.trace.FrameWritten(frameType, size)
+	}
+	switch frameType {
+	case TextMessage, BinaryMessage, continuationFrame:
+		c.writeStats.Frames++
+		c.writeStats.WireBytes += size
+	}
+}
+
+// controlSent reports a control frame written to the network.
+func (c *Conn) controlSent(frameType int, payload []byte) {
+	switch frameType {
+	case CloseMessage:
+		code, text := payloadCloseCode(payload)
+		if c.metrics != nil {
+			c.metrics.CloseSent(code)
+		}
+		if c.trace != nil && c.trace.CloseSent != nil {
+			c.trace.CloseSent(code, text)
+		}
+	case PingMessage:
+		if c.trace != nil {
+			c.pingSent(payload)
+		}
+	}
+}
+
+// closeReceived reports a valid close frame received from the peer.
+func (c *Conn) closeReceived(code int, text string) {
+	if c.metrics != nil {
+		c.metrics.CloseReceived(code)
+	}
+	if c.trace != nil && c.trace.CloseReceived != nil {
+		c.trace.CloseReceived(code, text)
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testMetrics struct {
+	mu            sync.Mutex
+	framesRead    []int
+	framesWritten []int
+	wireRead      int64
+	wireWritten   int64
+	read          []MessageStats
+	written       []MessageStats
+	closeReceived []int
+	closeSent     []int
+}
+
+func (m *testMetrics) FrameRead(frameType int, size int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.framesRead = append(m.framesRead, frameType)
+	m.wireRead += size
+}
+
+func (m *testMetrics) FrameWritten(frameType int, size int64, blocked time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.framesWritten = append(m.framesWritten, frameType)
+	m.wireWritten += size
+}
+
+func (m *testMetrics) MessageRead(s MessageStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.read = append(m.read, s)
+}
+
+func (m *testMetrics) MessageWritten(s MessageStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.written = append(m.written, s)
+}
+
+func (m *testMetrics) CloseReceived(code int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closeReceived = append(m.closeReceived, code)
+}
+
+func (m *testMetrics) CloseSent(code int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closeSent = append(m.closeSent, code)
+}
+
+func TestMetrics(t *testing.T) {
+	for _, isServer := range []bool{true, false} {
+		for _, compress := range []bool{false, true} {
+			var b bytes.Buffer
+			var wm, rm testMetrics
+			wc := newTestConn(nil, &b, isServer)
+			wc.metrics = &wm
+			rc := newTestConn(&b, io.Discard, !isServer)
+			rc.metrics = &rm
+			if compress {
+				wc.newCompressionWriter = compressNoContextTakeover
+				rc.newDecompressionReader = decompressNoContextTakeover
+			}
+
+			binary := []byte(strings.Repeat("0123456789", 300))
+			_ = wc.WriteMessage(TextMessage, []byte("hello"))
+			w, _ := wc.NextWriter(BinaryMessage)
+			_, _ = w.Write(binary)
+			_ = w.Close()
+			_ = wc.WriteControl(PingMessage, []byte("ping"), time.Time{})
+			_ = wc.WriteMessage(CloseMessage, FormatCloseMessage(CloseGoingAway, "bye"))
+
+			if int64(b.Len()) != wm.wireWritten {
+				t.Errorf("server=%v compress=%v: wire bytes written = %d, want %d", isServer, compress, wm.wireWritten, b.Len())
+			}
+
+			if _, p, err := rc.ReadMessage(); err != nil || string(p) != "hello" {
+				t.Fatalf("ReadMessage() returned %q, %v", p, err)
+			}
+			if _, p, err := rc.ReadMessage(); err != nil || !bytes.Equal(p, binary) {
+				t.Fatalf("ReadMessage() returned %d bytes, %v", len(p), err)
+			}
+			if _, _, err := rc.ReadMessage(); !IsCloseError(err, CloseGoingAway) {
+				t.Fatalf("ReadMessage() returned %v, want close error", err)
+			}
+
+			if len(wm.written) != 2 ||
+				wm.written[0].Type != TextMessage || wm.written[0].AppBytes != 5 || wm.written[0].Frames != 1 ||
+				wm.written[1].Type != BinaryMessage || wm.written[1].AppBytes != int64(len(binary)) ||
+				wm.written[0].Compressed != compress || wm.written[1].Compressed != compress {
+				t.Errorf("server=%v compress=%v: written = %+v", isServer, compress, wm.written)
+			}
+			if !reflect.DeepEqual(rm.read, wm.written) {
+				t.Errorf("server=%v compress=%v: read = %+v, want %+v", isServer, compress, rm.read, wm.written)
+			}
+			if !reflect.DeepEqual(rm.framesRead, wm.framesWritten) || rm.wireRead != wm.wireWritten {
+				t.Errorf("server=%v compress=%v: frames read = %v, %d bytes, want %v, %d bytes",
+					isServer, compress, rm.framesRead, rm.wireRead, wm.framesWritten, wm.wireWritten)
+			}
+			if !reflect.DeepEqual(wm.closeSent, []int{CloseGoingAway}) ||
+				!reflect.DeepEqual(rm.closeReceived, []int{CloseGoingAway}) ||
+				!reflect.DeepEqual(rm.closeSent, []int{CloseGoingAway}) {
+				t.Errorf("server=%v compress=%v: close codes sent = %v, received = %v, replied = %v",
+					isServer, compress, wm.closeSent, rm.closeReceived, rm.closeSent)
+			}
+			if !reflect.DeepEqual(rm.framesWritten, []int{PongMessage, CloseMessage}) {
+				t.Errorf("server=%v compress=%v: reader frames written = %v", isServer, compress, rm.framesWritten)
+			}
+		}
+	}
+}
+
+func TestMetricsPartialRead(t *testing.T) {
+	var b bytes.Buffer
+	var m testMetrics
+	wc := newTestConn(nil, &b, true)
+	rc := newTestConn(&b, nil, false)
+	rc.metrics = &m
+
+	_ = wc.WriteMessage(TextMessage, []byte("hello"))
+	_ = wc.WriteMessage(TextMessage, []byte("world"))
+
+	_, r, _ := rc.NextReader()
+	_, _ = r.Read(make([]byte, 1))
+	_, _, _ = rc.ReadMessage()
+
+	want := []MessageStats{
+		{Type: TextMessage, Frames: 1, WireBytes: 7, AppBytes: 1},
+		{Type: TextMessage, Frames: 1, WireBytes: 7, AppBytes: 5},
+	}
+	if !reflect.DeepEqual(m.read, want) {
+		t.Errorf("read = %+v, want %+v", m.read, want)
+	}
+}
+
+func TestMetricsPreparedMessage(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		var b bytes.Buffer
+		var m testMetrics
+		c := newTestConn(nil, &b, true)
+		c.metrics = &m
+		if compress {
+			c.newCompressionWriter = compressNoContextTakeover
+		}
+		pm, _ := NewPreparedMessage(TextMessage, []byte("hello, hello, hello"))
+		if err := c.WritePreparedMessage(pm); err != nil {
+			t.Fatal(err)
+		}
+		want := []MessageStats{{Type: TextMessage, Frames: 1, WireBytes: int64(b.Len()), AppBytes: 19, Compressed: compress}}
+		if !reflect.DeepEqual(m.written, want) {
+			t.Errorf("compress=%v: written = %+v, want %+v", compress, m.written, want)
+		}
+	}
+}
@@ -0,0 +1,495 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package mux multiplexes logical streams over a WebSocket connection.
+//
+// Both ends of a connection create a Session and call Run. Either end opens
+// streams with Open and accepts streams opened by the peer with Accept. A
+// Stream is an io.ReadWriteCloser:
+//
+//	s := mux.NewSession(conn, nil)
+//	go s.Run(ctx)
+//	st, err := s.Open(1)
+//	if err != nil {
+//		return err
+//	}
+//	defer st.Close()
+//	_, err = io.Copy(st, file)
+//
+// # Flow control
+//
+// Each stream has a receive window. The peer sends at most the window of
+// unread data; the window is extended as the application reads from the
+// stream. A stream that is not read does not block other streams.
+//
+// # Scheduling
+//
+// Data from the streams with pending writes is sent in frames of at most
+// MaxFrameSize bytes in weighted round-robin order. A stream with priority
+// p sends up to p frames in each round, so a stream with priority 4 gets
+// four times the share of a stream with priority 1 when both have data to
+// send. Control frames are sent before data frames.
+//
+// # Wire format
+//
+// Each frame is a binary WebSocket message with a one byte frame type and
+// a four byte big-endian stream ID followed by the payload. The client side
+// of the connection opens streams with odd IDs and the server side opens
+// streams with even IDs.
+package mux
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/internal/lifecycle"
+)
+
+var (
+	// ErrClosed is returned from Session and Stream methods after the
+	// session is closed.
+	ErrClosed = errors.New("mux: session closed")
+
+	// ErrStreamReset is returned from Stream methods after the stream is
+	// reset by either end.
+	ErrStreamReset = errors.New("mux: stream reset")
+)
+
+// Options specifies options for a Session.
+type Options struct {
+	// WindowSize specifies the receive window of each stream in bytes.
+	// If zero, a default of 256 KiB is used. Values smaller than 64 KiB
+	// are increased to 64 KiB.
+	WindowSize int
+
+	// MaxFrameSize specifies the maximum payload size of the data frames
+	// sent by the session. If zero, a default of 16 KiB is used.
+	MaxFrameSize int
+
+	// AcceptBacklog specifies the maximum number of streams opened by the
+	// peer and not yet accepted. Streams opened by the peer when the
+	// backlog is full are reset. If zero, a default of 64 is used.
+	AcceptBacklog int
+}
+
+const (
+	// initialWindow is the receive window of a new stream before the
+	// receiver sends a window update.
+	initialWindow = 64 << 10
+
+	defaultWindowSize    = 256 << 10
+	defaultMaxFrameSize  = 16 << 10
+	defaultAcceptBacklog = 64
+
+	maxPriority = 255
+)
+
+// Frame types.
+const (
+	frameOpen   = 0 // payload: priority (1 byte)
+	frameData   = 1 // payload: data
+	frameWindow = 2 // payload: window increment (4 bytes)
+	frameFin    = 3 // no payload; the sender does not send more data
+	frameReset  = 4 // no payload; the stream is aborted
+)
+
+const headerSize = 5
+
+type frame struct {
+	typ  byte
+	id   uint32
+	data []byte
+}
+
+type protocolError string
+
+func (e protocolError) Error() string { return "mux: protocol error: " + string(e) }
+
+// Session is a multiplexed connection.
+//
+// It is safe to call Session and Stream methods concurrently.
+type Session struct {
+	conn *websocket.Conn
+	opts Options
+
+	accept chan *Stream
+	done   chan struct{} // closed when the session is closed
+
+	mu      sync.Mutex
+	wcond   *sync.Cond // signals the write loop
+	streams map[uint32]*Stream
+	nextID  uint32
+	ctrl    []frame   // queued control frames
+	sendq   []*Stream // streams with pending writes
+	rr      int       // index of the current stream in sendq
+	err     error     // set when the session is closed
+	closed  bool      // closed with Close
+}
+
+// NewSession returns a session for conn. The session reads from conn and
+// writes to conn in Run. The application must not otherwise read from or
+// write to conn. If opts is nil, default options are used.
+func NewSession(conn *websocket.Conn, opts *Options) *Session {
+	s := &Session{
+		conn:    conn,
+		done:    make(chan struct{}),
+		streams: make(map[uint32]*Stream),
+		nextID:  1,
+	}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.WindowSize <= 0 {
+		s.opts.WindowSize = defaultWindowSize
+	}
+	if s.opts.WindowSize < initialWindow {
+		s.opts.WindowSize = initialWindow
+	}
+	if s.opts.MaxFrameSize <= 0 {
+		s.opts.MaxFrameSize = defaultMaxFrameSize
+	}
+	if s.opts.AcceptBacklog <= 0 {
+		s.opts.AcceptBacklog = defaultAcceptBacklog
+	}
+	if conn.IsServer() {
+		s.nextID = 2
+	}
+	s.accept = make(chan *Stream, s.opts.AcceptBacklog)
+	s.wcond = sync.NewCond(&s.mu)
+	return s
+}
+
+// Conn returns the WebSocket connection used by s.
+func (s *Session) Conn() *websocket.Conn {
+	return s.conn
+}
+
+// Run reads and writes frames until the connection fails, ctx is done or
+// Close is called. When Run returns, the session is closed and the streams
+// return ErrClosed.
+//
+// Run returns nil if the session was closed with Close or the peer closed
+// the connection normally, ctx.Err() if ctx is done and the read error
+// otherwise. If the peer violates the protocol, Run closes the connection
+// with the close code websocket.CloseProtocolError and returns the error.
+func (s *Session) Run(ctx context.Context) error {
+	stop := lifecycle.Watch(ctx, func() { s.conn.Close() })
+	writeDone := make(chan struct{})
+	go func() {
+		defer close(writeDone)
+		s.writeLoop()
+	}()
+
+	err := s.readLoop()
+	stop()
+	var perr protocolError
+	if errors.As(err, &perr) {
+		_ = lifecycle.Close(s.conn, websocket.CloseProtocolError, "")
+	}
+	s.shutdown()
+	s.conn.Close()
+	<-writeDone
+	return lifecycle.RunError(ctx, s.isClosed(), err)
+}
+
+func (s *Session) readLoop() error {
+	for {
+		messageType, r, err := s.conn.NextReader()
+		if err != nil {
+			return err
+		}
+		if messageType != websocket.BinaryMessage {
+			return protocolError("text message")
+		}
+		var hdr [headerSize]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return protocolError("short frame")
+			}
+			return err
+		}
+		p, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if err := s.handle(frame{typ: hdr[0], id: binary.BigEndian.Uint32(hdr[1:]), data: p}); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Session) handle(f frame) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.streams[f.id]
+	switch f.typ {
+	case frameOpen:
+		if len(f.data) != 1 {
+			return protocolError("invalid open frame")
+		}
+		// The peer opens streams with the parity of its role.
+		if f.id == 0 || st != nil || (f.id%2 == 0) == s.conn.IsServer() {
+			return protocolError("invalid stream ID")
+		}
+		st = s.newStreamLocked(f.id, int(f.data[0]))
+		select {
+		case s.accept <- st:
+			s.growWindowLocked(st)
+		default:
+			st.resetLocked()
+		}
+	case frameData:
+		if st == nil {
+			// The stream was reset.
+			return nil
+		}
+		if st.remoteClosed {
+			return protocolError("data after fin")
+		}
+		if len(f.data) > st.recvWindow {
+			return protocolError("flow control window exceeded")
+		}
+		st.recvWindow -= len(f.data)
+		st.recvBuf.Write(f.data)
+		st.cond.Broadcast()
+	case frameWindow:
+		if len(f.data) != 4 {
+			return protocolError("invalid window frame")
+		}
+		if st != nil {
+			st.sendWindow += int(binary.BigEndian.Uint32(f.data))
+			s.wcond.Signal()
+		}
+	case frameFin:
+		if st != nil {
+			st.remoteClosed = true
+			st.cond.Broadcast()
+			s.maybeRemoveLocked(st)
+		}
+	case frameReset:
+		if st != nil {
+			st.err = ErrStreamReset
+			s.removeLocked(st)
+		}
+	default:
+		return protocolError("unknown frame type")
+	}
+	return nil
+}
+
+// newStreamLocked adds a stream to the session.
+func (s *Session) newStreamLocked(id uint32, priority int) *Stream {
+	st := &Stream{
+		s:          s,
+		id:         id,
+		priority:   clampPriority(priority),
+		sendWindow: initialWindow,
+		recvWindow: s.opts.WindowSize,
+		cond:       sync.NewCond(&s.mu),
+	}
+	st.credits = st.priority
+	s.streams[id] = st
+	return st
+}
+
+// growWindowLocked queues a window update for the receive window of st
+// beyond the initial window.
+func (s *Session) growWindowLocked(st *Stream) {
+	if n := s.opts.WindowSize - initialWindow; n > 0 {
+		s.queueLocked(windowFrame(st.id, n))
+	}
+}
+
+func windowFrame(id uint32, n int) frame {
+	return frame{typ: frameWindow, id: id, data: binary.BigEndian.AppendUint32(nil, uint32(n))}
+}
+
+func clampPriority(p int) int {
+	switch {
+	case p < 1:
+		return 1
+	case p > maxPriority:
+		return maxPriority
+	}
+	return p
+}
+
+func (s *Session) queueLocked(f frame) {
+	s.ctrl = append(s.ctrl, f)
+	s.wcond.Signal()
+}
+
+func (s *Session) maybeRemoveLocked(st *Stream) {
+	if st.localClosed && st.remoteClosed {
+		s.removeLocked(st)
+	}
+}
+
+func (s *Session) removeLocked(st *Stream) {
+	delete(s.streams, st.id)
+	st.cond.Broadcast()
+}
+
+// nextLocked returns the stream to send data from next and the data to
+// send, or nil if no stream can send.
+func (s *Session) nextLocked() (*Stream, []byte) {
+	for i := 0; i < len(s.sendq); i++ {
+		if s.rr >= len(s.sendq) {
+			s.rr = 0
+		}
+		st := s.sendq[s.rr]
+		if len(st.pending) > 0 && st.sendWindow > 0 && st.err == nil {
+			n := min(len(st.pending), st.sendWindow, s.opts.MaxFrameSize)
+			st.sendWindow -= n
+			st.credits--
+			if st.credits <= 0 {
+				st.credits = st.priority
+				s.rr++
+			}
+			return st, st.pending[:n]
+		}
+		st.credits = st.priority
+		s.rr++
+	}
+	return nil, nil
+}
+
+func (s *Session) writeLoop() {
+	for {
+		s.mu.Lock()
+		var (
+			f  frame
+			st *Stream
+		)
+		for {
+			if s.err != nil {
+				s.mu.Unlock()
+				return
+			}
+			if len(s.ctrl) > 0 {
+				f = s.ctrl[0]
+				s.ctrl = s.ctrl[1:]
+				break
+			}
+			if st, f.data = s.nextLocked(); st != nil {
+				f.typ, f.id = frameData, st.id
+				st.writing = true
+				break
+			}
+			s.wcond.Wait()
+		}
+		s.mu.Unlock()
+
+		err := s.writeFrame(f)
+
+		if st != nil {
+			s.mu.Lock()
+			if len(st.pending) >= len(f.data) {
+				st.pending = st.pending[len(f.data):]
+			}
+			st.writing = false
+			st.cond.Broadcast()
+			s.mu.Unlock()
+		}
+		if err != nil {
+			// The read loop fails when the connection is closed.
+			s.conn.Close()
+			return
+		}
+	}
+}
+
+func (s *Session) writeFrame(f frame) error {
+	w, err := s.conn.NextWriter(websocket.BinaryMessage)
+	if err != nil {
+		return err
+	}
+	var hdr [headerSize]byte
+	hdr[0] = f.typ
+	binary.BigEndian.PutUint32(hdr[1:], f.id)
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(f.data); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+func (s *Session) shutdown() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = ErrClosed
+		close(s.done)
+	}
+	for _, st := range s.streams {
+		if st.err == nil {
+			st.err = ErrClosed
+		}
+		st.cond.Broadcast()
+	}
+	s.streams = make(map[uint32]*Stream)
+	s.wcond.Broadcast()
+}
+
+func (s *Session) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// Open opens a new stream with the given priority. The priority is
+// clamped to the range 1 to 255 and applies to data sent in both
+// directions. Open does not wait for the peer to accept the stream.
+func (s *Session) Open(priority int) (*Stream, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	id := s.nextID
+	s.nextID += 2
+	st := s.newStreamLocked(id, priority)
+	s.queueLocked(frame{typ: frameOpen, id: id, data: []byte{byte(st.priority)}})
+	s.growWindowLocked(st)
+	return st, nil
+}
+
+// Accept waits for and returns the next stream opened by the peer.
+func (s *Session) Accept(ctx context.Context) (*Stream, error) {
+	select {
+	case st := <-s.accept:
+		return st, nil
+	case <-s.done:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Done returns a channel that is closed when the session is closed.
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close sends a close message to the peer, closes the connection and stops
+// Run. Open streams return ErrClosed.
+func (s *Session) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	err := lifecycle.Close(s.conn, websocket.CloseNormalClosure, "")
+	s.shutdown()
+	return err
+}
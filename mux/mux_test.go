@@ -0,0 +1,289 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mux
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/wstest"
+)
+
+func newSessions(t *testing.T, opts *Options) (client, server *Session) {
+	t.Helper()
+	cc, sc, err := wstest.Pair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = NewSession(cc, opts)
+	server = NewSession(sc, opts)
+	go client.Run(context.Background())
+	go server.Run(context.Background())
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return client, server
+}
+
+// echo accepts streams from s and writes the data read from each stream
+// back to the stream.
+func echo(s *Session) {
+	for {
+		st, err := s.Accept(context.Background())
+		if err != nil {
+			return
+		}
+		go func() {
+			io.Copy(st, st)
+			st.Close()
+		}()
+	}
+}
+
+func TestStreams(t *testing.T) {
+	client, server := newSessions(t, &Options{MaxFrameSize: 1000})
+	go echo(server)
+	go echo(client)
+
+	for _, tt := range []struct {
+		s      *Session
+		wantID uint32
+	}{
+		{client, 1},
+		{client, 3},
+		{server, 2},
+	} {
+		st, err := tt.s.Open(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.ID() != tt.wantID {
+			t.Errorf("ID() = %d, want %d", st.ID(), tt.wantID)
+		}
+		data := make([]byte, 10000)
+		rand.Read(data)
+		go func() {
+			st.Write(data)
+			st.Close()
+		}()
+		got, err := io.ReadAll(st)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("stream %d: echoed data differs", st.ID())
+		}
+	}
+}
+
+func TestFlowControl(t *testing.T) {
+	client, server := newSessions(t, &Options{WindowSize: initialWindow})
+	go echo(server)
+
+	// Fill the window of a stream that is not read.
+	stalled, err := client.Open(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	written := make(chan int)
+	go func() {
+		n, _ := stalled.Write(make([]byte, 4*initialWindow))
+		written <- n
+	}()
+
+	// Other streams are not blocked.
+	st, err := client.Open(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, 3*initialWindow)
+	rand.Read(data)
+	go func() {
+		st.Write(data)
+		st.Close()
+	}()
+	got, err := io.ReadAll(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("echoed data differs")
+	}
+
+	select {
+	case n := <-written:
+		t.Fatalf("write to stalled stream returned %d", n)
+	default:
+	}
+	if _, err := io.ReadFull(stalled, make([]byte, 4*initialWindow)); err != nil {
+		t.Fatal(err)
+	}
+	if n := <-written; n != 4*initialWindow {
+		t.Errorf("Write() returned %d, want %d", n, 4*initialWindow)
+	}
+}
+
+func TestScheduling(t *testing.T) {
+	s := &Session{opts: Options{MaxFrameSize: 1}}
+	low := &Stream{id: 1, priority: 1, credits: 1}
+	high := &Stream{id: 3, priority: 3, credits: 3}
+	for _, st := range []*Stream{low, high} {
+		st.pending = make([]byte, 100)
+		st.sendWindow = 100
+		s.sendq = append(s.sendq, st)
+	}
+	counts := map[uint32]int{}
+	for i := 0; i < 40; i++ {
+		st, p := s.nextLocked()
+		if len(p) != 1 {
+			t.Fatalf("len(p) = %d, want 1", len(p))
+		}
+		counts[st.id]++
+	}
+	if counts[1] != 10 || counts[3] != 30 {
+		t.Errorf("frames sent = %v, want 10 for priority 1 and 30 for priority 3", counts)
+	}
+
+	// A stream without send window is skipped.
+	high.sendWindow = 0
+	for i := 0; i < 5; i++ {
+		if st, _ := s.nextLocked(); st != low {
+			t.Fatalf("nextLocked() returned stream %d, want 1", st.id)
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	client, server := newSessions(t, nil)
+	st, err := client.Open(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	peer, err := server.Accept(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := peer.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Read(make([]byte, 1)); err != ErrStreamReset {
+		t.Errorf("Read() returned %v, want %v", err, ErrStreamReset)
+	}
+	if _, err := st.Write([]byte("x")); err != ErrStreamReset {
+		t.Errorf("Write() returned %v, want %v", err, ErrStreamReset)
+	}
+	if _, err := peer.Read(make([]byte, 1)); err != ErrStreamReset {
+		t.Errorf("peer Read() returned %v, want %v", err, ErrStreamReset)
+	}
+}
+
+func TestResetDuringWrite(t *testing.T) {
+	cc, sc, err := wstest.Pair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewSession(cc, &Options{MaxFrameSize: 1024})
+	defer client.Close()
+	go client.Run(context.Background())
+	st, err := client.Open(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The peer reads two frames, resets the stream while the client is
+	// writing the next data frame and closes the connection.
+	go func() {
+		for i := 0; i < 2; i++ {
+			if _, _, err := sc.ReadMessage(); err != nil {
+				return
+			}
+		}
+		var reset [headerSize]byte
+		reset[0] = frameReset
+		binary.BigEndian.PutUint32(reset[1:], st.ID())
+		sc.WriteMessage(websocket.BinaryMessage, reset[:])
+		sc.Close()
+	}()
+
+	p := make([]byte, 16<<10)
+	n, err := st.Write(p)
+	if err == nil || n >= len(p) {
+		t.Errorf("Write() = %d, %v, want short write and error", n, err)
+	}
+	// Write must not return while the write loop uses p.
+	client.mu.Lock()
+	writing := st.writing
+	client.mu.Unlock()
+	if writing {
+		t.Error("Write() returned while the write loop is writing the data")
+	}
+}
+
+func TestClose(t *testing.T) {
+	cc, sc, err := wstest.Pair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewSession(cc, nil)
+	server := NewSession(sc, nil)
+	errc := make(chan error, 1)
+	go client.Run(context.Background())
+	go func() { errc <- server.Run(context.Background()) }()
+
+	st, err := client.Open(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.Accept(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+
+	if err := <-errc; err != nil {
+		t.Errorf("server Run() returned %v, want nil", err)
+	}
+	if _, err := st.Read(make([]byte, 1)); err != ErrClosed {
+		t.Errorf("Read() returned %v, want %v", err, ErrClosed)
+	}
+	if _, err := server.Accept(context.Background()); err != ErrClosed {
+		t.Errorf("Accept() returned %v, want %v", err, ErrClosed)
+	}
+	if _, err := client.Open(1); err != ErrClosed {
+		t.Errorf("Open() returned %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestProtocolError(t *testing.T) {
+	cc, sc, err := wstest.Pair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	server := NewSession(sc, nil)
+	errc := make(chan error, 1)
+	go func() { errc <- server.Run(context.Background()) }()
+
+	// The client opens streams with odd IDs.
+	if err := cc.WriteMessage(websocket.BinaryMessage, []byte{frameOpen, 0, 0, 0, 2, 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := cc.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseProtocolError) {
+		t.Errorf("ReadMessage() returned %v, want close error with CloseProtocolError", err)
+	}
+	var perr protocolError
+	if err := <-errc; !errors.As(err, &perr) {
+		t.Errorf("Run() returned %v, want protocol error", err)
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mux
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// Stream is a logical stream in a session.
+type Stream struct {
+	s    *Session
+	id   uint32
+	cond *sync.Cond // signals changes to the stream state; uses s.mu
+
+	// The following fields are protected by s.mu.
+	priority     int
+	credits      int    // frames left in the current scheduling round
+	sendWindow   int    // bytes the peer allows us to send
+	pending      []byte // data of the blocked Write
+	writing      bool   // the write loop is writing pending data
+	recvBuf      bytes.Buffer
+	recvWindow   int // bytes the peer may send
+	unacked      int // bytes read and not yet returned to the peer's window
+	localClosed  bool
+	remoteClosed bool
+	err          error
+}
+
+// ID returns the stream ID.
+func (st *Stream) ID() uint32 {
+	return st.id
+}
+
+// Priority returns the scheduling priority of the stream.
+func (st *Stream) Priority() int {
+	st.s.mu.Lock()
+	defer st.s.mu.Unlock()
+	return st.priority
+}
+
+// SetPriority sets the priority for data written to the stream by this end
+// of the connection. The priority is clamped to the range 1 to 255.
+func (st *Stream) SetPriority(priority int) {
+	st.s.mu.Lock()
+	defer st.s.mu.Unlock()
+	st.priority = clampPriority(priority)
+}
+
+// Read reads data from the stream. Read returns io.EOF after the peer
+// closes the stream and all data is read.
+func (st *Stream) Read(p []byte) (int, error) {
+	s := st.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for st.recvBuf.Len() == 0 && !st.remoteClosed && st.err == nil {
+		st.cond.Wait()
+	}
+	if st.recvBuf.Len() == 0 {
+		if st.remoteClosed {
+			return 0, io.EOF
+		}
+		return 0, st.err
+	}
+	n, _ := st.recvBuf.Read(p)
+	st.unacked += n
+	// Return the read bytes to the peer in batches.
+	if st.unacked >= s.opts.WindowSize/2 && !st.remoteClosed && st.err == nil {
+		st.recvWindow += st.unacked
+		s.queueLocked(windowFrame(st.id, st.unacked))
+		st.unacked = 0
+	}
+	return n, nil
+}
+
+// Write writes data to the stream. Write blocks until all data is sent or
+// the stream fails.
+func (st *Stream) Write(p []byte) (int, error) {
+	s := st.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case st.err != nil:
+		return 0, st.err
+	case st.localClosed:
+		return 0, io.ErrClosedPipe
+	case len(p) == 0:
+		return 0, nil
+	}
+	st.pending = p
+	s.sendq = append(s.sendq, st)
+	s.wcond.Signal()
+	// Wait for the write loop to finish a frame in progress even if the
+	// stream fails. The write loop reads p until the frame is written.
+	for (len(st.pending) > 0 && st.err == nil) || st.writing {
+		st.cond.Wait()
+	}
+	n := len(p) - len(st.pending)
+	st.pending = nil
+	for i, q := range s.sendq {
+		if q == st {
+			s.sendq = append(s.sendq[:i], s.sendq[i+1:]...)
+			break
+		}
+	}
+	if n < len(p) {
+		return n, st.err
+	}
+	return n, nil
+}
+
+// Close closes the stream for writing. The peer reads io.EOF after the
+// data written before Close. The stream can be read until the peer closes
+// the stream.
+func (st *Stream) Close() error {
+	s := st.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st.err != nil {
+		if st.err == ErrStreamReset {
+			return nil
+		}
+		return st.err
+	}
+	if st.localClosed {
+		return nil
+	}
+	st.localClosed = true
+	s.queueLocked(frame{typ: frameFin, id: st.id})
+	s.maybeRemoveLocked(st)
+	return nil
+}
+
+// Reset aborts the stream in both directions. Pending and future reads and
+// writes on both ends return ErrStreamReset.
+func (st *Stream) Reset() error {
+	s := st.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st.err != nil {
+		return nil
+	}
+	st.resetLocked()
+	return nil
+}
+
+func (st *Stream) resetLocked() {
+	st.err = ErrStreamReset
+	st.recvBuf.Reset()
+	st.s.queueLocked(frame{typ: frameReset, id: st.id})
+	st.s.removeLocked(st)
+}
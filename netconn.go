@@ -0,0 +1,395 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// NetConnOptions specifies how a NetConnAdapter maps the byte stream onto
+// WebSocket messages.
+type NetConnOptions struct {
+	// MaxMessageSize specifies the maximum payload size in bytes of a message
+	// sent by the adapter. Writes larger than this size are split across
+	// multiple messages. If zero, the size of messages is not limited.
+	MaxMessageSize int
+
+	// Buffered specifies whether writes are accumulated in the current
+	// message. If false, each call to Write sends the data as one or more
+	// complete messages. If true, the current message is sent when Flush or
+	// Close is called or when the message reaches MaxMessageSize. The
+	// connection's write buffer size determines how much data is held before
+	// a fragment of the message is sent to the network.
+	Buffered bool
+}
+
+// NetConnAdapter adapts a WebSocket connection to the net.Conn interface.
+// Data written to the adapter is sent as binary messages. Data read from the
+// adapter is the concatenation of the payloads of the data messages received
+// from the peer.
+//
+// The adapter can be used with any consumer of net.Conn, such as tls.Client,
+// tls.Server or http.Serve with a single connection listener. Applications
+// must not read from or write to the wrapped Conn while the adapter is in use.
+//
+// The adapter reads the WebSocket connection in a goroutine started by the
+// first call to Read. A read that times out because of the read deadline
+// does not affect the connection and can be retried after extending the
+// deadline, as net/http does. A write that times out corrupts the
+// connection as with the Conn deadline methods.
+//
+// Read, Write and Close can be called concurrently. At most one goroutine
+// should call Read at a time and at most one goroutine should call Write or
+// Flush at a time.
+type NetConnAdapter struct {
+	c    *Conn
+	opts NetConnOptions
+
+	readOnce     sync.Once
+	readMu       sync.Mutex
+	pending      []byte        // unread data from the last chunk
+	chunks       chan []byte   // data read by readLoop
+	readDone     chan struct{} // closed when readLoop returns
+	readErr      error         // error that stopped readLoop
+	readDeadline deadline
+
+	writeMu sync.Mutex
+	w       io.WriteCloser // writer for the current message in buffered mode
+	wn      int            // bytes written to w
+
+	deadlineMu    sync.Mutex
+	writeDeadline time.Time
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	closeErr  error
+}
+
+var _ net.Conn = (*NetConnAdapter)(nil)
+
+// NewNetConn returns an adapter for using c as a net.Conn. If opts is nil,
+// then each call to Write sends a single binary message.
+func NewNetConn(c *Conn, opts *NetConnOptions) *NetConnAdapter {
+	a := &NetConnAdapter{
+		c:            c,
+		chunks:       make(chan []byte),
+		readDone:     make(chan struct{}),
+		readDeadline: makeDeadline(),
+		closed:       make(chan struct{}),
+	}
+	if opts != nil {
+		a.opts = *opts
+	}
+	return a
+}
+
+// Conn returns the WebSocket connection wrapped by a.
+func (a *NetConnAdapter) Conn() *Conn {
+	return a.c
+}
+
+func (a *NetConnAdapter) isClosed() bool {
+	return isDone(a.closed)
+}
+
+// Read reads data from the payloads of the data messages received from the
+// peer. Messages boundaries are not preserved. Read returns io.EOF when the
+// peer closes the connection with CloseNormalClosure, CloseGoingAway or an
+// empty close message.
+func (a *NetConnAdapter) Read(p []byte) (int, error) {
+	a.readMu.Lock()
+	defer a.readMu.Unlock()
+	a.readOnce.Do(func() { go a.readLoop() })
+
+	if a.isClosed() {
+		return 0, net.ErrClosed
+	}
+	if isDone(a.readDeadline.wait()) {
+		return 0, os.ErrDeadlineExceeded
+	}
+	if len(a.pending) == 0 {
+		select {
+		case a.pending = <-a.chunks:
+		case <-a.readDone:
+			return 0, a.readError(a.readErr)
+		case <-a.closed:
+			return 0, net.ErrClosed
+		case <-a.readDeadline.wait():
+			return 0, os.ErrDeadlineExceeded
+		}
+	}
+	n := copy(p, a.pending)
+	a.pending = a.pending[n:]
+	return n, nil
+}
+
+// netConnReadSize is the maximum size of the chunks passed from readLoop to
+// Read.
+const netConnReadSize = 4096
+
+// readLoop reads the payloads of data messages and sends them to Read in
+// chunks until the connection fails or the adapter is closed.
+func (a *NetConnAdapter) readLoop() {
+	defer close(a.readDone)
+	for {
+		_, r, err := a.c.NextReader()
+		if err != nil {
+			a.readErr = err
+			return
+		}
+		for err != io.EOF {
+			var n int
+			p := make([]byte, netConnReadSize)
+			n, err = r.Read(p)
+			if err != nil && err != io.EOF {
+				a.readErr = err
+				return
+			}
+			if n == 0 {
+				continue
+			}
+			select {
+			case a.chunks <- p[:n]:
+			case <-a.closed:
+				a.readErr = net.ErrClosed
+				return
+			}
+		}
+	}
+}
+
+func (a *NetConnAdapter) readError(err error) error {
+	if a.isClosed() {
+		return net.ErrClosed
+	}
+	if IsCloseError(err, CloseNormalClosure, CloseGoingAway, CloseNoStatusReceived) {
+		return io.EOF
+	}
+	return err
+}
+
+// Write writes p to the connection as described in NetConnOptions.
+func (a *NetConnAdapter) Write(p []byte) (int, error) {
+	a.writeMu.Lock()
+	defer a.writeMu.Unlock()
+
+	if a.isClosed() {
+		return 0, net.ErrClosed
+	}
+	a.applyWriteDeadline()
+
+	nn := 0
+	for len(p) > 0 {
+		chunk := p
+		if max := a.opts.MaxMessageSize; max > 0 {
+			if a.opts.Buffered {
+				max -= a.wn
+			}
+			if len(chunk) > max {
+				chunk = chunk[:max]
+			}
+		}
+		var err error
+		if a.opts.Buffered {
+			err = a.writeBuffered(chunk)
+		} else {
+			err = a.c.WriteMessage(BinaryMessage, chunk)
+		}
+		if err != nil {
+			return nn, a.writeError(err)
+		}
+		nn += len(chunk)
+		p = p[len(chunk):]
+	}
+	return nn, nil
+}
+
+func (a *NetConnAdapter) writeBuffered(p []byte) error {
+	if a.w == nil {
+		w, err := a.c.NextWriter(BinaryMessage)
+		if err != nil {
+			return err
+		}
+		a.w = w
+		a.wn = 0
+	}
+	n, err := a.w.Write(p)
+	a.wn += n
+	if err != nil {
+		a.w = nil
+		return err
+	}
+	if a.opts.MaxMessageSize > 0 && a.wn >= a.opts.MaxMessageSize {
+		return a.flush()
+	}
+	return nil
+}
+
+func (a *NetConnAdapter) writeError(err error) error {
+	if a.isClosed() {
+		return net.ErrClosed
+	}
+	return err
+}
+
+// Flush sends the current message in buffered mode. Flush is a no-op if
+// the adapter is not in buffered mode or if no data is pending.
+func (a *NetConnAdapter) Flush() error {
+	a.writeMu.Lock()
+	defer a.writeMu.Unlock()
+	if a.isClosed() {
+		return net.ErrClosed
+	}
+	a.applyWriteDeadline()
+	return a.writeError(a.flush())
+}
+
+// applyWriteDeadline copies the deadline set by SetWriteDeadline to the
+// WebSocket connection. The caller must hold writeMu.
+func (a *NetConnAdapter) applyWriteDeadline() {
+	a.deadlineMu.Lock()
+	t := a.writeDeadline
+	a.deadlineMu.Unlock()
+	_ = a.c.SetWriteDeadline(t)
+}
+
+func (a *NetConnAdapter) flush() error {
+	if a.w == nil {
+		return nil
+	}
+	w := a.w
+	a.w = nil
+	a.wn = 0
+	return w.Close()
+}
+
+// Close flushes pending data if no write is in progress, sends a close
+// message with CloseNormalClosure to the peer and closes the underlying
+// network connection. Close does not wait for the peer to reply to the close
+// message. Blocked Read and Write calls return net.ErrClosed. Close waits for
+// the background read started by Read to stop.
+func (a *NetConnAdapter) Close() error {
+	a.closeOnce.Do(func() {
+		// Do not wait for a blocked writer. Closing the network connection
+		// below unblocks the writer.
+		if a.writeMu.TryLock() {
+			_ = a.flush()
+			a.writeMu.Unlock()
+		}
+		close(a.closed)
+		// Make a best effort to send the close message.
+		_ = a.c.WriteControl(CloseMessage, FormatCloseMessage(CloseNormalClosure, ""), time.Now().Add(writeWait))
+		a.closeErr = a.c.Close()
+		// Wait for readLoop so that it does not use the connection after
+		// Close returns. Mark the loop done if Read never started it.
+		a.readOnce.Do(func() { close(a.readDone) })
+		<-a.readDone
+	})
+	return a.closeErr
+}
+
+// LocalAddr returns the local network address.
+func (a *NetConnAdapter) LocalAddr() net.Addr {
+	return a.c.LocalAddr()
+}
+
+// RemoteAddr returns the remote network address.
+func (a *NetConnAdapter) RemoteAddr() net.Addr {
+	return a.c.RemoteAddr()
+}
+
+// SetDeadline sets the read and write deadlines on the connection.
+func (a *NetConnAdapter) SetDeadline(t time.Time) error {
+	if err := a.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return a.SetWriteDeadline(t)
+}
+
+// SetReadDeadline sets the read deadline on the connection. The deadline
+// applies to subsequent reads and to a read in progress. Reads that time out
+// return an error with a Timeout method that returns true and do not affect
+// later reads.
+func (a *NetConnAdapter) SetReadDeadline(t time.Time) error {
+	a.readDeadline.set(t)
+	return nil
+}
+
+// SetWriteDeadline sets the write deadline on the connection. The deadline
+// applies to subsequent writes and to a write in progress. As with the Conn
+// deadline methods, the WebSocket connection is corrupt after a write times
+// out and all future writes return an error.
+func (a *NetConnAdapter) SetWriteDeadline(t time.Time) error {
+	a.deadlineMu.Lock()
+	a.writeDeadline = t
+	a.deadlineMu.Unlock()
+	// Set the deadline on the network connection to interrupt a write in
+	// progress. The WebSocket connection sets the deadline again before
+	// writing each frame.
+	return a.c.conn.SetWriteDeadline(t)
+}
+
+// deadline is a deadline that can be changed while a goroutine waits for
+// it.
+type deadline struct {
+	mu     sync.Mutex
+	timer  *time.Timer
+	cancel chan struct{} // closed when the deadline expires
+}
+
+func makeDeadline() deadline {
+	return deadline{cancel: make(chan struct{})}
+}
+
+// set sets the deadline. A zero value for t means no deadline.
+func (d *deadline) set(t time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.timer != nil && !d.timer.Stop() {
+		// Wait for the timer function to close cancel.
+		<-d.cancel
+	}
+	d.timer = nil
+
+	expired := isDone(d.cancel)
+	if t.IsZero() {
+		if expired {
+			d.cancel = make(chan struct{})
+		}
+		return
+	}
+	if dur := time.Until(t); dur > 0 {
+		if expired {
+			d.cancel = make(chan struct{})
+		}
+		cancel := d.cancel
+		d.timer = time.AfterFunc(dur, func() { close(cancel) })
+		return
+	}
+	if !expired {
+		close(d.cancel)
+	}
+}
+
+// wait returns a channel that is closed when the deadline expires.
+func (d *deadline) wait() chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.cancel
+}
+
+func isDone(c chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
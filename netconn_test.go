@@ -0,0 +1,268 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newPipeConns returns a server and client connection connected with
+// net.Pipe.
+func newPipeConns() (server, client *Conn) {
+	s, c := net.Pipe()
+	return newConn(s, true, 1024, 1024, nil, nil, nil), newConn(c, false, 1024, 1024, nil, nil, nil)
+}
+
+func TestNetConnAdapterMessages(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *NetConnOptions
+		data []string
+		want []string
+	}{
+		{"default", nil, []string{"hello", "world"}, []string{"hello", "world"}},
+		{"max", &NetConnOptions{MaxMessageSize: 3}, []string{"hello"}, []string{"hel", "lo"}},
+		{"buffered", &NetConnOptions{Buffered: true}, []string{"hello", "world"}, []string{"helloworld"}},
+		{"bufferedMax", &NetConnOptions{Buffered: true, MaxMessageSize: 4}, []string{"hel", "lo", "world"}, []string{"hell", "owor", "ld"}},
+	}
+	for _, tt := range tests {
+		sc, cc := newPipeConns()
+		a := NewNetConn(cc, tt.opts)
+		done := make(chan error, 1)
+		go func() {
+			for _, d := range tt.data {
+				if _, err := a.Write([]byte(d)); err != nil {
+					done <- err
+					return
+				}
+			}
+			done <- a.Flush()
+		}()
+		for _, want := range tt.want {
+			mt, p, err := sc.ReadMessage()
+			if err != nil {
+				t.Fatalf("%s: ReadMessage() returned %v", tt.name, err)
+			}
+			if mt != BinaryMessage || string(p) != want {
+				t.Errorf("%s: ReadMessage() = %d, %q, want %d, %q", tt.name, mt, p, BinaryMessage, want)
+			}
+		}
+		if err := <-done; err != nil {
+			t.Errorf("%s: write returned %v", tt.name, err)
+		}
+		sc.Close()
+		cc.Close()
+	}
+}
+
+func TestNetConnAdapterRead(t *testing.T) {
+	sc, cc := newPipeConns()
+	a := NewNetConn(cc, nil)
+	go func() {
+		sc.WriteMessage(BinaryMessage, []byte("hello "))
+		sc.WriteMessage(TextMessage, []byte{})
+		sc.WriteMessage(BinaryMessage, []byte("world"))
+		sc.WriteMessage(CloseMessage, FormatCloseMessage(CloseNormalClosure, ""))
+		// Read the close message sent in reply.
+		sc.ReadMessage()
+	}()
+	p, err := io.ReadAll(a)
+	if err != nil {
+		t.Fatalf("ReadAll() returned %v", err)
+	}
+	if string(p) != "hello world" {
+		t.Errorf("ReadAll() = %q, want %q", p, "hello world")
+	}
+}
+
+func TestNetConnAdapterClose(t *testing.T) {
+	sc, cc := newPipeConns()
+	sa := NewNetConn(sc, nil)
+	ca := NewNetConn(cc, &NetConnOptions{Buffered: true})
+
+	go func() {
+		ca.Write([]byte("pending"))
+		ca.Close()
+	}()
+
+	p, err := io.ReadAll(sa)
+	if err != nil {
+		t.Fatalf("ReadAll() returned %v", err)
+	}
+	if string(p) != "pending" {
+		t.Errorf("ReadAll() = %q, want %q", p, "pending")
+	}
+	if err := sa.Close(); err != nil {
+		t.Errorf("Close() returned %v", err)
+	}
+	if _, err := sa.Read(p); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Read() after Close returned %v, want %v", err, net.ErrClosed)
+	}
+	if _, err := sa.Write(p); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Write() after Close returned %v, want %v", err, net.ErrClosed)
+	}
+}
+
+func TestNetConnAdapterReadDeadline(t *testing.T) {
+	sc, cc := newPipeConns()
+	defer sc.Close()
+	a := NewNetConn(cc, nil)
+	defer a.Close()
+
+	if err := a.SetReadDeadline(time.Now().Add(10 * time.Millisecond)); err != nil {
+		t.Fatalf("SetReadDeadline() returned %v", err)
+	}
+	var p [5]byte
+	_, err := a.Read(p[:])
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Errorf("Read() returned %v, want timeout", err)
+	}
+
+	// The connection is usable after the timeout. A deadline in the past
+	// interrupts a blocked read.
+	done := make(chan error, 1)
+	go func() {
+		_, err := a.Read(p[:])
+		done <- err
+	}()
+	a.SetReadDeadline(time.Time{})
+	time.Sleep(10 * time.Millisecond)
+	a.SetReadDeadline(time.Now().Add(-time.Second))
+	if err := <-done; !errors.As(err, &ne) || !ne.Timeout() {
+		t.Errorf("Read() returned %v, want timeout", err)
+	}
+	a.SetReadDeadline(time.Time{})
+	go sc.WriteMessage(BinaryMessage, []byte("hello"))
+	if n, err := a.Read(p[:]); err != nil || string(p[:n]) != "hello" {
+		t.Errorf("Read() after timeout = %q, %v, want %q", p[:n], err, "hello")
+	}
+}
+
+func TestNetConnAdapterWriteDeadline(t *testing.T) {
+	sc, cc := newPipeConns()
+	defer sc.Close()
+	a := NewNetConn(cc, nil)
+	defer a.Close()
+
+	// The pipe is synchronous and the server does not read. The write blocks
+	// until the deadline.
+	done := make(chan error, 1)
+	go func() {
+		_, err := a.Write([]byte("hello"))
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if err := a.SetWriteDeadline(time.Now()); err != nil {
+		t.Fatalf("SetWriteDeadline() returned %v", err)
+	}
+	err := <-done
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Errorf("Write() returned %v, want timeout", err)
+	}
+}
+
+func TestNetConnAdapterTLS(t *testing.T) {
+	s := httptest.NewUnstartedServer(nil)
+	s.StartTLS()
+	cert := s.TLS.Certificates[0]
+	pool := s.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	s.Close()
+
+	sc, cc := newPipeConns()
+	server := tls.Server(NewNetConn(sc, nil), &tls.Config{Certificates: []tls.Certificate{cert}})
+	client := tls.Client(NewNetConn(cc, nil), &tls.Config{RootCAs: pool, ServerName: "example.com"})
+
+	message := bytes.Repeat([]byte("hello"), 10000)
+	go func() {
+		client.Write(message)
+		client.Close()
+	}()
+
+	p, err := io.ReadAll(server)
+	if err != nil {
+		t.Fatalf("ReadAll() returned %v", err)
+	}
+	if !bytes.Equal(p, message) {
+		t.Errorf("ReadAll() returned %d bytes, want %d", len(p), len(message))
+	}
+	server.Close()
+}
+
+// oneConnListener is a listener that accepts a single connection.
+type oneConnListener struct {
+	conn net.Conn
+	once sync.Once
+	done chan struct{}
+}
+
+func (l *oneConnListener) Accept() (net.Conn, error) {
+	if c := l.conn; c != nil {
+		l.conn = nil
+		return c, nil
+	}
+	<-l.done
+	return nil, net.ErrClosed
+}
+
+func (l *oneConnListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *oneConnListener) Addr() net.Addr {
+	return &net.TCPAddr{}
+}
+
+func TestNetConnAdapterHTTP(t *testing.T) {
+	sc, cc := newPipeConns()
+	server, client := NewNetConn(sc, nil), NewNetConn(cc, nil)
+	defer server.Close()
+	defer client.Close()
+	l := &oneConnListener{conn: server, done: make(chan struct{})}
+	defer l.Close()
+	go http.Serve(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.Path)
+	}))
+
+	dialed := false
+	hc := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if dialed {
+				return nil, errors.New("connection was not reused")
+			}
+			dialed = true
+			return client, nil
+		},
+	}}
+	defer hc.CloseIdleConnections()
+
+	// The server sets a read deadline in the past to stop its background
+	// read between requests on a keep-alive connection. Wait before each
+	// request so that the background read is blocked when it is stopped.
+	for _, path := range []string{"/a", "/b", "/c"} {
+		time.Sleep(10 * time.Millisecond)
+		resp, err := hc.Get("http://example.com" + path)
+		if err != nil {
+			t.Fatalf("Get(%s) returned %v", path, err)
+		}
+		p, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil || string(p) != path {
+			t.Errorf("Get(%s) body = %q, %v, want %q", path, p, err, path)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"context"
+	"encoding/binary"
+	"time"
+)
+
+// pingWaiter is a call to Ping waiting for the matching pong.
+type pingWaiter struct {
+	sent time.Time
+	done chan error // receives nil when the pong arrives
+	rtt  time.Duration
+}
+
+// Ping sends a ping with a unique payload to the peer and waits for the
+// matching pong. Ping returns the time from sending the ping to receiving the
+// pong.
+//
+// Pongs are received by the read methods. The application must read the
+// connection concurrently with Ping as described in the section on Control
+// Messages in the package documentation. If reading the connection fails,
+// Ping returns the read error.
+//
+// The deadline of ctx, if any, applies to writing the ping. If ctx is done
+// before the pong arrives, Ping returns ctx.Err().
+//
+// Ping updates the smoothed round trip time returned by RTT. Ping can be
+// called concurrently with all other methods. The pong handler is called for
+// the matching pong as for any other pong.
+func (c *Conn) Ping(ctx context.Context) (time.Duration, error) {
+	var data [8]byte
+	w := &pingWaiter{done: make(chan error, 1)}
+
+	c.pingMu.Lock()
+	if c.pingErr != nil {
+		err := c.pingErr
+		c.pingMu.Unlock()
+		return 0, err
+	}
+	c.pingSeq++
+	binary.BigEndian.PutUint64(data[:], c.pingSeq)
+	key := string(data[:])
+	if c.pingWaiters == nil {
+		c.pingWaiters = make(map[string]*pingWaiter)
+	}
+	c.pingWaiters[key] = w
+	w.sent = time.Now()
+	c.pingMu.Unlock()
+
+	deadline, _ := ctx.Deadline()
+	if err := c.WriteControl(PingMessage, data[:], deadline); err != nil {
+		c.removePingWaiter(key)
+		return 0, err
+	}
+
+	select {
+	case err := <-w.done:
+		if err != nil {
+			return 0, err
+		}
+		return w.rtt, nil
+	case <-ctx.Done():
+		c.removePingWaiter(key)
+		return 0, ctx.Err()
+	}
+}
+
+// RTT returns the smoothed round trip time of the pings sent with Ping. The
+// smoothed round trip time is an exponentially weighted moving average of the
+// measurements with a weight of 1/8 for each new measurement, as specified
+// for TCP in RFC 6298. RTT returns zero before the first measurement.
+//
+// Applications that send keepalive pings with Ping maintain the statistic as
+// a side effect.
+func (c *Conn) RTT() time.Duration {
+	c.pingMu.Lock()
+	defer c.pingMu.Unlock()
+	return c.srtt
+}
+
+func (c *Conn) removePingWaiter(key string) {
+	c.pingMu.Lock()
+	delete(c.pingWaiters, key)
+	c.pingMu.Unlock()
+}
+
+// pingReplied completes the call to Ping waiting for a pong with payload
+// data, if any.
+func (c *Conn) pingReplied(data []byte) {
+	c.pingMu.Lock()
+	defer c.pingMu.Unlock()
+	w, ok := c.pingWaiters[string(data)]
+	if !ok {
+		return
+	}
+	delete(c.pingWaiters, string(data))
+	w.rtt = time.Since(w.sent)
+	if c.srtt == 0 {
+		c.srtt = w.rtt
+	} else {
+		c.srtt += (w.rtt - c.srtt) / 8
+	}
+	w.done <- nil
+}
+
+// failPings completes the pending calls to Ping with the read error err.
+func (c *Conn) failPings(err error) {
+	c.pingMu.Lock()
+	defer c.pingMu.Unlock()
+	if c.pingErr != nil {
+		return
+	}
+	c.pingErr = err
+	for key, w := range c.pingWaiters {
+		delete(c.pingWaiters, key)
+		w.done <- err
+	}
+}
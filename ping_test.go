@@ -0,0 +1,90 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPing(t *testing.T) {
+	server, client := newPipeConns()
+	defer server.Close()
+	defer client.Close()
+
+	var pongs []string
+	client.SetPongHandler(func(data string) error {
+		pongs = append(pongs, data)
+		return nil
+	})
+	go func() {
+		for {
+			if _, _, err := server.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	readDone := make(chan error)
+	go func() {
+		_, _, err := client.ReadMessage()
+		readDone <- err
+	}()
+
+	if client.RTT() != 0 {
+		t.Errorf("RTT() = %v before first ping, want 0", client.RTT())
+	}
+	for i := 0; i < 3; i++ {
+		rtt, err := client.Ping(context.Background())
+		if err != nil {
+			t.Fatalf("Ping() returned error %v", err)
+		}
+		if rtt <= 0 {
+			t.Errorf("Ping() = %v, want > 0", rtt)
+		}
+	}
+	if client.RTT() <= 0 {
+		t.Errorf("RTT() = %v, want > 0", client.RTT())
+	}
+
+	// Ping returns the read error when the connection fails.
+	server.Close()
+	if err := <-readDone; err == nil {
+		t.Fatal("ReadMessage() succeeded, want error")
+	}
+	if _, err := client.Ping(context.Background()); err == nil {
+		t.Error("Ping() on failed connection succeeded, want error")
+	}
+	if len(pongs) != 3 || pongs[0] == pongs[1] || pongs[1] == pongs[2] {
+		t.Errorf("pongs = %q, want three unique payloads", pongs)
+	}
+}
+
+func TestPingContext(t *testing.T) {
+	// The peer does not read the connection. Ping fails when the write
+	// deadline from the context expires.
+	server, client := newPipeConns()
+	defer server.Close()
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := client.Ping(ctx); err == nil {
+		t.Fatal("Ping() succeeded, want error")
+	}
+
+	// The peer reads the ping, but the application does not read the pong.
+	server, client = newPipeConns()
+	defer server.Close()
+	defer client.Close()
+	go func() { _, _, _ = server.ReadMessage() }()
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := client.Ping(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Ping() returned %v, want %v", err, context.DeadlineExceeded)
+	}
+	if len(client.pingWaiters) != 0 {
+		t.Errorf("%d ping waiters left after Ping returned", len(client.pingWaiters))
+	}
+}
@@ -9,7 +9,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
-	"errors"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
@@ -28,9 +28,58 @@ func (fn netDialerFunc) DialContext(ctx context.Context, network, addr string) (
 	return fn(ctx, network, addr)
 }
 
-func proxyFromURL(proxyURL *url.URL, forwardDial netDialerFunc) (netDialerFunc, error) {
+// ProxyAuthenticator computes credentials for authenticating with an HTTP
+// proxy. Set Dialer.ProxyAuthenticator to respond to the challenges in a 407
+// (Proxy Authentication Required) response from the proxy.
+//
+// Connection-oriented schemes such as Negotiate can be implemented with this
+// interface: the dialer sends the credentials on the same network connection
+// when the proxy keeps the connection open and calls Authorization again if
+// the proxy responds with another challenge.
+type ProxyAuthenticator interface {
+	// Authorization returns the value of the Proxy-Authorization header for
+	// the CONNECT request req in response to the challenges in the proxy's
+	// response resp. The challenges are in the Proxy-Authenticate header of
+	// resp. If Authorization returns the empty string, the dial fails with a
+	// *ProxyConnectError. If Authorization returns an error, the dial fails
+	// with that error.
+	Authorization(req *http.Request, resp *http.Response) (string, error)
+}
+
+// ProxyConnectError is returned when an HTTP proxy responds to the CONNECT
+// request with a status other than 200.
+type ProxyConnectError struct {
+	// Response is the proxy's response. The response body contains at most
+	// the first 1024 bytes of the body sent by the proxy and does not need to
+	// be closed by the application.
+	Response *http.Response
+}
+
+func (e *ProxyConnectError) Error() string {
+	if f := strings.SplitN(e.Response.Status, " ", 2); len(f) == 2 {
+		return f[1]
+	}
+	return e.Response.Status
+}
+
+// maxProxyAuthRounds is the maximum number of CONNECT requests sent in
+// response to challenges from the proxy.
+const maxProxyAuthRounds = 4
+
+// maxProxyDrain is the maximum number of bytes read from the body of a 407
+// response to reuse the connection.
+const maxProxyDrain = 64 << 10
+
+func proxyFromURL(proxyURL *url.URL, forwardDial netDialerFunc, d *Dialer) (netDialerFunc, error) {
 	if proxyURL.Scheme == "http" || proxyURL.Scheme == "https" {
-		return (&httpProxyDialer{proxyURL: proxyURL, forwardDial: forwardDial}).DialContext, nil
+		hpd := &httpProxyDialer{
+			proxyURL:      proxyURL,
+			forwardDial:   forwardDial,
+			connectHeader: d.ProxyConnectHeader,
+			getHeader:     d.GetProxyConnectHeader,
+			auth:          d.ProxyAuthenticator,
+		}
+		return hpd.DialContext, nil
 	}
 	dialer, err := proxy.FromURL(proxyURL, forwardDial)
 	if err != nil {
@@ -45,60 +94,113 @@ func proxyFromURL(proxyURL *url.URL, forwardDial netDialerFunc) (netDialerFunc,
 }
 
 type httpProxyDialer struct {
-	proxyURL    *url.URL
-	forwardDial netDialerFunc
+	proxyURL      *url.URL
+	forwardDial   netDialerFunc
+	connectHeader http.Header
+	getHeader     func(ctx context.Context, proxyURL *url.URL, target string) (http.Header, error)
+	auth          ProxyAuthenticator
 }
 
 func (hpd *httpProxyDialer) DialContext(ctx context.Context, network string, addr string) (net.Conn, error) {
-	hostPort, _ := hostPortNoPort(hpd.proxyURL)
-	conn, err := hpd.forwardDial(ctx, network, hostPort)
-	if err != nil {
-		return nil, err
+	connectHeader := hpd.connectHeader
+	if hpd.getHeader != nil {
+		var err error
+		connectHeader, err = hpd.getHeader(ctx, hpd.proxyURL, addr)
+		if err != nil {
+			return nil, err
+		}
 	}
-
-	connectHeader := make(http.Header)
-	if user := hpd.proxyURL.User; user != nil {
+	connectHeader = connectHeader.Clone()
+	if connectHeader == nil {
+		connectHeader = make(http.Header)
+	}
+	if user := hpd.proxyURL.User; user != nil && connectHeader.Get("Proxy-Authorization") == "" {
 		proxyUser := user.Username()
 		if proxyPassword, passwordSet := user.Password(); passwordSet {
 			credential := base64.StdEncoding.EncodeToString([]byte(proxyUser + ":" + proxyPassword))
 			connectHeader.Set("Proxy-Authorization", "Basic "+credential)
 		}
 	}
-	connectReq := &http.Request{
-		Method: http.MethodConnect,
-		URL:    &url.URL{Opaque: addr},
-		Host:   addr,
-		Header: connectHeader,
-	}
 
-	if err := connectReq.Write(conn); err != nil {
-		conn.Close()
-		return nil, err
-	}
+	hostPort, _ := hostPortNoPort(hpd.proxyURL)
+	var conn net.Conn
+	for round := 0; ; round++ {
+		if conn == nil {
+			var err error
+			conn, err = hpd.forwardDial(ctx, network, hostPort)
+			if err != nil {
+				return nil, err
+			}
+		}
 
-	// Read response. It's OK to use and discard buffered reader here because
-	// the remote server does not speak until spoken to.
-	br := bufio.NewReader(conn)
-	resp, err := http.ReadResponse(br, connectReq)
-	if err != nil {
-		conn.Close()
-		return nil, err
-	}
+		connectReq := &http.Request{
+			Method: http.MethodConnect,
+			URL:    &url.URL{Opaque: addr},
+			Host:   addr,
+			Header: connectHeader,
+		}
+		connectReq = connectReq.WithContext(ctx)
+
+		if err := connectReq.Write(conn); err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		// Read response. It's OK to use and discard buffered reader here because
+		// the remote server does not speak until spoken to.
+		br := bufio.NewReader(conn)
+		resp, err := http.ReadResponse(br, connectReq)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusOK {
+			// Close the response body to silence false positives from linters. Reset
+			// the buffered reader first to ensure that Close() does not read from
+			// conn.
+			// Note: Applications must call resp.Body.Close() on a response returned
+			// http.ReadResponse to inspect trailers or read another response from the
+			// buffered reader. The call to resp.Body.Close() does not release
+			// resources.
+			br.Reset(bytes.NewReader(nil))
+			_ = resp.Body.Close()
+			return conn, nil
+		}
+
+		// Slurp up some of the response to aid application debugging.
+		body := resp.Body
+		buf := make([]byte, 1024)
+		n, _ := io.ReadFull(body, buf)
+		resp.Body = io.NopCloser(bytes.NewReader(buf[:n]))
+
+		if resp.StatusCode == http.StatusProxyAuthRequired && hpd.auth != nil && round < maxProxyAuthRounds {
+			// Drain the remainder of the body to reuse the connection for
+			// the next request. Use a new connection if the body is large or
+			// the proxy closes the connection.
+			reuse := false
+			if !resp.Close {
+				m, err := io.Copy(io.Discard, io.LimitReader(body, maxProxyDrain))
+				reuse = err == nil && m < maxProxyDrain && br.Buffered() == 0
+			}
+
+			value, err := hpd.auth.Authorization(connectReq, resp)
+			if err != nil {
+				conn.Close()
+				return nil, err
+			}
+			if value != "" {
+				if !reuse {
+					conn.Close()
+					conn = nil
+				}
+				connectHeader = connectHeader.Clone()
+				connectHeader.Set("Proxy-Authorization", value)
+				continue
+			}
+		}
 
-	// Close the response body to silence false positives from linters. Reset
-	// the buffered reader first to ensure that Close() does not read from
-	// conn.
-	// Note: Applications must call resp.Body.Close() on a response returned
-	// http.ReadResponse to inspect trailers or read another response from the
-	// buffered reader. The call to resp.Body.Close() does not release
-	// resources.
-	br.Reset(bytes.NewReader(nil))
-	_ = resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
 		_ = conn.Close()
-		f := strings.SplitN(resp.Status, " ", 2)
-		return nil, errors.New(f[1])
+		return nil, &ProxyConnectError{Response: resp}
 	}
-	return conn, nil
 }
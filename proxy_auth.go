@@ -0,0 +1,214 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// DigestProxyAuth is a ProxyAuthenticator for the HTTP Digest authentication
+// scheme defined in RFC 7616. The MD5, MD5-sess, SHA-256 and SHA-256-sess
+// algorithms and the "auth" quality of protection are supported.
+type DigestProxyAuth struct {
+	Username string
+	Password string
+
+	nc uint32 // nonce count, accessed atomically
+}
+
+// Authorization implements the ProxyAuthenticator interface.
+func (a *DigestProxyAuth) Authorization(req *http.Request, resp *http.Response) (string, error) {
+	for _, c := range parseAuthChallenges(resp.Header["Proxy-Authenticate"]) {
+		if !equalASCIIFold(c.scheme, "Digest") {
+			continue
+		}
+		if v, ok := a.respond(req, c.params); ok {
+			return v, nil
+		}
+	}
+	return "", nil
+}
+
+func (a *DigestProxyAuth) respond(req *http.Request, params map[string]string) (string, bool) {
+	algorithm := params["algorithm"]
+	if algorithm == "" {
+		algorithm = "MD5"
+	}
+	var newHash func() hash.Hash
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return "", false
+	}
+	h := func(s string) string {
+		d := newHash()
+		d.Write([]byte(s))
+		return hex.EncodeToString(d.Sum(nil))
+	}
+
+	realm := params["realm"]
+	nonce := params["nonce"]
+	uri := req.URL.RequestURI()
+	if req.Method == http.MethodConnect {
+		uri = req.Host
+	}
+
+	qop := ""
+	if v, ok := params["qop"]; ok {
+		for _, q := range strings.Split(v, ",") {
+			if strings.TrimSpace(q) == "auth" {
+				qop = "auth"
+			}
+		}
+		if qop == "" {
+			// Only "auth" is supported.
+			return "", false
+		}
+	}
+
+	var cnonceBytes [16]byte
+	if _, err := rand.Read(cnonceBytes[:]); err != nil {
+		return "", false
+	}
+	cnonce := hex.EncodeToString(cnonceBytes[:])
+	nc := strconv.FormatUint(uint64(atomic.AddUint32(&a.nc, 1)), 16)
+	nc = strings.Repeat("0", 8-len(nc)) + nc
+
+	ha1 := h(a.Username + ":" + realm + ":" + a.Password)
+	if strings.HasSuffix(strings.ToUpper(algorithm), "-SESS") {
+		ha1 = h(ha1 + ":" + nonce + ":" + cnonce)
+	}
+	ha2 := h(req.Method + ":" + uri)
+	var response string
+	if qop == "" {
+		response = h(ha1 + ":" + nonce + ":" + ha2)
+	} else {
+		response = h(ha1 + ":" + nonce + ":" + nc + ":" + cnonce + ":" + qop + ":" + ha2)
+	}
+
+	var b strings.Builder
+	b.WriteString("Digest username=")
+	b.WriteString(strconv.Quote(a.Username))
+	b.WriteString(", realm=")
+	b.WriteString(strconv.Quote(realm))
+	b.WriteString(", nonce=")
+	b.WriteString(strconv.Quote(nonce))
+	b.WriteString(", uri=")
+	b.WriteString(strconv.Quote(uri))
+	b.WriteString(", algorithm=")
+	b.WriteString(algorithm)
+	b.WriteString(", response=")
+	b.WriteString(strconv.Quote(response))
+	if opaque, ok := params["opaque"]; ok {
+		b.WriteString(", opaque=")
+		b.WriteString(strconv.Quote(opaque))
+	}
+	if qop != "" {
+		b.WriteString(", qop=auth, nc=")
+		b.WriteString(nc)
+		b.WriteString(", cnonce=")
+		b.WriteString(strconv.Quote(cnonce))
+	}
+	return b.String(), true
+}
+
+// authChallenge is a challenge from a WWW-Authenticate or Proxy-Authenticate
+// header.
+type authChallenge struct {
+	scheme  string
+	token68 string
+	params  map[string]string
+}
+
+// parseAuthChallenges parses the challenges in the values of an
+// authentication header as defined in RFC 7235, section 4.1.
+func parseAuthChallenges(values []string) []authChallenge {
+	//  challenge   = auth-scheme [ 1*SP ( token68 / #auth-param ) ]
+	//  auth-param  = token BWS "=" BWS ( token / quoted-string )
+	//  token68     = 1*( ALPHA / DIGIT / "-" / "." / "_" / "~" / "+" / "/" ) *"="
+
+	var result []authChallenge
+	for _, s := range values {
+		var c *authChallenge
+		for {
+			s = skipSpace(s)
+			if strings.HasPrefix(s, ",") {
+				s = s[1:]
+				continue
+			}
+			if s == "" {
+				break
+			}
+			var t string
+			t, rest := nextToken(s)
+			if t == "" {
+				// Skip malformed input.
+				break
+			}
+			rest = skipSpace(rest)
+			if c != nil && strings.HasPrefix(rest, "=") && !isToken68Padding(rest) {
+				// Parameter for the current challenge.
+				var v string
+				v, rest = nextTokenOrQuoted(skipSpace(rest[1:]))
+				c.params[strings.ToLower(t)] = v
+				s = rest
+				continue
+			}
+
+			// Start of a new challenge.
+			result = append(result, authChallenge{scheme: t, params: make(map[string]string)})
+			c = &result[len(result)-1]
+			if tok, after, ok := nextToken68(rest); ok {
+				c.token68 = tok
+				rest = after
+			}
+			s = rest
+		}
+	}
+	return result
+}
+
+// isToken68Padding returns true if s starts with the padding at the end of a
+// token68 value.
+func isToken68Padding(s string) bool {
+	s = strings.TrimLeft(s, "=")
+	s = skipSpace(s)
+	return s == "" || s[0] == ','
+}
+
+// nextToken68 returns the leading token68 value of s if s starts with a
+// token68 value followed by the end of s or a comma.
+func nextToken68(s string) (token, rest string, ok bool) {
+	i := 0
+	for ; i < len(s); i++ {
+		b := s[i]
+		if !('a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9' ||
+			b == '-' || b == '.' || b == '_' || b == '~' || b == '+' || b == '/') {
+			break
+		}
+	}
+	if i == 0 {
+		return "", s, false
+	}
+	for i < len(s) && s[i] == '=' {
+		i++
+	}
+	rest = skipSpace(s[i:])
+	if rest != "" && rest[0] != ',' {
+		return "", s, false
+	}
+	return s[:i], rest, true
+}
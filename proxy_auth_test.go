@@ -0,0 +1,79 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var parseAuthChallengesTests = []struct {
+	values []string
+	want   []authChallenge
+}{
+	{[]string{`Basic realm="proxy"`}, []authChallenge{{scheme: "Basic", params: map[string]string{"realm": "proxy"}}}},
+	{[]string{`Negotiate`}, []authChallenge{{scheme: "Negotiate", params: map[string]string{}}}},
+	{[]string{`Negotiate YII=`}, []authChallenge{{scheme: "Negotiate", token68: "YII=", params: map[string]string{}}}},
+	{[]string{`Basic realm="a", Digest Realm="b" , nonce=n,qop="auth,auth-int"`}, []authChallenge{
+		{scheme: "Basic", params: map[string]string{"realm": "a"}},
+		{scheme: "Digest", params: map[string]string{"realm": "b", "nonce": "n", "qop": "auth,auth-int"}},
+	}},
+	{[]string{`Negotiate abc, Basic realm="x\"y"`}, []authChallenge{
+		{scheme: "Negotiate", token68: "abc", params: map[string]string{}},
+		{scheme: "Basic", params: map[string]string{"realm": `x"y`}},
+	}},
+	{[]string{`, ,`}, nil},
+	{[]string{`Digest realm="unterminated`}, []authChallenge{{scheme: "Digest", params: map[string]string{"realm": ""}}}},
+}
+
+func TestParseAuthChallenges(t *testing.T) {
+	for _, tt := range parseAuthChallengesTests {
+		got := parseAuthChallenges(tt.values)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseAuthChallenges(%q) = %+v, want %+v", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestDigestProxyAuth(t *testing.T) {
+	req := &http.Request{Method: http.MethodConnect, URL: &url.URL{Opaque: "example.com:443"}, Host: "example.com:443"}
+
+	tests := []struct {
+		challenge string
+		want      []string
+	}{
+		{`Digest realm="r", nonce="n"`, []string{`username="user"`, `realm="r"`, `nonce="n"`, `uri="example.com:443"`, `algorithm=MD5`}},
+		{`Digest realm="r", nonce="n", qop="auth", algorithm=MD5-sess, opaque="o"`, []string{`qop=auth`, `nc=00000001`, `cnonce="`, `opaque="o"`, `algorithm=MD5-sess`}},
+		{`Digest realm="r", nonce="n", algorithm=SHA-512`, nil},
+		{`Digest realm="r", nonce="n", qop="auth-int"`, nil},
+		{`Basic realm="r"`, nil},
+	}
+	for _, tt := range tests {
+		a := &DigestProxyAuth{Username: "user", Password: "pass"}
+		resp := &http.Response{Header: http.Header{"Proxy-Authenticate": {tt.challenge}}}
+		got, err := a.Authorization(req, resp)
+		if err != nil {
+			t.Errorf("Authorization(%q) returned error %v", tt.challenge, err)
+			continue
+		}
+		if tt.want == nil {
+			if got != "" {
+				t.Errorf("Authorization(%q) = %q, want empty string", tt.challenge, got)
+			}
+			continue
+		}
+		if !strings.HasPrefix(got, "Digest ") {
+			t.Errorf("Authorization(%q) = %q, want Digest credentials", tt.challenge, got)
+		}
+		for _, w := range tt.want {
+			if !strings.Contains(got, w) {
+				t.Errorf("Authorization(%q) = %q, want %s", tt.challenge, got, w)
+			}
+		}
+	}
+}
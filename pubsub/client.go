@@ -0,0 +1,175 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/correlate"
+)
+
+// ErrClosed is returned from Client methods after the client is closed or
+// the connection fails.
+var ErrClosed = correlate.ErrClosed
+
+// ClientOptions specifies options for a Client.
+type ClientOptions struct {
+	// OnPresence is called with presence notifications for the client's
+	// subscriptions. The server sends presence notifications only if
+	// enabled in the server options.
+	OnPresence func(p Presence)
+
+	// OnError is called with errors reported by the server for frames
+	// without an ID and with frames that cannot be decoded.
+	OnError func(err error)
+}
+
+// Client is a pub/sub client.
+//
+// Handlers and callbacks are called from the goroutine executing Run. They
+// must not call Subscribe, Unsubscribe or Publish directly because these
+// methods wait for a reply read by Run.
+//
+// Subscribe, Unsubscribe, Publish and Close can be called concurrently.
+type Client struct {
+	c    *correlate.Correlator[frame]
+	opts ClientOptions
+
+	mu   sync.Mutex
+	subs map[string]func(*Message)
+}
+
+// NewClient returns a client for conn. Dial conn with the Subprotocol in the
+// Dialer Subprotocols field. The client reads messages from conn in Run and
+// writes messages to conn in its other methods. The application must not
+// otherwise read from or write to conn.
+func NewClient(conn *websocket.Conn, opts *ClientOptions) *Client {
+	c := &Client{subs: make(map[string]func(*Message))}
+	if opts != nil {
+		c.opts = *opts
+	}
+	c.c = correlate.New(conn, &correlate.Options[frame]{
+		Codec: websocket.JSONCodec,
+		SetID: func(f *frame, id string) { f.ID = id },
+		ResponseID: func(f *frame) (string, bool) {
+			return f.ID, f.ID != "" && (f.Type == typeAck || f.Type == typeError)
+		},
+		Handler: c.handle,
+		OnDecodeError: func(err *websocket.DecodeError) {
+			if c.opts.OnError != nil {
+				c.opts.OnError(err)
+			}
+		},
+	})
+	return c
+}
+
+// Conn returns the WebSocket connection used by c.
+func (c *Client) Conn() *websocket.Conn {
+	return c.c.Conn()
+}
+
+// Run reads messages from the connection and calls the handlers until the
+// connection fails, ctx is done or Close is called. See correlate.Correlator
+// Run for the return values.
+func (c *Client) Run(ctx context.Context) error {
+	return c.c.Run(ctx)
+}
+
+func (c *Client) handle(f frame) {
+	switch f.Type {
+	case typeMessage:
+		m := &Message{Topic: f.Topic, From: f.From, Data: f.Data}
+		c.mu.Lock()
+		var handlers []func(*Message)
+		for pattern, h := range c.subs {
+			if Match(pattern, f.Topic) {
+				handlers = append(handlers, h)
+			}
+		}
+		c.mu.Unlock()
+		for _, h := range handlers {
+			h(m)
+		}
+	case typePresence:
+		if c.opts.OnPresence != nil {
+			c.opts.OnPresence(Presence{Topic: f.Topic, Client: f.Client, Joined: f.Event == eventJoin})
+		}
+	case typeError:
+		if c.opts.OnError != nil {
+			c.opts.OnError(&Error{Message: f.Error})
+		}
+	}
+}
+
+// call sends f and waits for the reply. It returns an *Error if the server
+// rejects f.
+func (c *Client) call(ctx context.Context, f frame) error {
+	r, err := c.c.Call(ctx, f)
+	if err != nil {
+		return err
+	}
+	if r.Type == typeError {
+		return &Error{Message: r.Error}
+	}
+	return nil
+}
+
+// Subscribe subscribes to topic and waits for the server to acknowledge the
+// subscription. The topic may contain wildcards. The handler is called with
+// each message published to a matching topic. If more than one subscription
+// matches a message, the handler of each subscription is called. Subscribe
+// returns an error if the client is already subscribed to topic.
+func (c *Client) Subscribe(ctx context.Context, topic string, handler func(m *Message)) error {
+	if !validTopic(topic, true) {
+		return ErrInvalidTopic
+	}
+	c.mu.Lock()
+	if _, ok := c.subs[topic]; ok {
+		c.mu.Unlock()
+		return errors.New("pubsub: already subscribed to " + topic)
+	}
+	c.subs[topic] = handler
+	c.mu.Unlock()
+
+	if err := c.call(ctx, frame{Type: typeSubscribe, Topic: topic}); err != nil {
+		c.mu.Lock()
+		delete(c.subs, topic)
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Unsubscribe removes the subscription to topic and waits for the server to
+// acknowledge the request.
+func (c *Client) Unsubscribe(ctx context.Context, topic string) error {
+	c.mu.Lock()
+	delete(c.subs, topic)
+	c.mu.Unlock()
+	return c.call(ctx, frame{Type: typeUnsubscribe, Topic: topic})
+}
+
+// Publish sends the JSON encoding of v to topic and waits for the server to
+// acknowledge the message. The topic must not contain wildcards.
+func (c *Client) Publish(ctx context.Context, topic string, v interface{}) error {
+	if !validTopic(topic, false) {
+		return ErrInvalidTopic
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.call(ctx, frame{Type: typePublish, Topic: topic, Data: data})
+}
+
+// Close sends a close message to the server and closes the connection.
+func (c *Client) Close() error {
+	return c.c.Close()
+}
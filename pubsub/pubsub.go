@@ -0,0 +1,154 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pubsub implements a lightweight topic-based publish/subscribe
+// protocol over WebSocket connections.
+//
+// A Server accepts connections and routes published messages to the
+// connections subscribed to matching topics. A Client subscribes to topics,
+// receives messages and publishes messages.
+//
+// # Protocol
+//
+// The subprotocol name is "pubsub.v1". Each frame is a JSON object sent as
+// one WebSocket text message. The client sends frames of these types:
+//
+//	{"type": "subscribe", "id": "1", "topic": "chat.*"}
+//	{"type": "unsubscribe", "id": "2", "topic": "chat.*"}
+//	{"type": "publish", "id": "3", "topic": "chat.general", "data": {"text": "hi"}}
+//
+// The server replies to a frame with an id with an ack frame or an error
+// frame carrying the same id. Frames without an id are not acknowledged.
+//
+//	{"type": "ack", "id": "3"}
+//	{"type": "error", "id": "3", "error": "not authorized"}
+//
+// The server sends published messages and presence notifications with these
+// frames:
+//
+//	{"type": "message", "topic": "chat.general", "from": "alice", "data": {"text": "hi"}}
+//	{"type": "presence", "topic": "chat.*", "client": "bob", "event": "join"}
+//
+// # Topics
+//
+// Topics are sequences of non-empty segments separated by dots. A
+// subscription topic may contain wildcard segments: "*" matches exactly one
+// segment and ">" as the last segment matches one or more segments. The
+// subscription "chat.*" matches "chat.general" but not "chat.general.bots";
+// the subscription "chat.>" matches both.
+package pubsub
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Subprotocol is the WebSocket subprotocol name of the protocol.
+const Subprotocol = "pubsub.v1"
+
+// ErrInvalidTopic is returned when publishing to a topic or subscribing to a
+// topic that is not well formed.
+var ErrInvalidTopic = errors.New("pubsub: invalid topic")
+
+// Frame types.
+const (
+	typeSubscribe   = "subscribe"
+	typeUnsubscribe = "unsubscribe"
+	typePublish     = "publish"
+	typeAck         = "ack"
+	typeError       = "error"
+	typeMessage     = "message"
+	typePresence    = "presence"
+)
+
+// Presence events.
+const (
+	eventJoin  = "join"
+	eventLeave = "leave"
+)
+
+type frame struct {
+	Type   string          `json:"type"`
+	ID     string          `json:"id,omitempty"`
+	Topic  string          `json:"topic,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+	From   string          `json:"from,omitempty"`
+	Client string          `json:"client,omitempty"`
+	Event  string          `json:"event,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// Message is a message published to a topic.
+type Message struct {
+	// Topic is the topic the message was published to.
+	Topic string
+
+	// From is the ID of the publishing client or empty if the message was
+	// published by the server.
+	From string
+
+	// Data is the JSON encoded message data.
+	Data json.RawMessage
+}
+
+// Presence is a notification that a client subscribed to or unsubscribed
+// from a topic.
+type Presence struct {
+	// Topic is the subscription topic, including wildcards.
+	Topic string
+
+	// Client is the ID of the client.
+	Client string
+
+	// Joined is true if the client subscribed and false if the client
+	// unsubscribed or disconnected.
+	Joined bool
+}
+
+// Error is an error reported by the server in reply to a frame.
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return "pubsub: " + e.Message
+}
+
+// Match reports whether topic matches the subscription pattern.
+func Match(pattern, topic string) bool {
+	for {
+		p, prest, pmore := strings.Cut(pattern, ".")
+		t, trest, tmore := strings.Cut(topic, ".")
+		switch {
+		case p == ">":
+			return t != ""
+		case p != "*" && p != t:
+			return false
+		}
+		if !pmore || !tmore {
+			return pmore == tmore
+		}
+		pattern, topic = prest, trest
+	}
+}
+
+// validTopic reports whether topic is well formed. Wildcard segments are
+// allowed if wildcards is true.
+func validTopic(topic string, wildcards bool) bool {
+	segments := strings.Split(topic, ".")
+	for i, s := range segments {
+		switch {
+		case s == "":
+			return false
+		case s == "*" || s == ">":
+			if !wildcards || s == ">" && i != len(segments)-1 {
+				return false
+			}
+		case strings.ContainsAny(s, "*>"):
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pubsub
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, topic string
+		want           bool
+	}{
+		{"a.b", "a.b", true},
+		{"a.b", "a.c", false},
+		{"a.b", "a.b.c", false},
+		{"a.b.c", "a.b", false},
+		{"a.*", "a.b", true},
+		{"a.*", "a.b.c", false},
+		{"*.b", "a.b", true},
+		{"a.>", "a.b", true},
+		{"a.>", "a.b.c", true},
+		{"a.>", "a", false},
+		{">", "a", true},
+		{"*", "a.b", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.topic); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestValidTopic(t *testing.T) {
+	tests := []struct {
+		topic     string
+		wildcards bool
+		want      bool
+	}{
+		{"a.b", false, true},
+		{"", false, false},
+		{"a..b", false, false},
+		{"a.", false, false},
+		{"a.*", false, false},
+		{"a.*", true, true},
+		{"a.>", true, true},
+		{"a.>.b", true, false},
+		{"a.b*", true, false},
+	}
+	for _, tt := range tests {
+		if got := validTopic(tt.topic, tt.wildcards); got != tt.want {
+			t.Errorf("validTopic(%q, %v) = %v, want %v", tt.topic, tt.wildcards, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/hub"
+	"github.com/gorilla/websocket/internal/lifecycle"
+)
+
+// ServerOptions specifies options for a Server.
+type ServerOptions struct {
+	// Upgrader upgrades HTTP requests in ServeHTTP. If nil, an upgrader
+	// with the Subprotocol is used.
+	Upgrader *websocket.Upgrader
+
+	// Hub specifies the queue options for the connections. If nil, the
+	// default hub options are used.
+	Hub *hub.Options
+
+	// Identify returns the client ID for the request in ServeHTTP. If
+	// Identify returns an error, ServeHTTP responds with status 401 and does
+	// not upgrade the connection. If Identify is nil, the client ID is the
+	// remote address of the request.
+	Identify func(r *http.Request) (string, error)
+
+	// AuthorizeSubscribe is called before a session subscribes to topic. If
+	// the function returns an error, the subscription is rejected and the
+	// error text is sent to the client. If nil, all subscriptions are
+	// allowed.
+	AuthorizeSubscribe func(s *Session, topic string) error
+
+	// AuthorizePublish is called before a message from a session is
+	// published to topic. If the function returns an error, the message is
+	// rejected and the error text is sent to the client. If nil, all
+	// messages are allowed.
+	AuthorizePublish func(s *Session, topic string) error
+
+	// Presence specifies whether the server notifies the sessions
+	// subscribed to a topic when another session subscribes to or
+	// unsubscribes from the same topic.
+	Presence bool
+}
+
+// Server routes messages between the connected sessions.
+//
+// It is safe to call Server methods concurrently.
+type Server struct {
+	opts     ServerOptions
+	upgrader *websocket.Upgrader
+	hub      *hub.Hub
+
+	mu       sync.RWMutex
+	sessions map[*Session]struct{}
+}
+
+// Session is a client connection to a server.
+type Session struct {
+	id  string
+	sub *hub.Subscriber
+
+	// The following field is protected by the server mu.
+	topics map[string]struct{}
+}
+
+// ID returns the client ID of the session.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Conn returns the connection of the session.
+func (s *Session) Conn() *websocket.Conn {
+	return s.sub.Conn()
+}
+
+// NewServer returns a new server. If opts is nil, default options are used.
+func NewServer(opts *ServerOptions) *Server {
+	srv := &Server{sessions: make(map[*Session]struct{})}
+	if opts != nil {
+		srv.opts = *opts
+	}
+	srv.upgrader = srv.opts.Upgrader
+	if srv.upgrader == nil {
+		srv.upgrader = &websocket.Upgrader{Subprotocols: []string{Subprotocol}}
+	}
+	srv.hub = hub.New(srv.opts.Hub)
+	return srv
+}
+
+// ServeHTTP identifies the client, upgrades the request to the WebSocket
+// protocol and serves the connection until it is closed.
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := r.RemoteAddr
+	if srv.opts.Identify != nil {
+		var err error
+		id, err = srv.opts.Identify(r)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+	}
+	conn, err := srv.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	_ = srv.ServeConn(r.Context(), conn, id)
+}
+
+// ServeConn serves the connection for the client with the given ID until
+// the connection fails, ctx is done or the server is shut down. The server
+// writes to conn; the application must not read from or write to conn.
+//
+// ServeConn returns nil if the connection was closed normally, ctx.Err() if
+// ctx is done, hub.ErrClosed if the server is shut down before the connection
+// is served and the read error otherwise.
+func (srv *Server) ServeConn(ctx context.Context, conn *websocket.Conn, id string) error {
+	sub, err := srv.hub.Subscribe(conn)
+	if err != nil {
+		// The server is shut down.
+		_ = lifecycle.Close(conn, websocket.CloseGoingAway, "")
+		return err
+	}
+	s := &Session{id: id, sub: sub, topics: make(map[string]struct{})}
+	srv.mu.Lock()
+	srv.sessions[s] = struct{}{}
+	srv.mu.Unlock()
+
+	stop := lifecycle.Watch(ctx, sub.Close)
+	err = srv.readLoop(s)
+	stop()
+	srv.remove(s)
+	sub.Close()
+	return lifecycle.RunError(ctx, false, err)
+}
+
+func (srv *Server) readLoop(s *Session) error {
+	conn := s.Conn()
+	for {
+		_, r, err := conn.NextReader()
+		if err != nil {
+			return err
+		}
+		var f frame
+		if err := json.NewDecoder(r).Decode(&f); err != nil {
+			srv.send(s, &frame{Type: typeError, Error: "invalid frame"})
+			continue
+		}
+		if err := srv.handle(s, &f); err != nil {
+			srv.send(s, &frame{Type: typeError, ID: f.ID, Error: err.Error()})
+		} else if f.ID != "" {
+			srv.send(s, &frame{Type: typeAck, ID: f.ID})
+		}
+	}
+}
+
+type protocolError string
+
+func (e protocolError) Error() string { return string(e) }
+
+const (
+	errInvalidTopic      = protocolError("invalid topic")
+	errUnknownFrame      = protocolError("unknown frame type")
+	errNotSubscribed     = protocolError("not subscribed")
+	errAlreadySubscribed = protocolError("already subscribed")
+)
+
+func (srv *Server) handle(s *Session, f *frame) error {
+	switch f.Type {
+	case typeSubscribe:
+		if !validTopic(f.Topic, true) {
+			return errInvalidTopic
+		}
+		if srv.opts.AuthorizeSubscribe != nil {
+			if err := srv.opts.AuthorizeSubscribe(s, f.Topic); err != nil {
+				return err
+			}
+		}
+		srv.mu.Lock()
+		if _, ok := s.topics[f.Topic]; ok {
+			srv.mu.Unlock()
+			return errAlreadySubscribed
+		}
+		s.topics[f.Topic] = struct{}{}
+		srv.mu.Unlock()
+		srv.presence(s, f.Topic, eventJoin)
+	case typeUnsubscribe:
+		srv.mu.Lock()
+		if _, ok := s.topics[f.Topic]; !ok {
+			srv.mu.Unlock()
+			return errNotSubscribed
+		}
+		delete(s.topics, f.Topic)
+		srv.mu.Unlock()
+		srv.presence(s, f.Topic, eventLeave)
+	case typePublish:
+		if !validTopic(f.Topic, false) {
+			return errInvalidTopic
+		}
+		if srv.opts.AuthorizePublish != nil {
+			if err := srv.opts.AuthorizePublish(s, f.Topic); err != nil {
+				return err
+			}
+		}
+		return srv.publish(f.Topic, s.id, f.Data)
+	default:
+		return errUnknownFrame
+	}
+	return nil
+}
+
+// remove removes s from the server and notifies the other sessions that s
+// left its topics.
+func (srv *Server) remove(s *Session) {
+	srv.mu.Lock()
+	delete(srv.sessions, s)
+	topics := make([]string, 0, len(s.topics))
+	for topic := range s.topics {
+		topics = append(topics, topic)
+	}
+	s.topics = make(map[string]struct{})
+	srv.mu.Unlock()
+
+	for _, topic := range topics {
+		srv.presence(s, topic, eventLeave)
+	}
+}
+
+// presence notifies the sessions other than s subscribed to topic of the
+// event.
+func (srv *Server) presence(s *Session, topic, event string) {
+	if !srv.opts.Presence {
+		return
+	}
+	pm, err := preparedFrame(&frame{Type: typePresence, Topic: topic, Client: s.id, Event: event})
+	if err != nil {
+		return
+	}
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+	for other := range srv.sessions {
+		if _, ok := other.topics[topic]; ok && other != s {
+			other.sub.SendPrepared(pm)
+		}
+	}
+}
+
+func (srv *Server) send(s *Session, f *frame) {
+	if p, err := json.Marshal(f); err == nil {
+		_ = s.sub.Send(websocket.TextMessage, p)
+	}
+}
+
+func preparedFrame(f *frame) (*websocket.PreparedMessage, error) {
+	p, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	return websocket.NewPreparedMessage(websocket.TextMessage, p)
+}
+
+// Publish sends a message with the JSON encoding of v to the sessions
+// subscribed to topics matching topic. The topic must not contain
+// wildcards.
+func (srv *Server) Publish(topic string, v interface{}) error {
+	if !validTopic(topic, false) {
+		return ErrInvalidTopic
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return srv.publish(topic, "", data)
+}
+
+func (srv *Server) publish(topic, from string, data json.RawMessage) error {
+	pm, err := preparedFrame(&frame{Type: typeMessage, Topic: topic, From: from, Data: data})
+	if err != nil {
+		return err
+	}
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+	for s := range srv.sessions {
+		for pattern := range s.topics {
+			if Match(pattern, topic) {
+				s.sub.SendPrepared(pm)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// Members returns the sorted IDs of the sessions subscribed to topic. The
+// topic is compared literally; wildcards are not expanded.
+func (srv *Server) Members(topic string) []string {
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+	var ids []string
+	for s := range srv.sessions {
+		if _, ok := s.topics[topic]; ok {
+			ids = append(ids, s.id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Shutdown closes all sessions after writing their queued messages. See
+// hub.Hub.Shutdown for details.
+func (srv *Server) Shutdown(ctx context.Context) error {
+	return srv.hub.Shutdown(ctx)
+}
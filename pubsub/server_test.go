@@ -0,0 +1,213 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/wstest"
+)
+
+// dial connects a new client to s as the client with the given ID and runs
+// the client.
+func dial(t *testing.T, s *wstest.Server, id string, opts *ClientOptions) (*Client, <-chan error) {
+	t.Helper()
+	d := s.Dialer(&websocket.Dialer{Subprotocols: []string{Subprotocol}})
+	conn, _, err := d.Dial(wstest.DefaultURL, http.Header{"X-Client": {id}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn.Subprotocol() != Subprotocol {
+		t.Errorf("Subprotocol() = %q, want %q", conn.Subprotocol(), Subprotocol)
+	}
+	c := NewClient(conn, opts)
+	errc := make(chan error, 1)
+	go func() { errc <- c.Run(context.Background()) }()
+	t.Cleanup(func() { c.Close() })
+	return c, errc
+}
+
+func newServer(t *testing.T, opts *ServerOptions) (*Server, *wstest.Server) {
+	t.Helper()
+	opts.Identify = func(r *http.Request) (string, error) {
+		id := r.Header.Get("X-Client")
+		if id == "" {
+			return "", errors.New("no client ID")
+		}
+		return id, nil
+	}
+	srv := NewServer(opts)
+	s := wstest.NewServer(srv)
+	t.Cleanup(func() {
+		srv.Shutdown(context.Background())
+		s.Close()
+	})
+	return srv, s
+}
+
+func receive(t *testing.T, ch <-chan *Message) *Message {
+	t.Helper()
+	select {
+	case m := <-ch:
+		return m
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for message")
+		return nil
+	}
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	srv, s := newServer(t, &ServerOptions{})
+	ctx := context.Background()
+	alice, _ := dial(t, s, "alice", nil)
+	bob, _ := dial(t, s, "bob", nil)
+
+	exact := make(chan *Message, 10)
+	wildcard := make(chan *Message, 10)
+	if err := bob.Subscribe(ctx, "chat.general", func(m *Message) { exact <- m }); err != nil {
+		t.Fatal(err)
+	}
+	if err := bob.Subscribe(ctx, "chat.>", func(m *Message) { wildcard <- m }); err != nil {
+		t.Fatal(err)
+	}
+	if err := bob.Subscribe(ctx, "chat.>", nil); err == nil {
+		t.Error("duplicate Subscribe() returned nil error")
+	}
+
+	if err := alice.Publish(ctx, "chat.general", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	for _, ch := range []chan *Message{exact, wildcard} {
+		m := receive(t, ch)
+		if m.Topic != "chat.general" || m.From != "alice" || string(m.Data) != `"hello"` {
+			t.Errorf("message = %+v", m)
+		}
+	}
+
+	if err := srv.Publish("chat.rooms.go", 42); err != nil {
+		t.Fatal(err)
+	}
+	if m := receive(t, wildcard); m.Topic != "chat.rooms.go" || m.From != "" || string(m.Data) != "42" {
+		t.Errorf("server message = %+v", m)
+	}
+
+	if err := bob.Unsubscribe(ctx, "chat.>"); err != nil {
+		t.Fatal(err)
+	}
+	if err := alice.Publish(ctx, "chat.general", "again"); err != nil {
+		t.Fatal(err)
+	}
+	if m := receive(t, exact); string(m.Data) != `"again"` {
+		t.Errorf("message = %s, want again", m.Data)
+	}
+	select {
+	case m := <-wildcard:
+		t.Errorf("received %+v after Unsubscribe", m)
+	default:
+	}
+
+	if err := alice.Publish(ctx, "chat.*", "x"); err != ErrInvalidTopic {
+		t.Errorf("Publish(chat.*) returned %v, want %v", err, ErrInvalidTopic)
+	}
+	if err := srv.Publish("chat..x", "x"); err != ErrInvalidTopic {
+		t.Errorf("server Publish(chat..x) returned %v, want %v", err, ErrInvalidTopic)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	_, s := newServer(t, &ServerOptions{
+		AuthorizeSubscribe: func(s *Session, topic string) error {
+			if strings.HasPrefix(topic, "admin.") && s.ID() != "root" {
+				return errors.New("not authorized")
+			}
+			return nil
+		},
+		AuthorizePublish: func(s *Session, topic string) error {
+			if s.ID() == "guest" {
+				return errors.New("read only")
+			}
+			return nil
+		},
+	})
+	ctx := context.Background()
+	guest, _ := dial(t, s, "guest", nil)
+
+	var e *Error
+	if err := guest.Subscribe(ctx, "admin.>", func(*Message) {}); !errors.As(err, &e) || e.Message != "not authorized" {
+		t.Errorf("Subscribe(admin.>) returned %v, want not authorized error", err)
+	}
+	if err := guest.Subscribe(ctx, "news", func(*Message) {}); err != nil {
+		t.Errorf("Subscribe(news) returned %v", err)
+	}
+	if err := guest.Publish(ctx, "news", "x"); !errors.As(err, &e) || e.Message != "read only" {
+		t.Errorf("Publish(news) returned %v, want read only error", err)
+	}
+
+	root, _ := dial(t, s, "root", nil)
+	if err := root.Subscribe(ctx, "admin.>", func(*Message) {}); err != nil {
+		t.Errorf("root Subscribe(admin.>) returned %v", err)
+	}
+
+	// Requests without a client ID are rejected before the upgrade.
+	_, resp, err := s.Dialer(nil).Dial(wstest.DefaultURL, nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Dial() without client ID returned %v, want status 401", err)
+	}
+}
+
+func TestPresence(t *testing.T) {
+	srv, s := newServer(t, &ServerOptions{Presence: true})
+	ctx := context.Background()
+	events := make(chan Presence, 10)
+	alice, _ := dial(t, s, "alice", &ClientOptions{OnPresence: func(p Presence) { events <- p }})
+	if err := alice.Subscribe(ctx, "room.*", func(*Message) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	bob, _ := dial(t, s, "bob", nil)
+	if err := bob.Subscribe(ctx, "room.*", func(*Message) {}); err != nil {
+		t.Fatal(err)
+	}
+	if got := srv.Members("room.*"); !reflect.DeepEqual(got, []string{"alice", "bob"}) {
+		t.Errorf("Members() = %v, want [alice bob]", got)
+	}
+	bob.Close()
+
+	for _, want := range []Presence{
+		{Topic: "room.*", Client: "bob", Joined: true},
+		{Topic: "room.*", Client: "bob", Joined: false},
+	} {
+		select {
+		case p := <-events:
+			if p != want {
+				t.Errorf("presence = %+v, want %+v", p, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for presence")
+		}
+	}
+}
+
+func TestServerShutdown(t *testing.T) {
+	srv, s := newServer(t, &ServerOptions{})
+	c, errc := dial(t, s, "alice", nil)
+
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("Run() returned %v after server shutdown, want nil", err)
+	}
+	if err := c.Publish(context.Background(), "news", "x"); err != ErrClosed {
+		t.Errorf("Publish() returned %v, want %v", err, ErrClosed)
+	}
+}
@@ -0,0 +1,391 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package reconnect implements a WebSocket client that redials the server
+// when the connection fails.
+package reconnect
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/internal/lifecycle"
+)
+
+// ErrBufferFull is returned from Client.WriteMessage when the client is not
+// connected and the buffer of outgoing messages is full.
+var ErrBufferFull = errors.New("reconnect: outgoing message buffer full")
+
+// ErrClosed is returned from Client methods after the client is closed.
+var ErrClosed = errors.New("reconnect: client closed")
+
+// Backoff specifies an exponential backoff policy for redialing the server.
+type Backoff struct {
+	// Initial is the delay before the first redial.
+	Initial time.Duration
+
+	// Max is the maximum delay between redials.
+	Max time.Duration
+
+	// Multiplier is the factor by which the delay grows after each failed
+	// dial. If Multiplier is less than 1, the delay does not grow.
+	Multiplier float64
+
+	// Jitter is the fraction of the delay that is randomized. A delay d is
+	// changed to a random value in the range [d-Jitter*d, d+Jitter*d]. If
+	// zero, the delay is not randomized.
+	Jitter float64
+}
+
+// DefaultBackoff is the backoff policy used when Client.Backoff is nil.
+var DefaultBackoff = &Backoff{
+	Initial:    500 * time.Millisecond,
+	Max:        30 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// Delay returns the delay before redial attempt n, counting from zero.
+func (b *Backoff) Delay(n int) time.Duration {
+	d := float64(b.Initial)
+	for i := 0; i < n && b.Multiplier > 1; i++ {
+		d *= b.Multiplier
+		if b.Max > 0 && d >= float64(b.Max) {
+			break
+		}
+	}
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		d += d * b.Jitter * (2*rand.Float64() - 1)
+	}
+	if d < 0 {
+		d = 0
+	}
+	return time.Duration(d)
+}
+
+// Client maintains a WebSocket connection to a server, redialing the server
+// when the connection fails.
+//
+// Messages received from the server are passed to OnMessage. Outgoing
+// messages are sent with WriteMessage. While the client is not connected,
+// outgoing messages are buffered and sent after the next successful dial.
+type Client struct {
+	// URL is the WebSocket URL of the server.
+	URL string
+
+	// Header specifies the request header for each dial.
+	Header http.Header
+
+	// Dialer is used to connect to the server. If nil,
+	// websocket.DefaultDialer is used.
+	Dialer *websocket.Dialer
+
+	// Backoff specifies the delay between redials. If nil, DefaultBackoff
+	// is used. The backoff is reset after each successful connection.
+	Backoff *Backoff
+
+	// MaxBufferedMessages specifies the maximum number of outgoing messages
+	// buffered while the client is not connected. If zero, a default of 128
+	// is used. If negative, messages are not buffered and WriteMessage
+	// returns ErrBufferFull while the client is not connected.
+	MaxBufferedMessages int
+
+	// WriteTimeout specifies the write deadline for each message sent on a
+	// connection. A write that times out closes the connection and the
+	// message is buffered for the next connection. If zero, writes have no
+	// deadline.
+	WriteTimeout time.Duration
+
+	// OnConnect is called after each successful dial, before buffered
+	// messages are sent. Use OnConnect to authenticate and resubscribe on
+	// the new connection. If OnConnect returns an error, the connection is
+	// closed and the client redials the server.
+	//
+	// OnConnect is called from the goroutine executing Run. The function
+	// can read and write the connection directly.
+	OnConnect func(ctx context.Context, conn *websocket.Conn) error
+
+	// OnMessage is called with each message received from the server.
+	OnMessage func(messageType int, data []byte)
+
+	// OnDisconnect is called with the error that ended a connection or a
+	// failed dial.
+	OnDisconnect func(err error)
+
+	// IsPermanent reports whether a dial error is permanent. Run stops
+	// redialing and returns a permanent error. The response argument is the
+	// response returned from Dialer.DialContext. If IsPermanent is nil,
+	// IsPermanentError is used.
+	IsPermanent func(err error, resp *http.Response) bool
+
+	// writeMu serializes writes to the connection. Writes are not done
+	// while holding mu so that a blocked write does not block Close and
+	// Connected.
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	conn    *websocket.Conn // current connection, nil when not connected
+	pending []message       // messages buffered while not connected
+	closed  bool
+	cancel  context.CancelFunc
+}
+
+type message struct {
+	messageType int
+	data        []byte
+}
+
+const defaultMaxBufferedMessages = 128
+
+// IsPermanentError returns true if err is a *websocket.BadHandshakeError with
+// a 4xx status code other than 408 (Request Timeout) and 429 (Too Many
+// Requests). Redialing after these errors is unlikely to succeed. The response
+// argument is not used.
+func IsPermanentError(err error, _ *http.Response) bool {
+	var he *websocket.BadHandshakeError
+	if !errors.As(err, &he) || he.Check != websocket.HandshakeCheckStatus {
+		return false
+	}
+	switch he.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return he.StatusCode >= 400 && he.StatusCode < 500
+}
+
+// Run dials the server and maintains the connection until ctx is done, Close
+// is called or a permanent dial error occurs. Run returns ctx.Err(), nil or
+// the permanent error respectively. If the client is already closed, Run
+// returns nil immediately.
+func (c *Client) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.cancel = cancel
+	c.mu.Unlock()
+
+	dialer := c.Dialer
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+	backoff := c.Backoff
+	if backoff == nil {
+		backoff = DefaultBackoff
+	}
+	isPermanent := c.IsPermanent
+	if isPermanent == nil {
+		isPermanent = IsPermanentError
+	}
+
+	attempt := 0
+	for {
+		conn, resp, err := dialer.DialContext(ctx, c.URL, c.Header)
+		if err == nil && c.OnConnect != nil {
+			if err = c.OnConnect(ctx, conn); err != nil {
+				conn.Close()
+			}
+		}
+		if err == nil {
+			attempt = 0
+			err = c.serve(ctx, conn)
+		}
+
+		if c.isClosed() {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if c.OnDisconnect != nil {
+			c.OnDisconnect(err)
+		}
+		if conn == nil && isPermanent(err, resp) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff.Delay(attempt))
+		attempt++
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			if c.isClosed() {
+				return nil
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// serve sends the buffered messages and reads the connection until an error
+// occurs or ctx is done.
+func (c *Client) serve(ctx context.Context, conn *websocket.Conn) error {
+	defer conn.Close()
+
+	// Close the connection when ctx is done to unblock the read loop.
+	stop := lifecycle.Watch(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := c.flush(conn); err != nil {
+		return err
+	}
+
+	defer func() {
+		c.mu.Lock()
+		c.conn = nil
+		c.mu.Unlock()
+	}()
+
+	for {
+		mt, p, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if c.OnMessage != nil {
+			c.OnMessage(mt, p)
+		}
+	}
+}
+
+// flush sends the buffered messages on conn and makes conn the current
+// connection. Messages that are not sent remain buffered.
+func (c *Client) flush(conn *websocket.Conn) error {
+	// Hold writeMu until conn is the current connection so that messages
+	// written concurrently are not sent before the buffered messages.
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = nil
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+
+	for i, m := range pending {
+		if err := c.write(conn, m.messageType, m.data); err != nil {
+			c.mu.Lock()
+			if !c.closed {
+				c.pending = append(pending[i:len(pending):len(pending)], c.pending...)
+			}
+			c.mu.Unlock()
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+	c.conn = conn
+	return nil
+}
+
+// write sends a message on conn with the write timeout. The caller must hold
+// writeMu.
+func (c *Client) write(conn *websocket.Conn, messageType int, data []byte) error {
+	if c.WriteTimeout > 0 {
+		_ = conn.SetWriteDeadline(time.Now().Add(c.WriteTimeout))
+	}
+	return conn.WriteMessage(messageType, data)
+}
+
+func (c *Client) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// WriteMessage sends a message to the server. If the client is not connected
+// or the write fails, the message is buffered and sent after the next
+// successful dial. WriteMessage returns ErrBufferFull if the buffer is full.
+//
+// WriteMessage can be called concurrently with other client methods. A
+// write blocked on the network does not block Close or Connected.
+func (c *Client) WriteMessage(messageType int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.mu.Lock()
+	closed, conn := c.closed, c.conn
+	c.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+	if conn != nil {
+		err := c.write(conn, messageType, data)
+		if err == nil {
+			return nil
+		}
+		// Close the connection to force the read loop to exit and the
+		// client to redial.
+		conn.Close()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+	if c.conn == conn {
+		c.conn = nil
+	}
+	limit := c.MaxBufferedMessages
+	if limit == 0 {
+		limit = defaultMaxBufferedMessages
+	}
+	if len(c.pending) >= limit {
+		return ErrBufferFull
+	}
+	c.pending = append(c.pending, message{messageType: messageType, data: append([]byte(nil), data...)})
+	return nil
+}
+
+// Connected returns true if the client is connected to the server.
+func (c *Client) Connected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn != nil
+}
+
+// Close sends a close message to the server, closes the current connection
+// and stops Run. Buffered messages are discarded. Close interrupts a blocked
+// WriteMessage.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.pending = nil
+	conn, cancel := c.conn, c.cancel
+	c.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	if conn == nil {
+		return nil
+	}
+	// The close message is not sent if a blocked write holds the connection
+	// past lifecycle.CloseTimeout.
+	return lifecycle.Close(conn, websocket.CloseNormalClosure, "")
+}
@@ -0,0 +1,344 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reconnect
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/wstest"
+)
+
+var testBackoff = &Backoff{Initial: time.Millisecond, Max: 10 * time.Millisecond, Multiplier: 2}
+
+func TestBackoffDelay(t *testing.T) {
+	b := &Backoff{Initial: time.Second, Max: 10 * time.Second, Multiplier: 2}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, w := range want {
+		if d := b.Delay(i); d != w {
+			t.Errorf("Delay(%d) = %v, want %v", i, d, w)
+		}
+	}
+
+	b.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := b.Delay(0); d < 500*time.Millisecond || d > 1500*time.Millisecond {
+			t.Fatalf("Delay(0) with jitter = %v, want value in [500ms, 1.5s]", d)
+		}
+	}
+}
+
+func TestIsPermanentError(t *testing.T) {
+	statusError := func(status int) error {
+		return &websocket.BadHandshakeError{Check: websocket.HandshakeCheckStatus, StatusCode: status}
+	}
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{statusError(http.StatusForbidden), true},
+		{statusError(http.StatusNotFound), true},
+		{statusError(http.StatusTooManyRequests), false},
+		{statusError(http.StatusRequestTimeout), false},
+		{statusError(http.StatusServiceUnavailable), false},
+		{&websocket.BadHandshakeError{Check: websocket.HandshakeCheckAccept, StatusCode: http.StatusSwitchingProtocols}, false},
+		{websocket.ErrBadHandshake, false},
+		{errors.New("other"), false},
+	}
+	for _, tt := range tests {
+		if got := IsPermanentError(tt.err, nil); got != tt.want {
+			t.Errorf("IsPermanentError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// echoServer echoes messages. The server closes the connection after echoing
+// the message "bye".
+type echoServer struct{}
+
+func (s *echoServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var upgrader websocket.Upgrader
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	for {
+		mt, p, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.WriteMessage(mt, p)
+		if string(p) == "bye" {
+			return
+		}
+	}
+}
+
+func TestClientReconnect(t *testing.T) {
+	s := httptest.NewServer(&echoServer{})
+	defer s.Close()
+
+	received := make(chan string, 10)
+	disconnected := make(chan struct{}, 10)
+	var connects int32
+	c := &Client{
+		URL:     "ws" + strings.TrimPrefix(s.URL, "http"),
+		Backoff: testBackoff,
+		OnConnect: func(ctx context.Context, conn *websocket.Conn) error {
+			atomic.AddInt32(&connects, 1)
+			return nil
+		},
+		OnMessage:    func(mt int, p []byte) { received <- string(p) },
+		OnDisconnect: func(err error) { disconnected <- struct{}{} },
+	}
+
+	receive := func(want string) {
+		t.Helper()
+		select {
+		case m := <-received:
+			if m != want {
+				t.Fatalf("received %q, want %q", m, want)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timeout waiting for %q", want)
+		}
+	}
+
+	// Buffer messages before the first dial.
+	for _, m := range []string{"a", "bye"} {
+		if err := c.WriteMessage(websocket.TextMessage, []byte(m)); err != nil {
+			t.Fatalf("WriteMessage(%q) returned %v", m, err)
+		}
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- c.Run(context.Background()) }()
+
+	receive("a")
+	receive("bye")
+	<-disconnected
+
+	// The message is buffered or sent on the new connection.
+	if err := c.WriteMessage(websocket.TextMessage, []byte("b")); err != nil {
+		t.Fatalf("WriteMessage returned %v", err)
+	}
+	receive("b")
+
+	if err := c.Close(); err != nil {
+		t.Errorf("Close() returned %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Run() returned %v, want nil", err)
+	}
+	if n := atomic.LoadInt32(&connects); n != 2 {
+		t.Errorf("OnConnect called %d times, want 2", n)
+	}
+	if err := c.WriteMessage(websocket.TextMessage, []byte("x")); err != ErrClosed {
+		t.Errorf("WriteMessage() after Close returned %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestClientRunAfterClose(t *testing.T) {
+	c := &Client{URL: "ws://127.0.0.1:1/"}
+	c.Close()
+	if err := c.Run(context.Background()); err != nil {
+		t.Errorf("Run() after Close returned %v, want nil", err)
+	}
+}
+
+func TestClientPermanentError(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer s.Close()
+
+	var disconnects int
+	c := &Client{
+		URL:          "ws" + strings.TrimPrefix(s.URL, "http"),
+		Backoff:      testBackoff,
+		OnDisconnect: func(err error) { disconnects++ },
+	}
+	err := c.Run(context.Background())
+	if !errors.Is(err, websocket.ErrBadHandshake) {
+		t.Errorf("Run() returned %v, want %v", err, websocket.ErrBadHandshake)
+	}
+	if disconnects != 1 {
+		t.Errorf("OnDisconnect called %d times, want 1", disconnects)
+	}
+}
+
+func TestClientTransientError(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		n := requests
+		mu.Unlock()
+		if n < 3 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		(&echoServer{}).ServeHTTP(w, r)
+	}))
+	defer s.Close()
+
+	connected := make(chan struct{})
+	c := &Client{
+		URL:     "ws" + strings.TrimPrefix(s.URL, "http"),
+		Backoff: testBackoff,
+		OnConnect: func(ctx context.Context, conn *websocket.Conn) error {
+			close(connected)
+			return nil
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.Run(ctx) }()
+
+	select {
+	case <-connected:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for connection")
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run() returned %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestClientBufferFull(t *testing.T) {
+	c := &Client{MaxBufferedMessages: 1}
+	if err := c.WriteMessage(websocket.TextMessage, []byte("a")); err != nil {
+		t.Fatalf("WriteMessage() returned %v", err)
+	}
+	if err := c.WriteMessage(websocket.TextMessage, []byte("b")); err != ErrBufferFull {
+		t.Errorf("WriteMessage() returned %v, want %v", err, ErrBufferFull)
+	}
+
+	c = &Client{MaxBufferedMessages: -1}
+	if err := c.WriteMessage(websocket.TextMessage, []byte("a")); err != ErrBufferFull {
+		t.Errorf("WriteMessage() with no buffer returned %v, want %v", err, ErrBufferFull)
+	}
+}
+
+func TestClientCloseBlockedWrite(t *testing.T) {
+	// The server does not read. Writes block because the in-memory
+	// connection is synchronous.
+	release := make(chan struct{})
+	defer close(release)
+	s := wstest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var upgrader websocket.Upgrader
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		<-release
+	}))
+	defer s.Close()
+
+	connected := make(chan struct{})
+	c := &Client{
+		URL:     wstest.DefaultURL,
+		Dialer:  s.Dialer(nil),
+		Backoff: testBackoff,
+		OnConnect: func(ctx context.Context, conn *websocket.Conn) error {
+			close(connected)
+			return nil
+		},
+	}
+	done := make(chan error, 1)
+	go func() { done <- c.Run(context.Background()) }()
+	<-connected
+	for !c.Connected() {
+		time.Sleep(time.Millisecond)
+	}
+
+	written := make(chan error, 1)
+	go func() { written <- c.WriteMessage(websocket.TextMessage, []byte("blocked")) }()
+	time.Sleep(10 * time.Millisecond)
+
+	closed := make(chan error, 1)
+	go func() {
+		c.Connected()
+		closed <- c.Close()
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close blocked by WriteMessage")
+	}
+	if err := <-written; err != ErrClosed {
+		t.Errorf("WriteMessage() returned %v, want %v", err, ErrClosed)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Run() returned %v, want nil", err)
+	}
+}
+
+func TestClientWriteTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	var connects int32
+	s := wstest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var upgrader websocket.Upgrader
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if atomic.AddInt32(&connects, 1) == 1 {
+			// Do not read on the first connection.
+			<-release
+			return
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer s.Close()
+
+	disconnected := make(chan error, 1)
+	c := &Client{
+		URL:          wstest.DefaultURL,
+		Dialer:       s.Dialer(nil),
+		Backoff:      testBackoff,
+		WriteTimeout: 10 * time.Millisecond,
+		OnDisconnect: func(err error) {
+			select {
+			case disconnected <- err:
+			default:
+			}
+		},
+	}
+	go c.Run(context.Background())
+	defer c.Close()
+	for !c.Connected() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The write times out and the message is buffered for the next
+	// connection.
+	if err := c.WriteMessage(websocket.TextMessage, []byte("a")); err != nil {
+		t.Fatalf("WriteMessage() returned %v", err)
+	}
+	select {
+	case <-disconnected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection was not closed after the write timeout")
+	}
+}
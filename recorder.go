@@ -0,0 +1,76 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+
+	"github.com/gorilla/websocket/internal/wsframe"
+)
+
+// FrameDirection is the direction of a recorded frame.
+type FrameDirection int
+
+const (
+	// FrameSent is a frame sent to the peer.
+	FrameSent FrameDirection = 1
+
+	// FrameReceived is a frame received from the peer.
+	FrameReceived FrameDirection = 2
+)
+
+func (d FrameDirection) String() string {
+	switch d {
+	case FrameSent:
+		return "sent"
+	case FrameReceived:
+		return "received"
+	}
+	return "FrameDirection(" + strconv.Itoa(int(d)) + ")"
+}
+
+// FrameRecorder receives a copy of the raw frames sent and received on a
+// connection. Frames are recorded as they appear on the network, including
+// the frame header and the masking key. Received frames are recorded when
+// the connection reads the complete frame from the network, which may be
+// before the application reads the frame.
+//
+// RecordFrame is called concurrently from the goroutines reading and writing
+// the connection. The frame slice is valid only for the duration of the
+// call.
+//
+// A recorder holds each received frame in memory until the entire frame is
+// read. Use SetReadLimit to bound the memory used for recording.
+//
+// If the recorder implements io.Closer, the connection's Close method calls
+// the recorder's Close method. The Close method can be called more than once
+// and concurrently with RecordFrame.
+//
+// See the transcript package for a recorder that writes frames to a file.
+type FrameRecorder interface {
+	RecordFrame(dir FrameDirection, frame []byte)
+}
+
+// setFrameRecorder attaches r to the connection. The function must be called
+// before the connection is used.
+func (c *Conn) setFrameRecorder(r FrameRecorder) {
+	if r == nil {
+		return
+	}
+	c.recorder = r
+	s := &wsframe.Splitter{Record: func(frame []byte) { r.RecordFrame(FrameReceived, frame) }}
+	c.br = bufio.NewReaderSize(io.TeeReader(c.br, s), c.br.Size())
+}
+
+// recordSent records a frame written to the network. The caller must hold
+// c.mu.
+func (c *Conn) recordSent(buf0, buf1 []byte) {
+	if len(buf1) > 0 {
+		buf0 = append(append(make([]byte, 0, len(buf0)+len(buf1)), buf0...), buf1...)
+	}
+	c.recorder.RecordFrame(FrameSent, buf0)
+}
@@ -0,0 +1,128 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket/internal/wsframe"
+)
+
+type testRecorder struct {
+	mu     sync.Mutex
+	frames map[FrameDirection][][]byte
+	closed int
+}
+
+func (r *testRecorder) RecordFrame(dir FrameDirection, frame []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.frames == nil {
+		r.frames = make(map[FrameDirection][][]byte)
+	}
+	r.frames[dir] = append(r.frames[dir], append([]byte(nil), frame...))
+}
+
+func (r *testRecorder) Close() error {
+	r.mu.Lock()
+	r.closed++
+	r.mu.Unlock()
+	return nil
+}
+
+func TestFrameRecorder(t *testing.T) {
+	sc, cc := newPipeConns()
+	var sr, cr testRecorder
+	sc.setFrameRecorder(&sr)
+	cc.setFrameRecorder(&cr)
+
+	done := make(chan struct{})
+	go func() {
+		// Read the pong and the reply to the close message.
+		for {
+			if _, _, err := cc.NextReader(); err != nil {
+				break
+			}
+		}
+		close(done)
+	}()
+	go func() {
+		cc.WriteMessage(TextMessage, []byte("hello"))
+		cc.WriteMessage(BinaryMessage, make([]byte, 3000))
+		cc.WriteControl(PingMessage, []byte("ping"), time.Time{})
+		cc.WriteMessage(CloseMessage, FormatCloseMessage(CloseNormalClosure, ""))
+	}()
+	for {
+		if _, _, err := sc.ReadMessage(); err != nil {
+			break
+		}
+	}
+	<-done
+	sc.Close()
+	cc.Close()
+
+	// Text, three frames for the binary message, ping and close.
+	if n := len(cr.frames[FrameSent]); n != 6 {
+		t.Errorf("client sent %d frames, want 6", n)
+	}
+	for _, tt := range []struct {
+		name        string
+		sent, recvd [][]byte
+	}{
+		{"client to server", cr.frames[FrameSent], sr.frames[FrameReceived]},
+		{"server to client", sr.frames[FrameSent], cr.frames[FrameReceived]},
+	} {
+		if len(tt.sent) != len(tt.recvd) {
+			t.Errorf("%s: %d frames sent, %d received", tt.name, len(tt.sent), len(tt.recvd))
+			continue
+		}
+		for i := range tt.sent {
+			if !bytes.Equal(tt.sent[i], tt.recvd[i]) {
+				t.Errorf("%s: frame %d sent %x, received %x", tt.name, i, tt.sent[i], tt.recvd[i])
+			}
+		}
+	}
+	if sr.closed != 1 || cr.closed != 1 {
+		t.Errorf("recorder Close called %d, %d times, want 1", sr.closed, cr.closed)
+	}
+}
+
+func TestFrameSplitter(t *testing.T) {
+	var b bytes.Buffer
+	wc := newConn(fakeNetConn{Writer: &b}, false, 1024, 70000, nil, nil, nil)
+	for _, n := range []int{0, 125, 126, 65535, 65536} {
+		wc.WriteMessage(BinaryMessage, make([]byte, n))
+	}
+	stream := b.Bytes()
+
+	for _, chunk := range []int{1, 3, 1000, len(stream)} {
+		var lengths []int
+		s := &wsframe.Splitter{Record: func(frame []byte) { lengths = append(lengths, len(frame)) }}
+		for p := stream; len(p) > 0; {
+			n := chunk
+			if n > len(p) {
+				n = len(p)
+			}
+			s.Write(p[:n])
+			p = p[n:]
+		}
+		want := []int{6, 131, 134, 65543, 65550}
+		if len(lengths) != len(want) {
+			t.Fatalf("chunk %d: frame lengths %v, want %v", chunk, lengths, want)
+		}
+		for i := range want {
+			if lengths[i] != want[i] {
+				t.Errorf("chunk %d: frame lengths %v, want %v", chunk, lengths, want)
+				break
+			}
+		}
+	}
+}
+
+var _ io.Closer = (*testRecorder)(nil)
@@ -6,9 +6,11 @@ package websocket
 
 import (
 	"bufio"
+	"log/slog"
 	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -16,10 +18,82 @@ import (
 // HandshakeError describes an error with the handshake from the peer.
 type HandshakeError struct {
 	message string
+	err     error
+
+	// Status is the HTTP status code of the error response sent to the
+	// client.
+	Status int
+
+	// Check identifies the validation of the request that failed. Check is
+	// HandshakeCheckNone when the handshake failed for a reason other than an
+	// invalid request.
+	Check HandshakeCheck
+
+	// Request is the client's handshake request.
+	Request *http.Request
 }
 
 func (e HandshakeError) Error() string { return e.message }
 
+// Unwrap returns the underlying error, if any.
+func (e HandshakeError) Unwrap() error { return e.err }
+
+// HandshakeCheck identifies a validation performed on the opening handshake.
+type HandshakeCheck int
+
+// Validations of the opening handshake.
+const (
+	// HandshakeCheckNone indicates a failure other than a failed validation.
+	HandshakeCheckNone HandshakeCheck = iota
+
+	// HandshakeCheckStatus validates the response status code.
+	HandshakeCheckStatus
+
+	// HandshakeCheckMethod validates the request method.
+	HandshakeCheckMethod
+
+	// HandshakeCheckUpgrade validates the Upgrade header.
+	HandshakeCheckUpgrade
+
+	// HandshakeCheckConnection validates the Connection header.
+	HandshakeCheckConnection
+
+	// HandshakeCheckVersion validates the Sec-WebSocket-Version header.
+	HandshakeCheckVersion
+
+	// HandshakeCheckKey validates the Sec-WebSocket-Key header.
+	HandshakeCheckKey
+
+	// HandshakeCheckAccept validates the Sec-WebSocket-Accept header.
+	HandshakeCheckAccept
+
+	// HandshakeCheckOrigin validates the Origin header.
+	HandshakeCheckOrigin
+
+	// HandshakeCheckExtensions validates extension negotiation.
+	HandshakeCheckExtensions
+)
+
+var handshakeCheckNames = [...]string{
+	HandshakeCheckNone:       "none",
+	HandshakeCheckStatus:     "status",
+	HandshakeCheckMethod:     "method",
+	HandshakeCheckUpgrade:    "upgrade",
+	HandshakeCheckConnection: "connection",
+	HandshakeCheckVersion:    "version",
+	HandshakeCheckKey:        "key",
+	HandshakeCheckAccept:     "accept",
+	HandshakeCheckOrigin:     "origin",
+	HandshakeCheckExtensions: "extensions",
+}
+
+func (c HandshakeCheck) String() string {
+	if c >= 0 && int(c) < len(handshakeCheckNames) {
+		return handshakeCheckNames[c]
+	}
+	return "HandshakeCheck(" + strconv.Itoa(int(c)) + ")"
+}
+
 // Upgrader specifies parameters for upgrading an HTTP connection to a
 // WebSocket connection.
 //
@@ -71,10 +145,42 @@ type Upgrader struct {
 	// guarantee that compression will be supported. Currently only "no context
 	// takeover" modes are supported.
 	EnableCompression bool
+
+	// NewFrameRecorder specifies a function for creating a recorder for a
+	// connection. The function is called with the handshake request after
+	// the handshake succeeds. If NewFrameRecorder is nil or returns nil,
+	// frames are not recorded.
+	NewFrameRecorder func(r *http.Request) FrameRecorder
+
+	// Metrics specifies the metrics for connections created by this upgrader.
+	// If Metrics is nil, measurements are not taken.
+	Metrics Metrics
+
+	// ConnTrace specifies hooks for connections created by this upgrader.
+	// The hooks are called before the hooks of a trace attached to the
+	// request context with WithConnTrace.
+	ConnTrace *ConnTrace
+
+	// Codecs maps subprotocols to the codecs used by the connection
+	// WriteValue and ReadValue methods. The codec for the negotiated
+	// subprotocol, if any, is the default codec for the connection.
+	Codecs map[string]Codec
+
+	// JSONOptions specifies the options for decoding JSON messages on
+	// connections created by this upgrader. See Conn.SetJSONOptions.
+	JSONOptions *JSONOptions
+
+	// Logger specifies a logger for rejected handshakes and for connections
+	// created by this upgrader. If Logger is nil, nothing is logged. See
+	// Conn.SetLogger for the records logged by connections.
+	Logger *slog.Logger
 }
 
-func (u *Upgrader) returnError(w http.ResponseWriter, r *http.Request, status int, reason string) (*Conn, error) {
-	err := HandshakeError{reason}
+func (u *Upgrader) returnError(w http.ResponseWriter, r *http.Request, status int, check HandshakeCheck, reason string, cause error) (*Conn, error) {
+	err := HandshakeError{message: reason, err: cause, Status: status, Check: check, Request: r}
+	if u.Logger != nil {
+		u.logHandshakeError(r, err)
+	}
 	if u.Error != nil {
 		u.Error(w, r, status, err)
 	} else {
@@ -122,27 +228,42 @@ func (u *Upgrader) selectSubprotocol(r *http.Request, responseHeader http.Header
 // If the upgrade fails, then Upgrade replies to the client with an HTTP error
 // response.
 func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, responseHeader http.Header) (*Conn, error) {
+	trace := composeConnTrace(u.ConnTrace, ContextConnTrace(r.Context()))
+	if trace == nil {
+		return u.upgrade(w, r, responseHeader, nil)
+	}
+	if trace.HandshakeStart != nil {
+		trace.HandshakeStart(r)
+	}
+	c, err := u.upgrade(w, r, responseHeader, trace)
+	if trace.HandshakeDone != nil {
+		trace.HandshakeDone(HandshakeDoneInfo{Conn: c, Err: err})
+	}
+	return c, err
+}
+
+func (u *Upgrader) upgrade(w http.ResponseWriter, r *http.Request, responseHeader http.Header, trace *ConnTrace) (*Conn, error) {
 	const badHandshake = "websocket: the client is not using the websocket protocol: "
 
 	if !tokenListContainsValue(r.Header, "Connection", "upgrade") {
-		return u.returnError(w, r, http.StatusBadRequest, badHandshake+"'upgrade' token not found in 'Connection' header")
+		return u.returnError(w, r, http.StatusBadRequest, HandshakeCheckConnection, badHandshake+"'upgrade' token not found in 'Connection' header", nil)
 	}
 
 	if !tokenListContainsValue(r.Header, "Upgrade", "websocket") {
 		w.Header().Set("Upgrade", "websocket")
-		return u.returnError(w, r, http.StatusUpgradeRequired, badHandshake+"'websocket' token not found in 'Upgrade' header")
+		return u.returnError(w, r, http.StatusUpgradeRequired, HandshakeCheckUpgrade, badHandshake+"'websocket' token not found in 'Upgrade' header", nil)
 	}
 
 	if r.Method != http.MethodGet {
-		return u.returnError(w, r, http.StatusMethodNotAllowed, badHandshake+"request method is not GET")
+		return u.returnError(w, r, http.StatusMethodNotAllowed, HandshakeCheckMethod, badHandshake+"request method is not GET", nil)
 	}
 
 	if !tokenListContainsValue(r.Header, "Sec-Websocket-Version", "13") {
-		return u.returnError(w, r, http.StatusBadRequest, "websocket: unsupported version: 13 not found in 'Sec-Websocket-Version' header")
+		return u.returnError(w, r, http.StatusBadRequest, HandshakeCheckVersion, "websocket: unsupported version: 13 not found in 'Sec-Websocket-Version' header", nil)
 	}
 
 	if _, ok := responseHeader["Sec-Websocket-Extensions"]; ok {
-		return u.returnError(w, r, http.StatusInternalServerError, "websocket: application specific 'Sec-WebSocket-Extensions' headers are unsupported")
+		return u.returnError(w, r, http.StatusInternalServerError, HandshakeCheckExtensions, "websocket: application specific 'Sec-WebSocket-Extensions' headers are unsupported", nil)
 	}
 
 	checkOrigin := u.CheckOrigin
@@ -150,12 +271,12 @@ func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, responseHeade
 		checkOrigin = checkSameOrigin
 	}
 	if !checkOrigin(r) {
-		return u.returnError(w, r, http.StatusForbidden, "websocket: request origin not allowed by Upgrader.CheckOrigin")
+		return u.returnError(w, r, http.StatusForbidden, HandshakeCheckOrigin, "websocket: request origin not allowed by Upgrader.CheckOrigin", nil)
 	}
 
 	challengeKey := r.Header.Get("Sec-Websocket-Key")
 	if !isValidChallengeKey(challengeKey) {
-		return u.returnError(w, r, http.StatusBadRequest, "websocket: not a websocket handshake: 'Sec-WebSocket-Key' header must be Base64 encoded value of 16-byte in length")
+		return u.returnError(w, r, http.StatusBadRequest, HandshakeCheckKey, "websocket: not a websocket handshake: 'Sec-WebSocket-Key' header must be Base64 encoded value of 16-byte in length", nil)
 	}
 
 	subprotocol := u.selectSubprotocol(r, responseHeader)
@@ -174,8 +295,8 @@ func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, responseHeade
 
 	netConn, brw, err := http.NewResponseController(w).Hijack()
 	if err != nil {
-		return u.returnError(w, r, http.StatusInternalServerError,
-			"websocket: hijack: "+err.Error())
+		return u.returnError(w, r, http.StatusInternalServerError, HandshakeCheckNone,
+			"websocket: hijack: "+err.Error(), err)
 	}
 
 	// Close the network connection when returning an error. The variable
@@ -212,6 +333,8 @@ func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, responseHeade
 
 	c := newConn(netConn, true, u.ReadBufferSize, u.WriteBufferSize, u.WriteBufferPool, br, writeBuf)
 	c.subprotocol = subprotocol
+	c.subprotocolCodec = u.Codecs[subprotocol]
+	c.jsonOptions = u.JSONOptions
 
 	if compress {
 		c.newCompressionWriter = compressNoContextTakeover
@@ -276,6 +399,13 @@ func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, responseHeade
 		}
 	}
 
+	if u.NewFrameRecorder != nil {
+		c.setFrameRecorder(u.NewFrameRecorder(r))
+	}
+	c.metrics = u.Metrics
+	c.trace = trace
+	c.SetLogger(u.Logger)
+
 	// Success! Set netConn to nil to stop the deferred function above from
 	// closing the network connection.
 	netConn = nil
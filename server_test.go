@@ -169,3 +169,36 @@ func TestHijack_NotSupported(t *testing.T) {
 		t.Fatalf("got err=%T and status_code=%d", err, recorder.Code)
 	}
 }
+
+func TestHandshakeErrorFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		header http.Header
+		status int
+		check  HandshakeCheck
+	}{
+		{"connection", http.MethodGet, http.Header{"Upgrade": {"websocket"}}, http.StatusBadRequest, HandshakeCheckConnection},
+		{"upgrade", http.MethodGet, http.Header{"Connection": {"upgrade"}}, http.StatusUpgradeRequired, HandshakeCheckUpgrade},
+		{"method", http.MethodPost, http.Header{"Connection": {"upgrade"}, "Upgrade": {"websocket"}}, http.StatusMethodNotAllowed, HandshakeCheckMethod},
+		{"version", http.MethodGet, http.Header{"Connection": {"upgrade"}, "Upgrade": {"websocket"}}, http.StatusBadRequest, HandshakeCheckVersion},
+		{"origin", http.MethodGet, http.Header{"Connection": {"upgrade"}, "Upgrade": {"websocket"}, "Sec-Websocket-Version": {"13"},
+			"Origin": {"http://other.com"}}, http.StatusForbidden, HandshakeCheckOrigin},
+		{"key", http.MethodGet, http.Header{"Connection": {"upgrade"}, "Upgrade": {"websocket"}, "Sec-Websocket-Version": {"13"}}, http.StatusBadRequest, HandshakeCheckKey},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "http://example.com", nil)
+		req.Header = tt.header
+		recorder := httptest.NewRecorder()
+		var upgrader Upgrader
+		_, err := upgrader.Upgrade(recorder, req, nil)
+		var he HandshakeError
+		if !errors.As(err, &he) {
+			t.Errorf("%s: Upgrade returned %v, want HandshakeError", tt.name, err)
+			continue
+		}
+		if he.Status != tt.status || he.Check != tt.check || he.Request != req || recorder.Code != tt.status {
+			t.Errorf("%s: got status %d, check %v, response code %d, want %d, %v", tt.name, he.Status, he.Check, recorder.Code, tt.status, tt.check)
+		}
+	}
+}
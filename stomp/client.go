@@ -0,0 +1,388 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stomp
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/internal/lifecycle"
+)
+
+// ClientOptions specifies options for Connect.
+type ClientOptions struct {
+	// Host is the value of the host header in the CONNECT frame. If empty,
+	// "/" is used.
+	Host string
+
+	// Login and Passcode are the credentials sent in the CONNECT frame.
+	// They are omitted if empty.
+	Login    string
+	Passcode string
+
+	// HeartBeat specifies the heart-beat intervals offered by the client.
+	HeartBeat HeartBeat
+
+	// Header specifies additional headers for the CONNECT frame.
+	Header Header
+}
+
+// Message is a MESSAGE frame received for a subscription.
+type Message struct {
+	*Frame
+
+	// Destination, MessageID and Subscription are the values of the
+	// destination, message-id and subscription headers.
+	Destination  string
+	MessageID    string
+	Subscription string
+}
+
+// Client is the client side of a STOMP connection.
+//
+// Subscription handlers are called from the goroutine executing Run. They
+// must not call methods that wait for a receipt.
+//
+// It is safe to call Client methods concurrently.
+type Client struct {
+	c         *conn
+	connected *Frame
+	heartBeat HeartBeat
+
+	mu       sync.Mutex
+	seq      uint64
+	subs     map[string]*Subscription
+	receipts map[string]chan struct{}
+	closed   bool
+	done     chan struct{} // closed when Run returns or Close is called
+}
+
+// Connect sends a CONNECT frame on ws and waits for the CONNECTED frame.
+// The deadline of ctx applies to the handshake. If the server replies with
+// an ERROR frame, Connect returns an *Error. Call Run to receive frames
+// after Connect returns.
+func Connect(ctx context.Context, ws *websocket.Conn, opts *ClientOptions) (*Client, error) {
+	var o ClientOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Host == "" {
+		o.Host = "/"
+	}
+	f := &Frame{Command: CommandConnect, Header: Header{}}
+	for k, v := range o.Header {
+		f.Header[k] = v
+	}
+	f.Header["accept-version"] = "1.2"
+	f.Header["host"] = o.Host
+	f.Header["heart-beat"] = o.HeartBeat.String()
+	if o.Login != "" {
+		f.Header["login"] = o.Login
+	}
+	if o.Passcode != "" {
+		f.Header["passcode"] = o.Passcode
+	}
+
+	c := newConn(ws)
+	deadline, _ := ctx.Deadline()
+	stop := lifecycle.Watch(ctx, func() { ws.Close() })
+	_ = ws.SetWriteDeadline(deadline)
+	_ = ws.SetReadDeadline(deadline)
+	var r *Frame
+	err := c.writeFrame(f)
+	if err == nil {
+		r, err = c.readFrame()
+	}
+	if stop() {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		ws.Close()
+		return nil, handshakeError(ctx, err)
+	}
+	_ = ws.SetWriteDeadline(time.Time{})
+	_ = ws.SetReadDeadline(time.Time{})
+
+	switch r.Command {
+	case CommandConnected:
+	case CommandError:
+		ws.Close()
+		return nil, &Error{Frame: r}
+	default:
+		ws.Close()
+		return nil, invalidFrame("unexpected %s frame", r.Command)
+	}
+	if v := r.Header["version"]; v != "1.2" {
+		ws.Close()
+		return nil, invalidFrame("unsupported version %q", v)
+	}
+	remote, err := parseHeartBeat(r.Header["heart-beat"])
+	if err != nil {
+		ws.Close()
+		return nil, err
+	}
+	return &Client{
+		c:         c,
+		connected: r,
+		heartBeat: negotiate(o.HeartBeat, remote),
+		subs:      make(map[string]*Subscription),
+		receipts:  make(map[string]chan struct{}),
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// handshakeError returns ctx.Err() if the handshake failed because ctx is
+// done.
+func handshakeError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// Conn returns the WebSocket connection used by c.
+func (c *Client) Conn() *websocket.Conn {
+	return c.c.ws
+}
+
+// Connected returns the CONNECTED frame received from the server.
+func (c *Client) Connected() *Frame {
+	return c.connected
+}
+
+// HeartBeat returns the negotiated heart-beat intervals.
+func (c *Client) HeartBeat() HeartBeat {
+	return c.heartBeat
+}
+
+// Run receives frames and sends heart-beats until the connection fails,
+// ctx is done or the client is closed. Run returns an *Error if the server
+// sends an ERROR frame.
+//
+// Run returns nil if the client was closed with Close or Disconnect or the
+// server closed the connection normally, ctx.Err() if ctx is done and the
+// read error otherwise.
+func (c *Client) Run(ctx context.Context) error {
+	hbctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c.c.startHeartbeat(c.heartBeat)
+	go c.c.heartbeat(hbctx, c.heartBeat)
+	stop := lifecycle.Watch(ctx, func() { c.c.ws.Close() })
+	err := c.readLoop()
+	stop()
+	c.shutdown()
+	c.c.ws.Close()
+	return lifecycle.RunError(ctx, c.isClosed(), err)
+}
+
+func (c *Client) readLoop() error {
+	for {
+		f, err := c.c.readFrame()
+		if err != nil {
+			return err
+		}
+		switch f.Command {
+		case CommandMessage:
+			m := &Message{
+				Frame:        f,
+				Destination:  f.Header["destination"],
+				MessageID:    f.Header["message-id"],
+				Subscription: f.Header["subscription"],
+			}
+			c.mu.Lock()
+			sub := c.subs[m.Subscription]
+			c.mu.Unlock()
+			if sub != nil {
+				sub.handler(m)
+			}
+		case CommandReceipt:
+			c.mu.Lock()
+			ch := c.receipts[f.Header["receipt-id"]]
+			delete(c.receipts, f.Header["receipt-id"])
+			c.mu.Unlock()
+			if ch != nil {
+				close(ch)
+			}
+		case CommandError:
+			return &Error{Frame: f}
+		default:
+			return invalidFrame("unexpected %s frame", f.Command)
+		}
+	}
+}
+
+func (c *Client) shutdown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+}
+
+func (c *Client) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+func (c *Client) nextID(prefix string) string {
+	c.seq++
+	return prefix + strconv.FormatUint(c.seq, 10)
+}
+
+// WriteFrame sends f to the server.
+func (c *Client) WriteFrame(f *Frame) error {
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+	return c.c.writeFrame(f)
+}
+
+// WriteFrameReceipt adds a receipt header to f, sends f to the server and
+// waits for the RECEIPT frame. Run must be executing to receive the
+// receipt.
+func (c *Client) WriteFrameReceipt(ctx context.Context, f *Frame) error {
+	c.mu.Lock()
+	id := c.nextID("receipt-")
+	ch := make(chan struct{})
+	c.receipts[id] = ch
+	c.mu.Unlock()
+
+	g := *f
+	g.Header = Header{"receipt": id}
+	for k, v := range f.Header {
+		if k != "receipt" {
+			g.Header[k] = v
+		}
+	}
+	err := c.WriteFrame(&g)
+	if err == nil {
+		select {
+		case <-ch:
+			return nil
+		case <-c.done:
+			// The receipt may have been received before the connection
+			// was closed.
+			select {
+			case <-ch:
+				return nil
+			default:
+				err = ErrClosed
+			}
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	c.mu.Lock()
+	delete(c.receipts, id)
+	c.mu.Unlock()
+	return err
+}
+
+// Send sends a SEND frame with the body to the destination. The header
+// specifies additional headers such as content-type and may be nil.
+func (c *Client) Send(destination string, body []byte, header Header) error {
+	f := &Frame{Command: CommandSend, Header: Header{}, Body: body}
+	for k, v := range header {
+		f.Header[k] = v
+	}
+	f.Header["destination"] = destination
+	return c.WriteFrame(f)
+}
+
+// Subscription is a subscription to a destination.
+type Subscription struct {
+	c           *Client
+	id          string
+	destination string
+	ack         AckMode
+	handler     func(m *Message)
+}
+
+// ID returns the subscription ID.
+func (s *Subscription) ID() string {
+	return s.id
+}
+
+// Subscribe subscribes to the destination with the acknowledgment mode and
+// waits for the server to acknowledge the subscription with a receipt. The
+// handler is called with each message received for the subscription. If ack
+// is not AckAuto, the application must acknowledge the messages with Ack or
+// Nack.
+func (c *Client) Subscribe(ctx context.Context, destination string, ack AckMode, handler func(m *Message)) (*Subscription, error) {
+	if ack == "" {
+		ack = AckAuto
+	}
+	c.mu.Lock()
+	s := &Subscription{c: c, id: c.nextID("sub-"), destination: destination, ack: ack, handler: handler}
+	c.subs[s.id] = s
+	c.mu.Unlock()
+
+	err := c.WriteFrameReceipt(ctx, &Frame{Command: CommandSubscribe, Header: Header{
+		"id":          s.id,
+		"destination": destination,
+		"ack":         string(ack),
+	}})
+	if err != nil {
+		c.mu.Lock()
+		delete(c.subs, s.id)
+		c.mu.Unlock()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Unsubscribe removes the subscription and waits for the server to
+// acknowledge the request with a receipt.
+func (s *Subscription) Unsubscribe(ctx context.Context) error {
+	s.c.mu.Lock()
+	delete(s.c.subs, s.id)
+	s.c.mu.Unlock()
+	return s.c.WriteFrameReceipt(ctx, &Frame{Command: CommandUnsubscribe, Header: Header{"id": s.id}})
+}
+
+// Ack acknowledges the message. For subscriptions with the mode AckClient,
+// Ack acknowledges all messages received before m.
+func (c *Client) Ack(m *Message) error {
+	return c.WriteFrame(&Frame{Command: CommandAck, Header: Header{"id": m.Header["ack"]}})
+}
+
+// Nack tells the server that the message was not consumed.
+func (c *Client) Nack(m *Message) error {
+	return c.WriteFrame(&Frame{Command: CommandNack, Header: Header{"id": m.Header["ack"]}})
+}
+
+// Disconnect sends a DISCONNECT frame, waits for the receipt and closes the
+// connection.
+func (c *Client) Disconnect(ctx context.Context) error {
+	err := c.WriteFrameReceipt(ctx, &Frame{Command: CommandDisconnect})
+	if cerr := c.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Close sends a WebSocket close message to the server, closes the
+// connection and stops Run. Close does not send a DISCONNECT frame; use
+// Disconnect for a graceful shutdown.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+	err := lifecycle.Close(c.c.ws, websocket.CloseNormalClosure, "")
+	c.shutdown()
+	return err
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stomp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/wstest"
+)
+
+// connectRaw connects a client to a server connection that replies to
+// CONNECT with the CONNECTED frame header and then only reads messages if
+// read is true.
+func connectRaw(t *testing.T, header Header, read bool) *Client {
+	t.Helper()
+	cc, sc, err := wstest.Pair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cc.Close()
+		sc.Close()
+	})
+	go func() {
+		if _, _, err := sc.ReadMessage(); err != nil {
+			return
+		}
+		f := &Frame{Command: CommandConnected, Header: header}
+		if err := sc.WriteMessage(websocket.TextMessage, f.encode()); err != nil {
+			return
+		}
+		for read {
+			if _, _, err := sc.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	c, err := Connect(context.Background(), cc, &ClientOptions{
+		HeartBeat: HeartBeat{Receive: 50 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestHeartBeatPing(t *testing.T) {
+	// The server offers heart-beats but does not send them. The server
+	// answers pings while reading, which keeps the connection alive.
+	c := connectRaw(t, Header{"version": "1.2", "heart-beat": "50,0"}, true)
+	if hb := c.HeartBeat(); hb != (HeartBeat{Receive: 50 * time.Millisecond}) {
+		t.Errorf("HeartBeat() = %v", hb)
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- c.Run(context.Background()) }()
+	select {
+	case err := <-errc:
+		t.Fatalf("Run() returned %v", err)
+	case <-time.After(500 * time.Millisecond):
+	}
+	if c.Conn().RTT() == 0 {
+		t.Error("RTT() = 0, want a ping round trip")
+	}
+	c.Close()
+	if err := <-errc; err != nil {
+		t.Errorf("Run() returned %v after Close", err)
+	}
+}
+
+func TestHeartBeatTimeout(t *testing.T) {
+	// The server neither sends heart-beats nor answers pings.
+	c := connectRaw(t, Header{"version": "1.2", "heart-beat": "50,0"}, false)
+	errc := make(chan error, 1)
+	go func() { errc <- c.Run(context.Background()) }()
+	select {
+	case err := <-errc:
+		var nerr net.Error
+		if !errors.As(err, &nerr) || !nerr.Timeout() {
+			t.Errorf("Run() returned %v, want timeout", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not time out")
+	}
+}
+
+func TestConnectErrors(t *testing.T) {
+	cc, sc, err := wstest.Pair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	go Accept(context.Background(), sc, &ServerOptions{
+		Authenticate: func(f *Frame) error {
+			if f.Header["passcode"] != "secret" {
+				return errors.New("bad passcode")
+			}
+			return nil
+		},
+	})
+	var e *Error
+	_, err = Connect(context.Background(), cc, &ClientOptions{Login: "a", Passcode: "wrong"})
+	if !errors.As(err, &e) || e.Frame.Header["message"] != "bad passcode" {
+		t.Errorf("Connect() returned %v, want bad passcode error", err)
+	}
+
+	// Servers reject clients that do not support STOMP 1.2.
+	cc, sc, err = wstest.Pair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	errc := make(chan error, 1)
+	go func() {
+		_, err := Accept(context.Background(), sc, nil)
+		errc <- err
+	}()
+	f := &Frame{Command: CommandConnect, Header: Header{"accept-version": "1.0,1.1"}}
+	if err := cc.WriteMessage(websocket.TextMessage, f.encode()); err != nil {
+		t.Fatal(err)
+	}
+	_, p, err := cc.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, err := parseFrame(p); err != nil || r.Command != CommandError || r.Header["version"] != "1.2" {
+		t.Errorf("reply = %+v, %v, want ERROR frame with version 1.2", r, err)
+	}
+	// Read the close message.
+	cc.ReadMessage()
+	if err := <-errc; !errors.Is(err, ErrInvalidFrame) {
+		t.Errorf("Accept() returned %v, want %v", err, ErrInvalidFrame)
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stomp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+)
+
+// escapeHeaders reports whether header entries of frames with the command
+// are escaped. CONNECT and CONNECTED frames are not escaped for
+// compatibility with STOMP 1.0.
+func escapeHeaders(command string) bool {
+	return command != CommandConnect && command != CommandConnected
+}
+
+var headerEscaper = strings.NewReplacer(`\`, `\\`, "\r", `\r`, "\n", `\n`, ":", `\c`)
+
+// encode returns the wire encoding of f. A content-length header is added
+// to frames with a body.
+func (f *Frame) encode() []byte {
+	var b bytes.Buffer
+	b.WriteString(f.Command)
+	b.WriteByte('\n')
+	escape := escapeHeaders(f.Command)
+	for _, k := range sortedKeys(f.Header) {
+		if k == "content-length" {
+			continue
+		}
+		v := f.Header[k]
+		if escape {
+			k, v = headerEscaper.Replace(k), headerEscaper.Replace(v)
+		}
+		b.WriteString(k)
+		b.WriteByte(':')
+		b.WriteString(v)
+		b.WriteByte('\n')
+	}
+	if len(f.Body) > 0 {
+		b.WriteString("content-length:")
+		b.WriteString(strconv.Itoa(len(f.Body)))
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	b.Write(f.Body)
+	b.WriteByte(0)
+	return b.Bytes()
+}
+
+func invalidFrame(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidFrame, fmt.Sprintf(format, args...))
+}
+
+func unescape(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", invalidFrame("invalid escape sequence")
+		}
+		switch s[i] {
+		case 'r':
+			b.WriteByte('\r')
+		case 'n':
+			b.WriteByte('\n')
+		case 'c':
+			b.WriteByte(':')
+		case '\\':
+			b.WriteByte('\\')
+		default:
+			return "", invalidFrame("invalid escape sequence \\%c", s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// cutLine returns the line at the start of p without the end-of-line and
+// the rest of p.
+func cutLine(p []byte) (line string, rest []byte, ok bool) {
+	i := bytes.IndexByte(p, '\n')
+	if i < 0 {
+		return "", nil, false
+	}
+	line = string(p[:i])
+	line = strings.TrimSuffix(line, "\r")
+	return line, p[i+1:], true
+}
+
+// trimEOL removes heart-beats from the start of p.
+func trimEOL(p []byte) []byte {
+	for len(p) > 0 && (p[0] == '\n' || p[0] == '\r' && len(p) > 1 && p[1] == '\n') {
+		if p[0] == '\r' {
+			p = p[1:]
+		}
+		p = p[1:]
+	}
+	return p
+}
+
+// parseFrame parses one frame from p. It returns nil if p contains only
+// heart-beats.
+func parseFrame(p []byte) (*Frame, error) {
+	p = trimEOL(p)
+	if len(p) == 0 {
+		return nil, nil
+	}
+	command, p, ok := cutLine(p)
+	if !ok || command == "" {
+		return nil, invalidFrame("missing command")
+	}
+	f := &Frame{Command: command, Header: make(Header)}
+	escape := escapeHeaders(command)
+	for {
+		var line string
+		line, p, ok = cutLine(p)
+		if !ok {
+			return nil, invalidFrame("missing end of header")
+		}
+		if line == "" {
+			break
+		}
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, invalidFrame("invalid header line %q", line)
+		}
+		if escape {
+			var err error
+			if k, err = unescape(k); err != nil {
+				return nil, err
+			}
+			if v, err = unescape(v); err != nil {
+				return nil, err
+			}
+		}
+		if _, ok := f.Header[k]; !ok {
+			f.Header[k] = v
+		}
+	}
+
+	n := bytes.IndexByte(p, 0)
+	if s, ok := f.Header["content-length"]; ok {
+		var err error
+		n, err = strconv.Atoi(s)
+		if err != nil || n < 0 || n >= len(p) || p[n] != 0 {
+			return nil, invalidFrame("invalid content-length %q", s)
+		}
+	}
+	if n < 0 {
+		return nil, invalidFrame("missing NULL octet")
+	}
+	if n > 0 {
+		f.Body = p[:n]
+	}
+	if len(trimEOL(p[n+1:])) > 0 {
+		return nil, invalidFrame("data after NULL octet")
+	}
+	return f, nil
+}
+
+// conn reads and writes frames on a WebSocket connection. It is used by
+// both ends of a STOMP connection.
+type conn struct {
+	ws *websocket.Conn
+
+	writeMu   sync.Mutex
+	lastWrite time.Time // protected by writeMu
+
+	lastRead    atomic.Int64 // unix nanoseconds of the last data received
+	readTimeout atomic.Int64 // set when heart-beats start
+}
+
+func newConn(ws *websocket.Conn) *conn {
+	c := &conn{ws: ws}
+	c.lastRead.Store(time.Now().UnixNano())
+	return c
+}
+
+// writeFrame writes f as one WebSocket message.
+func (c *conn) writeFrame(f *Frame) error {
+	p := f.encode()
+	messageType := websocket.TextMessage
+	if !utf8.Valid(f.Body) {
+		messageType = websocket.BinaryMessage
+	}
+	return c.write(messageType, p)
+}
+
+func (c *conn) write(messageType int, p []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.lastWrite = time.Now()
+	return c.ws.WriteMessage(messageType, p)
+}
+
+// readFrame reads the next frame and skips heart-beats.
+func (c *conn) readFrame() (*Frame, error) {
+	for {
+		if d := time.Duration(c.readTimeout.Load()); d > 0 {
+			if err := c.ws.SetReadDeadline(time.Now().Add(d)); err != nil {
+				return nil, err
+			}
+		}
+		_, r, err := c.ws.NextReader()
+		if err != nil {
+			return nil, err
+		}
+		p, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		c.received()
+		f, err := parseFrame(p)
+		if err != nil || f != nil {
+			return f, err
+		}
+	}
+}
+
+// received records that data was received from the peer.
+func (c *conn) received() {
+	c.lastRead.Store(time.Now().UnixNano())
+	if d := time.Duration(c.readTimeout.Load()); d > 0 {
+		_ = c.ws.SetReadDeadline(time.Now().Add(d))
+	}
+}
+
+// startHeartbeat applies a read deadline of twice the receive interval to
+// the reads in readFrame. Pongs from the peer extend the deadline. It must
+// be called before the connection is read concurrently.
+func (c *conn) startHeartbeat(hb HeartBeat) {
+	if hb.Receive > 0 {
+		c.readTimeout.Store(int64(2 * hb.Receive))
+		c.ws.SetPongHandler(func(string) error {
+			c.received()
+			return nil
+		})
+		c.received()
+	}
+}
+
+// heartbeat sends heart-beats and pings with the negotiated intervals until
+// ctx is done.
+func (c *conn) heartbeat(ctx context.Context, hb HeartBeat) {
+	var tick time.Duration
+	switch {
+	case hb.Send > 0 && hb.Receive > 0:
+		tick = min(hb.Send, hb.Receive/2)
+	case hb.Send > 0:
+		tick = hb.Send
+	case hb.Receive > 0:
+		tick = hb.Receive / 2
+	default:
+		return
+	}
+	t := time.NewTicker(tick)
+	defer t.Stop()
+
+	var pinging atomic.Bool
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			if hb.Send > 0 {
+				c.writeMu.Lock()
+				idle := now.Sub(c.lastWrite) >= hb.Send-tick
+				c.writeMu.Unlock()
+				if idle {
+					_ = c.write(websocket.TextMessage, []byte{'\n'})
+				}
+			}
+			if hb.Receive > 0 && now.Sub(time.Unix(0, c.lastRead.Load())) >= hb.Receive && pinging.CompareAndSwap(false, true) {
+				go func() {
+					defer pinging.Store(false)
+					// Stop waiting for the pong after the receive interval.
+					// A deadline on ctx would also apply to writing the ping
+					// and a write timeout breaks the connection.
+					ctx, cancel := context.WithCancel(ctx)
+					defer time.AfterFunc(hb.Receive, cancel).Stop()
+					defer cancel()
+					_, _ = c.ws.Ping(ctx)
+				}()
+			}
+		}
+	}
+}
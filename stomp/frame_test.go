@@ -0,0 +1,102 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stomp
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFrameEncoding(t *testing.T) {
+	tests := []struct {
+		frame   *Frame
+		encoded string
+	}{
+		{
+			&Frame{Command: CommandSend, Header: Header{"destination": "/queue/a"}},
+			"SEND\ndestination:/queue/a\n\n\x00",
+		},
+		{
+			&Frame{Command: CommandSend, Header: Header{"destination": "/queue/a", "key:x": "a\nb\\c"}, Body: []byte("hi\x00there")},
+			"SEND\ndestination:/queue/a\nkey\\cx:a\\nb\\\\c\ncontent-length:8\n\nhi\x00there\x00",
+		},
+		{
+			// CONNECT headers are not escaped.
+			&Frame{Command: CommandConnect, Header: Header{"login": "a:b"}},
+			"CONNECT\nlogin:a:b\n\n\x00",
+		},
+	}
+	for _, tt := range tests {
+		if got := string(tt.frame.encode()); got != tt.encoded {
+			t.Errorf("encode(%v) = %q, want %q", tt.frame, got, tt.encoded)
+		}
+		f, err := parseFrame([]byte(tt.encoded))
+		if err != nil {
+			t.Errorf("parseFrame(%q) returned %v", tt.encoded, err)
+			continue
+		}
+		delete(f.Header, "content-length")
+		if !reflect.DeepEqual(f, tt.frame) {
+			t.Errorf("parseFrame(%q) = %+v, want %+v", tt.encoded, f, tt.frame)
+		}
+	}
+}
+
+func TestParseFrame(t *testing.T) {
+	f, err := parseFrame([]byte("\r\n\nMESSAGE\r\nid:1\nid:2\n\nbody\x00\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Frame{Command: CommandMessage, Header: Header{"id": "1"}, Body: []byte("body")}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("parseFrame() = %+v, want %+v", f, want)
+	}
+
+	if f, err := parseFrame([]byte("\n")); f != nil || err != nil {
+		t.Errorf("parseFrame(heart-beat) = %v, %v, want nil, nil", f, err)
+	}
+
+	for _, s := range []string{
+		"SEND",
+		"SEND\nkey\n\n\x00",
+		"SEND\nkey:\\t\n\n\x00",
+		"SEND\n\nbody",
+		"SEND\ncontent-length:10\n\nbody\x00",
+		"SEND\n\nbody\x00SEND\n\n\x00",
+	} {
+		if _, err := parseFrame([]byte(s)); !errors.Is(err, ErrInvalidFrame) {
+			t.Errorf("parseFrame(%q) returned %v, want %v", s, err, ErrInvalidFrame)
+		}
+	}
+}
+
+func TestHeartBeatNegotiation(t *testing.T) {
+	hb, err := parseHeartBeat("100,200")
+	if err != nil || hb != (HeartBeat{Send: 100 * time.Millisecond, Receive: 200 * time.Millisecond}) {
+		t.Errorf("parseHeartBeat() = %v, %v", hb, err)
+	}
+	if hb.String() != "100,200" {
+		t.Errorf("String() = %q, want 100,200", hb.String())
+	}
+	if _, err := parseHeartBeat("100"); err == nil {
+		t.Error("parseHeartBeat(100) returned nil error")
+	}
+
+	ms := time.Millisecond
+	tests := []struct {
+		local, remote, want HeartBeat
+	}{
+		{HeartBeat{100 * ms, 200 * ms}, HeartBeat{300 * ms, 50 * ms}, HeartBeat{100 * ms, 300 * ms}},
+		{HeartBeat{100 * ms, 0}, HeartBeat{300 * ms, 500 * ms}, HeartBeat{500 * ms, 0}},
+		{HeartBeat{100 * ms, 100 * ms}, HeartBeat{}, HeartBeat{}},
+	}
+	for _, tt := range tests {
+		if got := negotiate(tt.local, tt.remote); got != tt.want {
+			t.Errorf("negotiate(%v, %v) = %v, want %v", tt.local, tt.remote, got, tt.want)
+		}
+	}
+}
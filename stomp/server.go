@@ -0,0 +1,270 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stomp
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/internal/lifecycle"
+)
+
+// ServerOptions specifies options for Accept.
+type ServerOptions struct {
+	// Authenticate is called with the CONNECT frame. If Authenticate
+	// returns an error, the server sends an ERROR frame with the error
+	// text and closes the connection. If nil, all clients are accepted.
+	Authenticate func(f *Frame) error
+
+	// HeartBeat specifies the heart-beat intervals offered by the server.
+	HeartBeat HeartBeat
+
+	// Server is the value of the server header in the CONNECTED frame. The
+	// header is omitted if empty.
+	Server string
+
+	// Handler is called with each frame received from the client except
+	// DISCONNECT frames. If Handler returns an error, the server sends an
+	// ERROR frame with the error text and closes the connection. If the
+	// frame has a receipt header and Handler returns nil, the server sends a
+	// RECEIPT frame. Handler is called from the goroutine executing Run.
+	Handler func(c *ServerConn, f *Frame) error
+}
+
+// ServerConn is the server side of a STOMP connection.
+//
+// It is safe to call ServerConn methods concurrently.
+type ServerConn struct {
+	c         *conn
+	opts      ServerOptions
+	connect   *Frame
+	heartBeat HeartBeat
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// Accept waits for the CONNECT or STOMP frame on ws and replies with the
+// CONNECTED frame. The deadline of ctx applies to the handshake. If the
+// client does not support STOMP 1.2 or authentication fails, Accept sends an
+// ERROR frame, closes the connection and returns an error. Call Run to
+// receive frames after Accept returns.
+func Accept(ctx context.Context, ws *websocket.Conn, opts *ServerOptions) (*ServerConn, error) {
+	sc := &ServerConn{c: newConn(ws)}
+	if opts != nil {
+		sc.opts = *opts
+	}
+
+	stop := lifecycle.Watch(ctx, func() { ws.Close() })
+	err := sc.handshake(ctx)
+	if stop() {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+// handshake reads the CONNECT frame and replies with the CONNECTED frame.
+func (sc *ServerConn) handshake(ctx context.Context) error {
+	ws := sc.c.ws
+	deadline, _ := ctx.Deadline()
+	_ = ws.SetWriteDeadline(deadline)
+	_ = ws.SetReadDeadline(deadline)
+	f, err := sc.c.readFrame()
+	if err != nil {
+		ws.Close()
+		return handshakeError(ctx, err)
+	}
+	if f.Command != CommandConnect && f.Command != CommandStomp {
+		err = invalidFrame("unexpected %s frame", f.Command)
+		sc.fail(err, nil)
+		return err
+	}
+	if !acceptsVersion12(f.Header["accept-version"]) {
+		err = invalidFrame("unsupported version %q", f.Header["accept-version"])
+		sc.fail(err, Header{"version": "1.2"})
+		return err
+	}
+	remote, err := parseHeartBeat(f.Header["heart-beat"])
+	if err != nil {
+		sc.fail(err, nil)
+		return err
+	}
+	if sc.opts.Authenticate != nil {
+		if err := sc.opts.Authenticate(f); err != nil {
+			sc.fail(err, nil)
+			return err
+		}
+	}
+	sc.connect = f
+	sc.heartBeat = negotiate(sc.opts.HeartBeat, remote)
+
+	r := &Frame{Command: CommandConnected, Header: Header{
+		"version":    "1.2",
+		"heart-beat": sc.opts.HeartBeat.String(),
+	}}
+	if sc.opts.Server != "" {
+		r.Header["server"] = sc.opts.Server
+	}
+	if err := sc.c.writeFrame(r); err != nil {
+		ws.Close()
+		return handshakeError(ctx, err)
+	}
+	_ = ws.SetWriteDeadline(time.Time{})
+	_ = ws.SetReadDeadline(time.Time{})
+	return nil
+}
+
+func acceptsVersion12(s string) bool {
+	for _, v := range strings.Split(s, ",") {
+		if strings.TrimSpace(v) == "1.2" {
+			return true
+		}
+	}
+	return false
+}
+
+// fail sends an ERROR frame with the error text and the header and closes
+// the connection.
+func (sc *ServerConn) fail(err error, header Header) {
+	f := &Frame{Command: CommandError, Header: Header{}, Body: []byte(err.Error())}
+	for k, v := range header {
+		f.Header[k] = v
+	}
+	f.Header["message"] = err.Error()
+	f.Header["content-type"] = "text/plain"
+	_ = sc.c.writeFrame(f)
+	_ = lifecycle.Close(sc.c.ws, websocket.CloseNormalClosure, "")
+}
+
+// Conn returns the WebSocket connection used by sc.
+func (sc *ServerConn) Conn() *websocket.Conn {
+	return sc.c.ws
+}
+
+// ConnectFrame returns the CONNECT or STOMP frame received from the client.
+func (sc *ServerConn) ConnectFrame() *Frame {
+	return sc.connect
+}
+
+// HeartBeat returns the negotiated heart-beat intervals.
+func (sc *ServerConn) HeartBeat() HeartBeat {
+	return sc.heartBeat
+}
+
+// Run receives frames, calls the handler and sends heart-beats until the
+// client disconnects, the connection fails, ctx is done or Close is called.
+//
+// Run returns nil if the client sent a DISCONNECT frame, the connection was
+// closed with Close or closed normally by the client, ctx.Err() if ctx is
+// done, the handler error if the handler failed and the read error
+// otherwise.
+func (sc *ServerConn) Run(ctx context.Context) error {
+	hbctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sc.c.startHeartbeat(sc.heartBeat)
+	go sc.c.heartbeat(hbctx, sc.heartBeat)
+	stop := lifecycle.Watch(ctx, func() { sc.c.ws.Close() })
+	err := sc.readLoop()
+	stop()
+	sc.c.ws.Close()
+	return lifecycle.RunError(ctx, sc.isClosed(), err)
+}
+
+func (sc *ServerConn) readLoop() error {
+	for {
+		f, err := sc.c.readFrame()
+		if err != nil {
+			return err
+		}
+		if f.Command == CommandDisconnect {
+			if err := sc.receipt(f); err != nil {
+				return err
+			}
+			sc.Close()
+			return nil
+		}
+		if sc.opts.Handler != nil {
+			if err := sc.opts.Handler(sc, f); err != nil {
+				h := Header{}
+				if id, ok := f.Header["receipt"]; ok {
+					h["receipt-id"] = id
+				}
+				sc.fail(err, h)
+				return err
+			}
+		}
+		if err := sc.receipt(f); err != nil {
+			return err
+		}
+	}
+}
+
+// receipt sends a RECEIPT frame if f has a receipt header.
+func (sc *ServerConn) receipt(f *Frame) error {
+	id, ok := f.Header["receipt"]
+	if !ok {
+		return nil
+	}
+	return sc.c.writeFrame(&Frame{Command: CommandReceipt, Header: Header{"receipt-id": id}})
+}
+
+func (sc *ServerConn) isClosed() bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.closed
+}
+
+// WriteFrame sends f to the client.
+func (sc *ServerConn) WriteFrame(f *Frame) error {
+	if sc.isClosed() {
+		return ErrClosed
+	}
+	return sc.c.writeFrame(f)
+}
+
+// SendMessage sends a MESSAGE frame for the subscription with the ID subID.
+// The header must contain the destination and message-id headers and the
+// ack header if the subscription is not in AckAuto mode.
+func (sc *ServerConn) SendMessage(subID string, body []byte, header Header) error {
+	f := &Frame{Command: CommandMessage, Header: Header{}, Body: body}
+	for k, v := range header {
+		f.Header[k] = v
+	}
+	f.Header["subscription"] = subID
+	return sc.WriteFrame(f)
+}
+
+// Error sends an ERROR frame with the error text to the client and closes
+// the connection.
+func (sc *ServerConn) Error(err error) error {
+	sc.mu.Lock()
+	if sc.closed {
+		sc.mu.Unlock()
+		return ErrClosed
+	}
+	sc.closed = true
+	sc.mu.Unlock()
+	sc.fail(err, nil)
+	return nil
+}
+
+// Close sends a WebSocket close message to the client, closes the
+// connection and stops Run.
+func (sc *ServerConn) Close() error {
+	sc.mu.Lock()
+	if sc.closed {
+		sc.mu.Unlock()
+		return nil
+	}
+	sc.closed = true
+	sc.mu.Unlock()
+	return lifecycle.Close(sc.c.ws, websocket.CloseNormalClosure, "")
+}
@@ -0,0 +1,164 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package stomp implements the STOMP 1.2 protocol over WebSocket
+// connections.
+//
+// Connect performs the STOMP handshake on the client side of a connection
+// and returns a Client. Accept performs the handshake on the server side of
+// a connection and returns a ServerConn. Dial and upgrade the connection with
+// the Subprotocol:
+//
+//	d := websocket.Dialer{Subprotocols: []string{stomp.Subprotocol}}
+//	ws, _, err := d.DialContext(ctx, "wss://broker.example.com/stomp", nil)
+//	if err != nil {
+//		return err
+//	}
+//	c, err := stomp.Connect(ctx, ws, &stomp.ClientOptions{Host: "/"})
+//	if err != nil {
+//		return err
+//	}
+//	go c.Run(ctx)
+//	err = c.Send("/queue/a", []byte("hello"), stomp.Header{"content-type": "text/plain"})
+//
+// Each STOMP frame is sent as one WebSocket message. Frames with a valid
+// UTF-8 body are sent as text messages and other frames are sent as binary
+// messages.
+//
+// # Heart-beats
+//
+// The heart-beat intervals are negotiated in the handshake as specified by
+// STOMP. While Run executes, the endpoint sends an end-of-line heart-beat
+// when no other frame was sent in the send interval. The endpoint also sends
+// a WebSocket ping when no data was received in the receive interval; a pong
+// from the peer counts as received data, so a peer that is slow to send
+// heart-beats but answers pings is not disconnected. Run fails when nothing
+// is received for twice the receive interval.
+package stomp
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Subprotocol is the WebSocket subprotocol name for STOMP 1.2.
+const Subprotocol = "v12.stomp"
+
+// Frame commands.
+const (
+	CommandConnect     = "CONNECT"
+	CommandStomp       = "STOMP"
+	CommandConnected   = "CONNECTED"
+	CommandSend        = "SEND"
+	CommandSubscribe   = "SUBSCRIBE"
+	CommandUnsubscribe = "UNSUBSCRIBE"
+	CommandAck         = "ACK"
+	CommandNack        = "NACK"
+	CommandBegin       = "BEGIN"
+	CommandCommit      = "COMMIT"
+	CommandAbort       = "ABORT"
+	CommandDisconnect  = "DISCONNECT"
+	CommandMessage     = "MESSAGE"
+	CommandReceipt     = "RECEIPT"
+	CommandError       = "ERROR"
+)
+
+// AckMode is the acknowledgment mode of a subscription.
+type AckMode string
+
+// Acknowledgment modes.
+const (
+	AckAuto             AckMode = "auto"
+	AckClient           AckMode = "client"
+	AckClientIndividual AckMode = "client-individual"
+)
+
+var (
+	// ErrInvalidFrame is returned when a received message is not a valid
+	// STOMP frame.
+	ErrInvalidFrame = errors.New("stomp: invalid frame")
+
+	// ErrClosed is returned from Client and ServerConn methods after the
+	// connection is closed.
+	ErrClosed = errors.New("stomp: connection closed")
+)
+
+// Header is the header of a frame. When a received frame has repeated
+// header entries, the first entry is used.
+type Header map[string]string
+
+// Frame is a STOMP frame.
+type Frame struct {
+	Command string
+	Header  Header
+	Body    []byte
+}
+
+// Error is an ERROR frame received from the server.
+type Error struct {
+	Frame *Frame
+}
+
+func (e *Error) Error() string {
+	if m := e.Frame.Header["message"]; m != "" {
+		return "stomp: server error: " + m
+	}
+	return "stomp: server error"
+}
+
+// HeartBeat specifies heart-beat intervals. A zero interval disables
+// heart-beats in that direction.
+type HeartBeat struct {
+	// Send is the smallest interval at which the endpoint can send
+	// heart-beats.
+	Send time.Duration
+
+	// Receive is the desired interval for receiving heart-beats.
+	Receive time.Duration
+}
+
+func (hb HeartBeat) String() string {
+	return strconv.FormatInt(hb.Send.Milliseconds(), 10) + "," + strconv.FormatInt(hb.Receive.Milliseconds(), 10)
+}
+
+// parseHeartBeat parses the value of a heart-beat header. A missing header
+// is the same as "0,0".
+func parseHeartBeat(s string) (HeartBeat, error) {
+	if s == "" {
+		return HeartBeat{}, nil
+	}
+	sx, sy, ok := strings.Cut(s, ",")
+	x, err1 := strconv.ParseUint(sx, 10, 32)
+	y, err2 := strconv.ParseUint(sy, 10, 32)
+	if !ok || err1 != nil || err2 != nil {
+		return HeartBeat{}, errors.New("stomp: invalid heart-beat header " + strconv.Quote(s))
+	}
+	return HeartBeat{Send: time.Duration(x) * time.Millisecond, Receive: time.Duration(y) * time.Millisecond}, nil
+}
+
+// negotiate returns the intervals used by an endpoint with the local
+// heart-beat settings when the peer sent the remote settings.
+func negotiate(local, remote HeartBeat) HeartBeat {
+	var hb HeartBeat
+	if local.Send > 0 && remote.Receive > 0 {
+		hb.Send = max(local.Send, remote.Receive)
+	}
+	if local.Receive > 0 && remote.Send > 0 {
+		hb.Receive = max(local.Receive, remote.Send)
+	}
+	return hb
+}
+
+// sortedKeys returns the keys of h in sorted order.
+func sortedKeys(h Header) []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,215 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package stomptest provides an in-process STOMP broker for testing STOMP
+// clients.
+//
+// The broker delivers each SEND frame to the current subscribers of the
+// destination. It does not queue messages for destinations without
+// subscribers, implement transactions or redeliver messages. Serve the
+// broker with wstest.NewServer to test without a network:
+//
+//	b := &stomptest.Broker{}
+//	s := wstest.NewServer(b)
+//	defer s.Close()
+//	d := s.Dialer(&websocket.Dialer{Subprotocols: []string{stomp.Subprotocol}})
+package stomptest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/stomp"
+)
+
+// Broker is a STOMP broker. The zero value is a broker that accepts all
+// clients.
+type Broker struct {
+	// Options specifies the server options for the connections. The
+	// Handler field is ignored.
+	Options stomp.ServerOptions
+
+	once     sync.Once
+	upgrader websocket.Upgrader
+
+	mu    sync.Mutex
+	seq   uint64
+	subs  map[string]map[*subscription]struct{} // by destination
+	conns map[*stomp.ServerConn]map[string]*subscription
+	acks  []string
+	nacks []string
+}
+
+type subscription struct {
+	conn        *stomp.ServerConn
+	id          string
+	destination string
+	ack         stomp.AckMode
+}
+
+func (b *Broker) init() {
+	b.once.Do(func() {
+		b.upgrader.Subprotocols = []string{stomp.Subprotocol}
+		b.subs = make(map[string]map[*subscription]struct{})
+		b.conns = make(map[*stomp.ServerConn]map[string]*subscription)
+	})
+}
+
+// ServeHTTP upgrades the request to the WebSocket protocol and serves the
+// STOMP connection.
+func (b *Broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.init()
+	ws, err := b.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	opts := b.Options
+	opts.Handler = b.handle
+	sc, err := stomp.Accept(r.Context(), ws, &opts)
+	if err != nil {
+		return
+	}
+	b.mu.Lock()
+	b.conns[sc] = make(map[string]*subscription)
+	b.mu.Unlock()
+	defer b.remove(sc)
+	_ = sc.Run(context.Background())
+}
+
+func (b *Broker) remove(sc *stomp.ServerConn) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, sub := range b.conns[sc] {
+		b.unsubscribeLocked(sub)
+	}
+	delete(b.conns, sc)
+}
+
+func (b *Broker) unsubscribeLocked(sub *subscription) {
+	delete(b.conns[sub.conn], sub.id)
+	if m := b.subs[sub.destination]; m != nil {
+		delete(m, sub)
+		if len(m) == 0 {
+			delete(b.subs, sub.destination)
+		}
+	}
+}
+
+func (b *Broker) handle(sc *stomp.ServerConn, f *stomp.Frame) error {
+	switch f.Command {
+	case stomp.CommandSend:
+		destination := f.Header["destination"]
+		if destination == "" {
+			return errors.New("missing destination header")
+		}
+		return b.publish(destination, f)
+	case stomp.CommandSubscribe:
+		sub := &subscription{
+			conn:        sc,
+			id:          f.Header["id"],
+			destination: f.Header["destination"],
+			ack:         stomp.AckMode(f.Header["ack"]),
+		}
+		if sub.id == "" || sub.destination == "" {
+			return errors.New("missing id or destination header")
+		}
+		if sub.ack == "" {
+			sub.ack = stomp.AckAuto
+		}
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.conns[sc][sub.id]; ok {
+			return errors.New("duplicate subscription id " + sub.id)
+		}
+		b.conns[sc][sub.id] = sub
+		m := b.subs[sub.destination]
+		if m == nil {
+			m = make(map[*subscription]struct{})
+			b.subs[sub.destination] = m
+		}
+		m[sub] = struct{}{}
+	case stomp.CommandUnsubscribe:
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		sub, ok := b.conns[sc][f.Header["id"]]
+		if !ok {
+			return errors.New("unknown subscription id " + f.Header["id"])
+		}
+		b.unsubscribeLocked(sub)
+	case stomp.CommandAck, stomp.CommandNack:
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if f.Command == stomp.CommandAck {
+			b.acks = append(b.acks, f.Header["id"])
+		} else {
+			b.nacks = append(b.nacks, f.Header["id"])
+		}
+	case stomp.CommandBegin, stomp.CommandCommit, stomp.CommandAbort:
+		return errors.New("transactions are not supported")
+	default:
+		return errors.New("unexpected " + f.Command + " frame")
+	}
+	return nil
+}
+
+// Publish sends a message to the subscribers of the destination as if a
+// client sent a SEND frame with the header and body.
+func (b *Broker) Publish(destination string, body []byte, header stomp.Header) error {
+	b.init()
+	return b.publish(destination, &stomp.Frame{Command: stomp.CommandSend, Header: header, Body: body})
+}
+
+func (b *Broker) publish(destination string, f *stomp.Frame) error {
+	b.mu.Lock()
+	b.seq++
+	id := "message-" + strconv.FormatUint(b.seq, 10)
+	subs := make([]*subscription, 0, len(b.subs[destination]))
+	for sub := range b.subs[destination] {
+		subs = append(subs, sub)
+	}
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		h := stomp.Header{}
+		for k, v := range f.Header {
+			if k != "receipt" && k != "transaction" {
+				h[k] = v
+			}
+		}
+		h["destination"] = destination
+		h["message-id"] = id
+		if sub.ack != stomp.AckAuto {
+			h["ack"] = id
+		}
+		// Ignore errors from connections that are closing.
+		_ = sub.conn.SendMessage(sub.id, f.Body, h)
+	}
+	return nil
+}
+
+// Subscribers returns the number of subscriptions to the destination.
+func (b *Broker) Subscribers(destination string) int {
+	b.init()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs[destination])
+}
+
+// Acks returns the IDs of the ACK frames received by the broker.
+func (b *Broker) Acks() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.acks...)
+}
+
+// Nacks returns the IDs of the NACK frames received by the broker.
+func (b *Broker) Nacks() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.nacks...)
+}
@@ -0,0 +1,161 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stomptest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/stomp"
+	"github.com/gorilla/websocket/wstest"
+)
+
+func connect(t *testing.T, b *Broker, opts *stomp.ClientOptions) (*stomp.Client, <-chan error) {
+	t.Helper()
+	s := wstest.NewServer(b)
+	t.Cleanup(func() { s.Close() })
+	d := s.Dialer(&websocket.Dialer{Subprotocols: []string{stomp.Subprotocol}})
+	ws, _, err := d.Dial(wstest.DefaultURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Subprotocol() != stomp.Subprotocol {
+		t.Errorf("Subprotocol() = %q, want %q", ws.Subprotocol(), stomp.Subprotocol)
+	}
+	// The connection must outlive the handshake context.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	c, err := stomp.Connect(ctx, ws, opts)
+	cancel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- c.Run(context.Background()) }()
+	t.Cleanup(func() { c.Close() })
+	return c, errc
+}
+
+func receive(t *testing.T, ch <-chan *stomp.Message) *stomp.Message {
+	t.Helper()
+	select {
+	case m := <-ch:
+		return m
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for message")
+		return nil
+	}
+}
+
+func TestSendSubscribe(t *testing.T) {
+	b := &Broker{Options: stomp.ServerOptions{Server: "stomptest"}}
+	ctx := context.Background()
+	producer, _ := connect(t, b, nil)
+	consumer, _ := connect(t, b, nil)
+	if s := consumer.Connected().Header["server"]; s != "stomptest" {
+		t.Errorf("server header = %q, want stomptest", s)
+	}
+
+	messages := make(chan *stomp.Message, 10)
+	sub, err := consumer.Subscribe(ctx, "/queue/a", stomp.AckClientIndividual, func(m *stomp.Message) { messages <- m })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := b.Subscribers("/queue/a"); n != 1 {
+		t.Errorf("Subscribers() = %d, want 1", n)
+	}
+
+	header := stomp.Header{"content-type": "text/plain", "x-key": "a:b\nc"}
+	if err := producer.Send("/queue/a", []byte("one"), header); err != nil {
+		t.Fatal(err)
+	}
+	m := receive(t, messages)
+	if m.Destination != "/queue/a" || m.Subscription != sub.ID() || string(m.Body) != "one" || m.Header["x-key"] != "a:b\nc" {
+		t.Errorf("message = %+v", m)
+	}
+	if err := consumer.Ack(m); err != nil {
+		t.Fatal(err)
+	}
+
+	// Binary bodies are sent in binary messages.
+	if err := b.Publish("/queue/a", []byte{0, 0xff}, nil); err != nil {
+		t.Fatal(err)
+	}
+	m = receive(t, messages)
+	if !reflect.DeepEqual(m.Body, []byte{0, 0xff}) {
+		t.Errorf("body = %x, want 00ff", m.Body)
+	}
+	if err := consumer.Nack(m); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sub.Unsubscribe(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := b.Subscribers("/queue/a"); n != 0 {
+		t.Errorf("Subscribers() = %d after Unsubscribe, want 0", n)
+	}
+	// The receipt for the unsubscribe follows the ACK and NACK frames.
+	if acks, nacks := b.Acks(), b.Nacks(); len(acks) != 1 || len(nacks) != 1 {
+		t.Errorf("Acks() = %v, Nacks() = %v, want one of each", acks, nacks)
+	}
+}
+
+func TestDisconnect(t *testing.T) {
+	b := &Broker{}
+	c, errc := connect(t, b, nil)
+	if err := c.Disconnect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("Run() returned %v after Disconnect, want nil", err)
+	}
+	if err := c.Send("/queue/a", nil, nil); err != stomp.ErrClosed {
+		t.Errorf("Send() returned %v, want %v", err, stomp.ErrClosed)
+	}
+}
+
+func TestServerError(t *testing.T) {
+	b := &Broker{}
+	c, errc := connect(t, b, nil)
+	err := c.WriteFrameReceipt(context.Background(), &stomp.Frame{Command: stomp.CommandBegin, Header: stomp.Header{"transaction": "tx1"}})
+	if err != stomp.ErrClosed {
+		t.Errorf("WriteFrameReceipt() returned %v, want %v", err, stomp.ErrClosed)
+	}
+	var e *stomp.Error
+	if err := <-errc; !errors.As(err, &e) || e.Frame.Header["message"] != "transactions are not supported" {
+		t.Errorf("Run() returned %v, want server error", err)
+	}
+	if e != nil && e.Frame.Header["receipt-id"] == "" {
+		t.Error("ERROR frame has no receipt-id header")
+	}
+}
+
+func TestHeartBeat(t *testing.T) {
+	hb := stomp.HeartBeat{Send: 50 * time.Millisecond, Receive: 50 * time.Millisecond}
+	b := &Broker{Options: stomp.ServerOptions{HeartBeat: hb}}
+	c, errc := connect(t, b, &stomp.ClientOptions{HeartBeat: hb})
+	if got := c.HeartBeat(); got != hb {
+		t.Errorf("HeartBeat() = %v, want %v", got, hb)
+	}
+	select {
+	case err := <-errc:
+		t.Fatalf("Run() returned %v", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+	messages := make(chan *stomp.Message, 1)
+	if _, err := c.Subscribe(context.Background(), "/topic/t", "", func(m *stomp.Message) { messages <- m }); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Send("/topic/t", []byte("alive"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if m := receive(t, messages); string(m.Body) != "alive" || m.Header["ack"] != "" {
+		t.Errorf("message = %+v", m)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// ConnTrace is a set of hooks to run at stages of the WebSocket lifecycle.
+// Any particular hook may be nil.
+//
+// Attach a trace to the handshake context with WithConnTrace or set the
+// ConnTrace field of Upgrader or Dialer. For servers, the handshake context
+// is the request context. For clients, the handshake context is the context
+// passed to DialContext.
+//
+// The hooks for frames and messages are called from the goroutines reading
+// and writing the connection. Hooks may be called concurrently and should
+// return quickly.
+type ConnTrace struct {
+	// HandshakeStart is called when the opening handshake starts. For
+	// servers, r is the handshake request received from the client. For
+	// clients, r is the handshake request to be sent to the server. The
+	// hook is called for each request when the Dialer follows redirects.
+	HandshakeStart func(r *http.Request)
+
+	// HandshakeDone is called when the opening handshake completes or
+	// fails.
+	HandshakeDone func(info HandshakeDoneInfo)
+
+	// FrameRead is called when the header of a frame is read. The size is
+	// the size of the frame on the network, including the header.
+	FrameRead func(frameType int, size int64)
+
+	// FrameWritten is called after a frame is written to the network. The
+	// size is the size of the frame on the network, including the header.
+	FrameWritten func(frameType int, size int64)
+
+	// MessageStart is called when the first frame of a text or binary
+	// message is read from the network or when the application starts
+	// writing a message.
+	MessageStart func(dir FrameDirection, messageType int)
+
+	// MessageEnd is called when the application reads the last byte of a
+	// text or binary message or advances to the next message, or after the
+	// last frame of a message is written to the network.
+	MessageEnd func(dir FrameDirection, m MessageStats)
+
+	// PingSent is called after a ping is written to the network.
+	PingSent func(data []byte)
+
+	// PongReceived is called when a pong is received from the peer. If the
+	// pong payload matches the payload of the last ping sent, rtt is the
+	// time since that ping was written to the network. Otherwise, rtt is
+	// zero.
+	PongReceived func(data []byte, rtt time.Duration)
+
+	// CloseSent is called after a close frame is written to the network.
+	// The code is CloseNoStatusReceived if the close frame does not have a
+	// payload.
+	CloseSent func(code int, text string)
+
+	// CloseReceived is called when a valid close frame is received from the
+	// peer. The code is CloseNoStatusReceived if the close frame does not
+	// have a payload.
+	CloseReceived func(code int, text string)
+
+	// ReadError is called with the first error returned to the application
+	// from a read method. Errors include *CloseError when the peer closes
+	// the connection.
+	ReadError func(err error)
+
+	// WriteError is called with the first error that fails writes to the
+	// connection. The hook is not called when writes fail because a close
+	// frame was sent.
+	WriteError func(err error)
+}
+
+// HandshakeDoneInfo is the argument to ConnTrace.HandshakeDone.
+type HandshakeDoneInfo struct {
+	// Conn is the connection or nil if the handshake failed.
+	Conn *Conn
+
+	// Response is the handshake response received from the server. The
+	// field is nil for servers.
+	Response *http.Response
+
+	// Err is the error that caused the handshake to fail.
+	Err error
+}
+
+type connTraceKey struct{}
+
+// ContextConnTrace returns the ConnTrace associated with ctx. If none, it
+// returns nil.
+func ContextConnTrace(ctx context.Context) *ConnTrace {
+	trace, _ := ctx.Value(connTraceKey{}).(*ConnTrace)
+	return trace
+}
+
+// WithConnTrace returns a new context based on ctx with trace attached. If
+// ctx already has a trace, the hooks of both traces are called with the
+// hooks of trace called first.
+func WithConnTrace(ctx context.Context, trace *ConnTrace) context.Context {
+	if trace == nil {
+		panic("nil trace")
+	}
+	return context.WithValue(ctx, connTraceKey{}, composeConnTrace(trace, ContextConnTrace(ctx)))
+}
+
+// composeConnTrace returns a trace that calls the hooks of t and then the
+// hooks of old. Either trace may be nil.
+func composeConnTrace(t, old *ConnTrace) *ConnTrace {
+	if t == nil {
+		return old
+	}
+	if old == nil {
+		return t
+	}
+	c := *t
+	tv := reflect.ValueOf(&c).Elem()
+	ov := reflect.ValueOf(old).Elem()
+	for i := 0; i < tv.NumField(); i++ {
+		hook := tv.Field(i)
+		oldHook := ov.Field(i)
+		if oldHook.IsNil() {
+			continue
+		}
+		if hook.IsNil() {
+			hook.Set(oldHook)
+			continue
+		}
+		tfCopy := reflect.ValueOf(hook.Interface())
+		hook.Set(reflect.MakeFunc(hook.Type(), func(args []reflect.Value) []reflect.Value {
+			tfCopy.Call(args)
+			return oldHook.Call(args)
+		}))
+	}
+	return &c
+}
+
+// pingSent records a ping written to the network.
+func (c *Conn) pingSent(data []byte) {
+	c.pingMu.Lock()
+	c.pingData = string(data)
+	c.pingTime = time.Now()
+	c.pingMu.Unlock()
+	if c.trace.PingSent != nil {
+		c.trace.PingSent(data)
+	}
+}
+
+// pongReceived calls the PongReceived hook with the round trip time of the
+// matching ping.
+func (c *Conn) pongReceived(data []byte) {
+	var rtt time.Duration
+	c.pingMu.Lock()
+	if !c.pingTime.IsZero() && c.pingData == string(data) {
+		rtt = time.Since(c.pingTime)
+		c.pingTime = time.Time{}
+	}
+	c.pingMu.Unlock()
+	c.trace.PongReceived(data, rtt)
+}
+
+// readError calls the ReadError hook with the first read error.
+func (c *Conn) readError(err error) {
+	if c.readErrTraced || c.trace.ReadError == nil {
+		return
+	}
+	c.readErrTraced = true
+	c.trace.ReadError(err)
+}
@@ -0,0 +1,189 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type traceRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *traceRecorder) add(format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, fmt.Sprintf(format, args...))
+}
+
+func (r *traceRecorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func (r *traceRecorder) trace(prefix string) *ConnTrace {
+	return &ConnTrace{
+		HandshakeStart: func(req *http.Request) { r.add("%sHandshakeStart", prefix) },
+		HandshakeDone: func(info HandshakeDoneInfo) {
+			r.add("%sHandshakeDone %v %v", prefix, info.Conn != nil, info.Err != nil)
+		},
+		FrameRead:     func(frameType int, size int64) { r.add("%sFrameRead %d", prefix, frameType) },
+		FrameWritten:  func(frameType int, size int64) { r.add("%sFrameWritten %d", prefix, frameType) },
+		MessageStart:  func(dir FrameDirection, messageType int) { r.add("%sMessageStart %v %d", prefix, dir, messageType) },
+		MessageEnd:    func(dir FrameDirection, m MessageStats) { r.add("%sMessageEnd %v %d", prefix, dir, m.AppBytes) },
+		PingSent:      func(data []byte) { r.add("%sPingSent %s", prefix, data) },
+		PongReceived:  func(data []byte, rtt time.Duration) { r.add("%sPongReceived %s %v", prefix, data, rtt > 0) },
+		CloseSent:     func(code int, text string) { r.add("%sCloseSent %d %s", prefix, code, text) },
+		CloseReceived: func(code int, text string) { r.add("%sCloseReceived %d %s", prefix, code, text) },
+		ReadError:     func(err error) { r.add("%sReadError %v", prefix, err) },
+		WriteError:    func(err error) { r.add("%sWriteError %v", prefix, err) },
+	}
+}
+
+func TestConnTrace(t *testing.T) {
+	var sr, cr traceRecorder
+	var b1, b2 bytes.Buffer
+	client := newTestConn(&b2, &b1, false)
+	server := newTestConn(&b1, &b2, true)
+	server.trace = sr.trace("")
+	client.trace = cr.trace("")
+
+	_ = client.WriteMessage(TextMessage, []byte("hello"))
+	_ = client.WriteControl(PingMessage, []byte("p"), time.Time{})
+	mt, p, _ := server.ReadMessage()
+	_ = server.WriteMessage(mt, p)
+	_, _, _ = client.ReadMessage()
+	_ = client.WriteMessage(CloseMessage, FormatCloseMessage(CloseNormalClosure, "bye"))
+	_, _, _ = server.ReadMessage()
+	_, _, _ = client.ReadMessage()
+
+	wantClient := []string{
+		"MessageStart sent 1",
+		"FrameWritten 1",
+		"MessageEnd sent 5",
+		"FrameWritten 9",
+		"PingSent p",
+		"FrameRead 1",
+		"MessageStart received 1",
+		"MessageEnd received 5",
+		"FrameWritten 8",
+		"CloseSent 1000 bye",
+		"FrameRead 10",
+		"PongReceived p true",
+		"FrameRead 8",
+		"CloseReceived 1000 ",
+		"ReadError websocket: close 1000 (normal)",
+	}
+	if got := cr.get(); !reflect.DeepEqual(got, wantClient) {
+		t.Errorf("client events\ngot:  %q\nwant: %q", got, wantClient)
+	}
+
+	wantServer := []string{
+		"FrameRead 1",
+		"MessageStart received 1",
+		"MessageEnd received 5",
+		"MessageStart sent 1",
+		"FrameWritten 1",
+		"MessageEnd sent 5",
+		"FrameRead 9",
+		"FrameWritten 10",
+		"FrameRead 8",
+		"CloseReceived 1000 bye",
+		"FrameWritten 8",
+		"CloseSent 1000 ",
+		"ReadError websocket: close 1000 (normal): bye",
+	}
+	if got := sr.get(); !reflect.DeepEqual(got, wantServer) {
+		t.Errorf("server events\ngot:  %q\nwant: %q", got, wantServer)
+	}
+}
+
+func TestConnTraceWriteError(t *testing.T) {
+	var r traceRecorder
+	server, client := newPipeConns()
+	server.trace = r.trace("")
+	client.Close()
+
+	_ = server.WriteMessage(TextMessage, []byte("hello"))
+	_ = server.WriteMessage(TextMessage, []byte("hello"))
+	events := r.get()
+	if len(events) != 2 || !strings.HasPrefix(events[1], "WriteError ") {
+		t.Errorf("events = %q, want MessageStart and one WriteError", events)
+	}
+}
+
+func TestConnTraceHandshake(t *testing.T) {
+	var r traceRecorder
+	upgrader := Upgrader{ConnTrace: r.trace("upgrader ")}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := WithConnTrace(req.Context(), r.trace("request "))
+		c, err := upgrader.Upgrade(w, req.WithContext(ctx), nil)
+		if err != nil {
+			return
+		}
+		c.Close()
+	}))
+	defer s.Close()
+
+	d := Dialer{ConnTrace: r.trace("dialer ")}
+	ctx := WithConnTrace(context.Background(), r.trace("context "))
+	ctx = WithConnTrace(ctx, r.trace("inner "))
+	c, _, err := d.DialContext(ctx, makeWsProto(s.URL), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, _ = c.ReadMessage()
+	c.Close()
+
+	want := []string{
+		"dialer HandshakeStart",
+		"inner HandshakeStart",
+		"context HandshakeStart",
+		"upgrader HandshakeStart",
+		"request HandshakeStart",
+		"upgrader HandshakeDone true false",
+		"request HandshakeDone true false",
+		"dialer HandshakeDone true false",
+		"inner HandshakeDone true false",
+		"context HandshakeDone true false",
+	}
+	got := r.get()
+	if len(got) < len(want) || !reflect.DeepEqual(got[:len(want)], want) {
+		t.Errorf("events\ngot:  %q\nwant: %q", got, want)
+	}
+
+	// Handshake failure.
+	r = traceRecorder{}
+	_, _, err = d.DialContext(ctx, makeWsProto(s.URL), http.Header{"Origin": {"http://other.example"}})
+	if err == nil {
+		t.Fatal("DialContext() succeeded, want error")
+	}
+	want = []string{
+		"dialer HandshakeStart",
+		"inner HandshakeStart",
+		"context HandshakeStart",
+		"upgrader HandshakeStart",
+		"request HandshakeStart",
+		"upgrader HandshakeDone false true",
+		"request HandshakeDone false true",
+		"dialer HandshakeDone false true",
+		"inner HandshakeDone false true",
+		"context HandshakeDone false true",
+	}
+	if got := r.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("events\ngot:  %q\nwant: %q", got, want)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package transcript
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/internal/handshake"
+	"github.com/gorilla/websocket/internal/wsframe"
+	"github.com/gorilla/websocket/wstest"
+)
+
+// ReplayOptions specifies options for replaying a transcript.
+type ReplayOptions struct {
+	// URL is the URL of the handshake request sent by ReplayServer. If
+	// empty, "ws://replay/" is used.
+	URL string
+
+	// Header specifies additional headers for the handshake request sent by
+	// ReplayServer.
+	Header http.Header
+
+	// Speed scales the recorded delays between frames. A speed of 1 replays
+	// the frames with the recorded timing and a speed of 2 replays the frames
+	// twice as fast. If zero, frames are sent without delay.
+	Speed float64
+
+	// Wait specifies how long to wait for the peer to close the connection
+	// after the last frame is sent. If zero, one second is used.
+	Wait time.Duration
+}
+
+func (o *ReplayOptions) wait() time.Duration {
+	if o.Wait > 0 {
+		return o.Wait
+	}
+	return time.Second
+}
+
+// ReplayServer replays the client frames in t to the WebSocket server
+// implemented by h and returns the frames sent by the server. The Offset of
+// the returned frames is the time since the end of the handshake.
+//
+// ReplayServer sends a handshake request to h over an in-memory connection.
+// The request offers compression if the transcript contains compressed
+// frames. The client frames are then written to the connection without
+// modification. ReplayServer stops sending frames if a write fails and
+// returns after the server closes the connection or after the Wait duration
+// in opts.
+//
+// ReplayServer returns an error if the handshake fails.
+func ReplayServer(t *Transcript, h http.Handler, opts *ReplayOptions) ([]Frame, error) {
+	if opts == nil {
+		opts = &ReplayOptions{}
+	}
+	s := wstest.NewServer(h)
+	defer s.Close()
+
+	conn, err := s.DialContext(context.Background(), "", "")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	br, err := clientHandshake(conn, t, opts)
+	if err != nil {
+		return nil, err
+	}
+	return replay(conn, br, t.ClientFrames(), opts), nil
+}
+
+// clientHandshake performs the client side of the opening handshake for
+// replaying t.
+func clientHandshake(conn net.Conn, t *Transcript, opts *ReplayOptions) (*bufio.Reader, error) {
+	rawURL := opts.URL
+	if rawURL == "" {
+		rawURL = "ws://replay/"
+	}
+	_, br, err := handshake.Client(conn, rawURL, opts.Header, t.compressed())
+	if err != nil {
+		return nil, fmt.Errorf("transcript: %w", err)
+	}
+	return br, nil
+}
+
+// ReplayHandler returns a handler that replays the server frames in t to
+// the client. The handler upgrades the request, selecting the first
+// subprotocol requested by the client and accepting compression. The server
+// frames are then written to the connection without modification. Frames
+// received from the client are discarded. The handler closes the connection
+// after the client closes the connection or after the Wait duration in
+// opts.
+func ReplayHandler(t *Transcript, opts *ReplayOptions) http.Handler {
+	if opts == nil {
+		opts = &ReplayOptions{}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{
+			Subprotocols:      websocket.Subprotocols(r),
+			EnableCompression: true,
+			CheckOrigin:       func(r *http.Request) bool { return true },
+		}
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		conn := c.NetConn()
+		defer conn.Close()
+		replay(conn, bufio.NewReader(conn), t.ServerFrames(), opts)
+	})
+}
+
+// replay writes frames to conn and returns the frames read from br.
+func replay(conn net.Conn, br *bufio.Reader, frames []Frame, opts *ReplayOptions) []Frame {
+	start := time.Now()
+
+	var (
+		mu       sync.Mutex
+		received []Frame
+	)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s := &wsframe.Splitter{Record: func(frame []byte) {
+			mu.Lock()
+			received = append(received, Frame{
+				Offset:    time.Since(start),
+				Direction: websocket.FrameReceived,
+				Data:      append([]byte(nil), frame...),
+			})
+			mu.Unlock()
+		}}
+		_, _ = io.Copy(s, br)
+	}()
+
+	var prev time.Duration
+	for i, f := range frames {
+		if opts.Speed > 0 && i > 0 {
+			d := time.Duration(float64(f.Offset-prev) / opts.Speed)
+			select {
+			case <-time.After(d):
+			case <-done:
+			}
+		}
+		prev = f.Offset
+		if _, err := conn.Write(f.Data); err != nil {
+			break
+		}
+	}
+
+	timer := time.NewTimer(opts.wait())
+	select {
+	case <-done:
+		timer.Stop()
+	case <-timer.C:
+	}
+	conn.Close()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	return received
+}
@@ -0,0 +1,111 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package transcript
+
+import (
+	"encoding/binary"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/wstest"
+)
+
+func TestReplayServer(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		messages := []string{"hello", "world"}
+		tr := recordServer(t, compress, messages)
+		if compress && !tr.compressed() {
+			t.Fatal("transcript is not compressed")
+		}
+
+		h := &echoHandler{upgrader: websocket.Upgrader{EnableCompression: true}}
+		frames, err := ReplayServer(tr, h, &ReplayOptions{Speed: 1})
+		if err != nil {
+			t.Fatalf("compress=%v: ReplayServer() returned %v", compress, err)
+		}
+		want := tr.ServerFrames()
+		if len(frames) != len(want) {
+			t.Fatalf("compress=%v: ReplayServer() returned %d frames, want %d", compress, len(frames), len(want))
+		}
+		for i := range frames {
+			if !reflect.DeepEqual(frames[i].Data, want[i].Data) {
+				t.Errorf("compress=%v: frame %d = %x, want %x", compress, i, frames[i].Data, want[i].Data)
+			}
+		}
+	}
+}
+
+// maskedFrame returns a masked client frame with the given first byte and
+// payload.
+func maskedFrame(b0 byte, payload []byte) []byte {
+	p := []byte{b0, 0x80 | byte(len(payload)), 1, 2, 3, 4}
+	for i, b := range payload {
+		p = append(p, b^p[2+i&3])
+	}
+	return p
+}
+
+func TestReplayServerProtocolError(t *testing.T) {
+	// A transcript with a text message followed by a frame with a reserved
+	// opcode.
+	tr := &Transcript{Frames: []Frame{
+		{Direction: websocket.FrameSent, Data: maskedFrame(0x81, []byte("hello"))},
+		{Direction: websocket.FrameSent, Data: maskedFrame(0x83, []byte("bad"))},
+	}}
+	frames, err := ReplayServer(tr, &echoHandler{}, &ReplayOptions{Wait: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("ReplayServer() returned %v", err)
+	}
+	if len(frames) != 2 {
+		t.Fatalf("ReplayServer() returned %d frames, want 2", len(frames))
+	}
+	if string(frames[0].Payload()) != "hello" {
+		t.Errorf("frame 0 payload = %q, want hello", frames[0].Payload())
+	}
+	if op := frames[1].Opcode(); op != websocket.CloseMessage {
+		t.Fatalf("frame 1 opcode = %d, want %d", op, websocket.CloseMessage)
+	}
+	if code := binary.BigEndian.Uint16(frames[1].Payload()); code != websocket.CloseProtocolError {
+		t.Errorf("close code = %d, want %d", code, websocket.CloseProtocolError)
+	}
+}
+
+func TestReplayServerHandshakeError(t *testing.T) {
+	h := &echoHandler{upgrader: websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return false }}}
+	if _, err := ReplayServer(&Transcript{}, h, nil); err == nil {
+		t.Error("ReplayServer() did not return error")
+	}
+}
+
+func TestReplayHandler(t *testing.T) {
+	messages := []string{"hello", "world"}
+	tr := recordServer(t, false, messages)
+
+	s := wstest.NewServer(ReplayHandler(tr, nil))
+	defer s.Close()
+	c, _, err := s.Dialer(nil).Dial("ws://example.com/", nil)
+	if err != nil {
+		t.Fatalf("Dial() returned %v", err)
+	}
+	defer c.Close()
+
+	var got []string
+	for {
+		_, p, err := c.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				t.Errorf("ReadMessage() returned %v, want close error", err)
+			}
+			break
+		}
+		got = append(got, string(p))
+	}
+	if !reflect.DeepEqual(got, messages) {
+		t.Errorf("received %q, want %q", got, messages)
+	}
+}
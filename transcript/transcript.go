@@ -0,0 +1,330 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package transcript records WebSocket frames to a compact file format and
+// replays recorded transcripts to reproduce bugs in tests.
+//
+// To record the connections accepted by a server, set the Upgrader's
+// NewFrameRecorder field:
+//
+//	upgrader := websocket.Upgrader{
+//		NewFrameRecorder: transcript.RecordToDir("/var/tmp/ws", true),
+//	}
+//
+// To reproduce a problem, load the transcript and replay the client's frames
+// to the server's handler with ReplayServer, or replay the server's frames to
+// a client with ReplayHandler.
+//
+// # File format
+//
+// A transcript starts with the four byte magic string "GWST", a version byte
+// and a flags byte. Bit 0 of the flags is set if the frames were recorded by
+// the server. Each record that follows has a direction byte (1 for sent, 2
+// for received), the time since the previous record in nanoseconds as an
+// unsigned varint, the frame length as an unsigned varint and the raw
+// frame.
+package transcript
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/internal/wsframe"
+)
+
+const (
+	magic   = "GWST"
+	version = 1
+
+	flagServer = 1 << 0
+
+	// maxFrameSize is the maximum frame size accepted by Read.
+	maxFrameSize = 1 << 30
+)
+
+// ErrFormat is returned when reading a transcript with an invalid format.
+var ErrFormat = errors.New("transcript: invalid format")
+
+// Frame is a raw frame in a transcript.
+type Frame struct {
+	// Offset is the time since the start of the transcript.
+	Offset time.Duration
+
+	// Direction is the direction of the frame from the perspective of the
+	// recorded connection.
+	Direction websocket.FrameDirection
+
+	// Data is the frame as it appeared on the network, including the header.
+	Data []byte
+}
+
+// Fin returns true if the FIN bit is set in the frame header.
+func (f *Frame) Fin() bool {
+	return len(f.Data) > 0 && f.Data[0]&0x80 != 0
+}
+
+// Compressed returns true if the RSV1 bit is set in the frame header.
+func (f *Frame) Compressed() bool {
+	return len(f.Data) > 0 && f.Data[0]&0x40 != 0
+}
+
+// Opcode returns the opcode from the frame header. The opcode of a
+// continuation frame is zero. Other opcodes have the values of the message
+// type constants in the websocket package.
+func (f *Frame) Opcode() int {
+	if len(f.Data) == 0 {
+		return -1
+	}
+	return int(f.Data[0] & 0xf)
+}
+
+// Payload returns the unmasked payload of the frame. Payload returns nil if
+// the frame is truncated.
+func (f *Frame) Payload() []byte {
+	n, hdr := wsframe.Len(f.Data)
+	if n < 0 || n > len(f.Data) {
+		return nil
+	}
+	p := append([]byte(nil), f.Data[hdr:n]...)
+	if f.Data[1]&0x80 != 0 {
+		key := f.Data[hdr-4 : hdr]
+		for i := range p {
+			p[i] ^= key[i&3]
+		}
+	}
+	return p
+}
+
+// Transcript is a sequence of frames recorded on a connection.
+type Transcript struct {
+	// Server is true if the frames were recorded by the server.
+	Server bool
+
+	// Frames are the recorded frames in the order recorded.
+	Frames []Frame
+}
+
+// ClientFrames returns the frames sent by the client.
+func (t *Transcript) ClientFrames() []Frame {
+	dir := websocket.FrameSent
+	if t.Server {
+		dir = websocket.FrameReceived
+	}
+	return t.filter(dir)
+}
+
+// ServerFrames returns the frames sent by the server.
+func (t *Transcript) ServerFrames() []Frame {
+	dir := websocket.FrameReceived
+	if t.Server {
+		dir = websocket.FrameSent
+	}
+	return t.filter(dir)
+}
+
+func (t *Transcript) filter(dir websocket.FrameDirection) []Frame {
+	var frames []Frame
+	for _, f := range t.Frames {
+		if f.Direction == dir {
+			frames = append(frames, f)
+		}
+	}
+	return frames
+}
+
+// compressed returns true if a data frame in the transcript is compressed.
+func (t *Transcript) compressed() bool {
+	for i := range t.Frames {
+		f := &t.Frames[i]
+		if op := f.Opcode(); (op == websocket.TextMessage || op == websocket.BinaryMessage) && f.Compressed() {
+			return true
+		}
+	}
+	return false
+}
+
+// Read reads a transcript from r.
+func Read(r io.Reader) (*Transcript, error) {
+	br := bufio.NewReader(r)
+	var hdr [len(magic) + 2]byte
+	if _, err := io.ReadFull(br, hdr[:]); err != nil {
+		return nil, ErrFormat
+	}
+	if string(hdr[:len(magic)]) != magic || hdr[len(magic)] != version {
+		return nil, ErrFormat
+	}
+	t := &Transcript{Server: hdr[len(magic)+1]&flagServer != 0}
+	var offset time.Duration
+	for {
+		dir, err := br.ReadByte()
+		if err == io.EOF {
+			return t, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if dir != byte(websocket.FrameSent) && dir != byte(websocket.FrameReceived) {
+			return nil, ErrFormat
+		}
+		delta, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, ErrFormat
+		}
+		n, err := binary.ReadUvarint(br)
+		if err != nil || n > maxFrameSize || delta > math.MaxInt64 {
+			return nil, ErrFormat
+		}
+		data := make([]byte, n)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return nil, ErrFormat
+		}
+		offset += time.Duration(delta)
+		t.Frames = append(t.Frames, Frame{Offset: offset, Direction: websocket.FrameDirection(dir), Data: data})
+	}
+}
+
+// ReadFile reads the transcript in the named file.
+func ReadFile(name string) (*Transcript, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// WriteTo writes the transcript to w in the transcript file format.
+func (t *Transcript) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	writeHeader(cw, t.Server)
+	var prev time.Duration
+	for _, f := range t.Frames {
+		writeRecord(cw, f.Direction, f.Offset-prev, f.Data)
+		prev = f.Offset
+	}
+	return cw.n, cw.err
+}
+
+type countWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	w.err = err
+	return n, err
+}
+
+func writeHeader(w io.Writer, server bool) error {
+	hdr := []byte(magic + "\x00\x00")
+	hdr[len(magic)] = version
+	if server {
+		hdr[len(magic)+1] |= flagServer
+	}
+	_, err := w.Write(hdr)
+	return err
+}
+
+func writeRecord(w io.Writer, dir websocket.FrameDirection, delta time.Duration, frame []byte) error {
+	if delta < 0 {
+		delta = 0
+	}
+	buf := make([]byte, 0, 1+2*binary.MaxVarintLen64+len(frame))
+	buf = append(buf, byte(dir))
+	buf = binary.AppendUvarint(buf, uint64(delta))
+	buf = binary.AppendUvarint(buf, uint64(len(frame)))
+	buf = append(buf, frame...)
+	_, err := w.Write(buf)
+	return err
+}
+
+// Recorder writes the frames recorded on a connection to an io.Writer in the
+// transcript file format. Each frame is written with a single call to the
+// writer so that the transcript is complete up to the last frame if the
+// application stops unexpectedly.
+//
+// Recorder implements websocket.FrameRecorder.
+type Recorder struct {
+	mu     sync.Mutex
+	w      io.Writer
+	last   time.Time
+	err    error
+	closed bool
+}
+
+// NewRecorder returns a recorder that writes to w. The server argument
+// specifies whether the recorded connection is a server connection.
+func NewRecorder(w io.Writer, server bool) *Recorder {
+	r := &Recorder{w: w, last: time.Now()}
+	r.err = writeHeader(w, server)
+	return r
+}
+
+// RecordFrame writes the frame to the recorder's writer. RecordFrame
+// discards the frame if the recorder is closed or a previous write failed.
+func (r *Recorder) RecordFrame(dir websocket.FrameDirection, frame []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil || r.closed {
+		return
+	}
+	now := time.Now()
+	r.err = writeRecord(r.w, dir, now.Sub(r.last), frame)
+	r.last = now
+}
+
+// Err returns the first error encountered writing the transcript.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Close stops recording. If the writer implements io.Closer, Close closes
+// the writer. Close is called by the connection's Close method.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if c, ok := r.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+var fileSeq uint64
+
+// RecordToDir returns a function for the NewFrameRecorder field of Upgrader
+// or Dialer. The function records each connection to a new file in dir. The
+// server argument specifies whether the function is used with an Upgrader.
+// If the file cannot be created, the connection is not recorded.
+func RecordToDir(dir string, server bool) func(r *http.Request) websocket.FrameRecorder {
+	return func(r *http.Request) websocket.FrameRecorder {
+		name := fmt.Sprintf("%s-%d.wst", time.Now().UTC().Format("20060102T150405.000000000Z"), atomic.AddUint64(&fileSeq, 1))
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return nil
+		}
+		return NewRecorder(f, server)
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package transcript
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gorilla/websocket/wstest"
+)
+
+// echoHandler echoes messages until the connection is closed.
+type echoHandler struct {
+	upgrader websocket.Upgrader
+}
+
+func (h *echoHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer c.Close()
+	for {
+		mt, p, err := c.ReadMessage()
+		if err != nil {
+			return
+		}
+		if err := c.WriteMessage(mt, p); err != nil {
+			return
+		}
+	}
+}
+
+// runClient sends messages to the server and closes the connection.
+func runClient(t *testing.T, d *websocket.Dialer, messages []string) {
+	t.Helper()
+	c, _, err := d.Dial("ws://example.com/", nil)
+	if err != nil {
+		t.Fatalf("Dial() returned %v", err)
+	}
+	defer c.Close()
+	for _, m := range messages {
+		if err := c.WriteMessage(websocket.TextMessage, []byte(m)); err != nil {
+			t.Fatalf("WriteMessage() returned %v", err)
+		}
+		if _, p, err := c.ReadMessage(); err != nil || string(p) != m {
+			t.Fatalf("ReadMessage() = %q, %v, want %q, nil", p, err, m)
+		}
+	}
+	c.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	for {
+		if _, _, err := c.NextReader(); err != nil {
+			break
+		}
+	}
+}
+
+func payloads(frames []Frame) []string {
+	var s []string
+	for _, f := range frames {
+		s = append(s, string(f.Payload()))
+	}
+	return s
+}
+
+// recordServer records the connection to an echo server.
+func recordServer(t *testing.T, compress bool, messages []string) *Transcript {
+	t.Helper()
+	var buf bytes.Buffer
+	recorded := make(chan struct{})
+	h := &echoHandler{upgrader: websocket.Upgrader{
+		EnableCompression: compress,
+		NewFrameRecorder: func(r *http.Request) websocket.FrameRecorder {
+			return &closeNotifier{NewRecorder(&buf, true), recorded}
+		},
+	}}
+	s := wstest.NewServer(h)
+	defer s.Close()
+	runClient(t, s.Dialer(&websocket.Dialer{EnableCompression: compress}), messages)
+	<-recorded
+
+	tr, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read() returned %v", err)
+	}
+	return tr
+}
+
+// closeNotifier closes a channel when the recorder is closed.
+type closeNotifier struct {
+	*Recorder
+	closed chan struct{}
+}
+
+func (n *closeNotifier) Close() error {
+	err := n.Recorder.Close()
+	close(n.closed)
+	return err
+}
+
+func TestRecord(t *testing.T) {
+	messages := []string{"hello", "world"}
+	tr := recordServer(t, false, messages)
+
+	if !tr.Server {
+		t.Error("Server = false, want true")
+	}
+	closePayload := string(websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	want := append(messages, closePayload)
+	if got := payloads(tr.ClientFrames()); !reflect.DeepEqual(got, want) {
+		t.Errorf("client payloads = %q, want %q", got, want)
+	}
+	if got := payloads(tr.ServerFrames()); !reflect.DeepEqual(got, want) {
+		t.Errorf("server payloads = %q, want %q", got, want)
+	}
+	for i, f := range tr.Frames {
+		if i > 0 && f.Offset < tr.Frames[i-1].Offset {
+			t.Errorf("frame %d: offset %v before previous frame offset %v", i, f.Offset, tr.Frames[i-1].Offset)
+		}
+		if !f.Fin() || f.Compressed() {
+			t.Errorf("frame %d: fin=%v, compressed=%v, want true, false", i, f.Fin(), f.Compressed())
+		}
+	}
+	if op := tr.Frames[len(tr.Frames)-1].Opcode(); op != websocket.CloseMessage {
+		t.Errorf("last frame opcode = %d, want %d", op, websocket.CloseMessage)
+	}
+
+	// Round trip through WriteTo.
+	var buf bytes.Buffer
+	if _, err := tr.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() returned %v", err)
+	}
+	tr2, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read() returned %v", err)
+	}
+	if !reflect.DeepEqual(tr, tr2) {
+		t.Errorf("Read(WriteTo()) = %+v, want %+v", tr2, tr)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	tr := &Transcript{Frames: []Frame{{Direction: websocket.FrameSent, Data: []byte{0x81, 0x00}}}}
+	tr.WriteTo(&buf)
+	valid := buf.Bytes()
+
+	for _, p := range [][]byte{
+		nil,
+		[]byte("GWS"),
+		[]byte("XXXX\x01\x00"),
+		[]byte("GWST\x02\x00"),
+		valid[:len(valid)-1],
+		append(append([]byte(nil), valid[:6]...), 3, 0, 0),
+	} {
+		if _, err := Read(bytes.NewReader(p)); err != ErrFormat {
+			t.Errorf("Read(%q) returned %v, want %v", p, err, ErrFormat)
+		}
+	}
+}
+
+func TestRecordToDir(t *testing.T) {
+	dir := t.TempDir()
+	h := &echoHandler{upgrader: websocket.Upgrader{NewFrameRecorder: RecordToDir(dir, true)}}
+	s := wstest.NewServer(h)
+	defer s.Close()
+	d := s.Dialer(&websocket.Dialer{NewFrameRecorder: RecordToDir(dir, false)})
+	runClient(t, d, []string{"hello"})
+
+	// Wait for the server to close the connection.
+	var names []string
+	for i := 0; i < 100 && len(names) < 2; i++ {
+		names, _ = filepath.Glob(filepath.Join(dir, "*.wst"))
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(names) != 2 {
+		t.Fatalf("found %d transcripts, want 2", len(names))
+	}
+	servers := 0
+	for _, name := range names {
+		tr, err := ReadFile(name)
+		if err != nil {
+			t.Fatalf("ReadFile(%s) returned %v", name, err)
+		}
+		if tr.Server {
+			servers++
+		}
+		if got := payloads(tr.ClientFrames()); len(got) < 1 || got[0] != "hello" {
+			t.Errorf("%s: client payloads = %q, want hello first", name, got)
+		}
+	}
+	if servers != 1 {
+		t.Errorf("found %d server transcripts, want 1", servers)
+	}
+
+	h = &echoHandler{upgrader: websocket.Upgrader{NewFrameRecorder: RecordToDir(filepath.Join(dir, "missing"), true)}}
+	s2 := wstest.NewServer(h)
+	defer s2.Close()
+	runClient(t, s2.Dialer(nil), []string{"hello"})
+	if _, err := os.Stat(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Errorf("Stat() returned %v, want not exist", err)
+	}
+}
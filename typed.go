@@ -0,0 +1,176 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"io"
+	"time"
+)
+
+// DecodeErrorAction specifies how a TypedConn handles a message that cannot
+// be decoded or that fails validation.
+type DecodeErrorAction int
+
+const (
+	// DecodeErrorReturn returns a *DecodeError from Read. The connection
+	// can be read again.
+	DecodeErrorReturn DecodeErrorAction = iota
+
+	// DecodeErrorClose sends a close message with CloseUnsupportedData to
+	// the peer and returns a *DecodeError from Read. The application should
+	// read the connection until the peer's close message is received or
+	// close the connection.
+	DecodeErrorClose
+
+	// DecodeErrorSkip calls the OnDecodeError function, if any, and reads
+	// the next message.
+	DecodeErrorSkip
+)
+
+// DecodeError is returned by TypedConn.Read when a message cannot be decoded
+// or fails validation.
+type DecodeError struct {
+	// MessageType is the type of the message, TextMessage or BinaryMessage.
+	MessageType int
+
+	// Err is the error returned by the codec or the validation function.
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return "websocket: decode message: " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// TypedConnOptions specifies options for a TypedConn.
+type TypedConnOptions[In, Out any] struct {
+	// Codec specifies the codec for encoding and decoding values. If Codec
+	// is nil, the connection's codec is used.
+	Codec Codec
+
+	// ValidateRead specifies a function for validating decoded values. If
+	// the function returns an error, the message is handled as specified by
+	// DecodeErrorAction.
+	ValidateRead func(v In) error
+
+	// ValidateWrite specifies a function for validating values before they
+	// are written. If the function returns an error, Write returns the error
+	// and does not write the value.
+	ValidateWrite func(v Out) error
+
+	// DecodeErrorAction specifies how Read handles a message that cannot be
+	// decoded or that fails validation. The default is DecodeErrorReturn.
+	DecodeErrorAction DecodeErrorAction
+
+	// OnDecodeError specifies a function for reporting messages skipped
+	// with DecodeErrorSkip.
+	OnDecodeError func(err *DecodeError)
+}
+
+// TypedConn reads values of type In from a WebSocket connection and writes
+// values of type Out to the connection.
+//
+// The concurrency rules for the wrapped connection apply: at most one
+// goroutine should call Read at a time and at most one goroutine should call
+// Write at a time.
+type TypedConn[In, Out any] struct {
+	c    *Conn
+	opts TypedConnOptions[In, Out]
+}
+
+// NewTypedConn returns a typed wrapper for c. If opts is nil, the
+// connection's codec is used, values are not validated and Read returns
+// decode errors.
+func NewTypedConn[In, Out any](c *Conn, opts *TypedConnOptions[In, Out]) *TypedConn[In, Out] {
+	t := &TypedConn[In, Out]{c: c}
+	if opts != nil {
+		t.opts = *opts
+	}
+	return t
+}
+
+// Conn returns the WebSocket connection wrapped by t.
+func (t *TypedConn[In, Out]) Conn() *Conn {
+	return t.c
+}
+
+func (t *TypedConn[In, Out]) codec() Codec {
+	if t.opts.Codec != nil {
+		return t.opts.Codec
+	}
+	return t.c.Codec()
+}
+
+// Read reads the next message from the connection and returns the decoded
+// value. If the message cannot be decoded or fails validation, the message is
+// handled as specified by the DecodeErrorAction option. Errors reading the
+// connection are returned as is and are permanent.
+func (t *TypedConn[In, Out]) Read() (In, error) {
+	codec := t.codec()
+	for {
+		var v In
+		messageType, r, err := t.c.NextReader()
+		if err != nil {
+			return v, err
+		}
+		er := &errorReader{r: r}
+		err = codec.Unmarshal(er, &v)
+		if er.err != nil {
+			// The connection failed while reading the message.
+			var zero In
+			return zero, er.err
+		}
+		if err == io.EOF {
+			// One value is expected in the message.
+			err = io.ErrUnexpectedEOF
+		}
+		if err == nil && t.opts.ValidateRead != nil {
+			err = t.opts.ValidateRead(v)
+		}
+		if err == nil {
+			return v, nil
+		}
+
+		de := &DecodeError{MessageType: messageType, Err: err}
+		switch t.opts.DecodeErrorAction {
+		case DecodeErrorSkip:
+			if t.opts.OnDecodeError != nil {
+				t.opts.OnDecodeError(de)
+			}
+			continue
+		case DecodeErrorClose:
+			_ = t.c.WriteControl(CloseMessage, FormatCloseMessage(CloseUnsupportedData, "invalid message"), time.Now().Add(writeWait))
+		}
+		var zero In
+		return zero, de
+	}
+}
+
+// Write validates v and writes the encoding of v as a message.
+func (t *TypedConn[In, Out]) Write(v Out) error {
+	if t.opts.ValidateWrite != nil {
+		if err := t.opts.ValidateWrite(v); err != nil {
+			return err
+		}
+	}
+	return t.c.writeValue(t.codec(), v)
+}
+
+// errorReader records the first error other than io.EOF returned from the
+// wrapped reader.
+type errorReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *errorReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF && r.err == nil {
+		r.err = err
+	}
+	return n, err
+}
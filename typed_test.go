@@ -0,0 +1,112 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+type typedRequest struct {
+	Op  string
+	Arg int
+}
+
+type typedResponse struct {
+	Result int
+}
+
+var errNegativeArg = errors.New("negative argument")
+
+func validateRequest(r typedRequest) error {
+	if r.Arg < 0 {
+		return errNegativeArg
+	}
+	return nil
+}
+
+func TestTypedConn(t *testing.T) {
+	var buf bytes.Buffer
+	wc := NewTypedConn[typedResponse, typedRequest](newTestConn(nil, &buf, false), nil)
+	rc := NewTypedConn(newTestConn(&buf, nil, true), &TypedConnOptions[typedRequest, typedResponse]{
+		ValidateRead: validateRequest,
+	})
+
+	_ = wc.Write(typedRequest{Op: "double", Arg: 2})
+	_ = wc.Write(typedRequest{Op: "double", Arg: -1})
+	_ = wc.Conn().WriteMessage(TextMessage, []byte("not json"))
+	_ = wc.Write(typedRequest{Op: "double", Arg: 3})
+
+	if v, err := rc.Read(); err != nil || v != (typedRequest{Op: "double", Arg: 2}) {
+		t.Errorf("Read() = %+v, %v", v, err)
+	}
+	var de *DecodeError
+	if _, err := rc.Read(); !errors.As(err, &de) || !errors.Is(err, errNegativeArg) {
+		t.Errorf("Read() returned %v, want validation error", err)
+	}
+	if _, err := rc.Read(); !errors.As(err, &de) || de.MessageType != TextMessage {
+		t.Errorf("Read() returned %v, want decode error", err)
+	}
+	if v, err := rc.Read(); err != nil || v.Arg != 3 {
+		t.Errorf("Read() = %+v, %v", v, err)
+	}
+	if _, err := rc.Read(); errors.As(err, &de) || err == nil {
+		t.Errorf("Read() at end of input returned %v, want connection error", err)
+	}
+}
+
+func TestTypedConnValidateWrite(t *testing.T) {
+	var buf bytes.Buffer
+	wc := NewTypedConn(newTestConn(nil, &buf, false), &TypedConnOptions[typedResponse, typedRequest]{
+		ValidateWrite: validateRequest,
+	})
+	if err := wc.Write(typedRequest{Arg: -1}); err != errNegativeArg {
+		t.Errorf("Write() returned %v, want %v", err, errNegativeArg)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Write() wrote %d bytes for invalid value", buf.Len())
+	}
+}
+
+func TestTypedConnDecodeErrorAction(t *testing.T) {
+	t.Run("skip", func(t *testing.T) {
+		var buf bytes.Buffer
+		wc := newTestConn(nil, &buf, false)
+		var skipped []*DecodeError
+		rc := NewTypedConn(newTestConn(&buf, nil, true), &TypedConnOptions[typedRequest, typedResponse]{
+			ValidateRead:      validateRequest,
+			DecodeErrorAction: DecodeErrorSkip,
+			OnDecodeError:     func(err *DecodeError) { skipped = append(skipped, err) },
+		})
+		_ = wc.WriteMessage(BinaryMessage, []byte("{"))
+		_ = wc.WriteJSON(typedRequest{Arg: -1})
+		_ = wc.WriteJSON(typedRequest{Arg: 1})
+		if v, err := rc.Read(); err != nil || v.Arg != 1 {
+			t.Errorf("Read() = %+v, %v", v, err)
+		}
+		if len(skipped) != 2 || skipped[0].MessageType != BinaryMessage || !errors.Is(skipped[0], io.ErrUnexpectedEOF) ||
+			!errors.Is(skipped[1], errNegativeArg) {
+			t.Errorf("skipped = %v", skipped)
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		var in, out bytes.Buffer
+		wc := newTestConn(&out, &in, false)
+		rc := NewTypedConn(newTestConn(&in, &out, true), &TypedConnOptions[typedRequest, typedResponse]{
+			DecodeErrorAction: DecodeErrorClose,
+		})
+		_ = wc.WriteMessage(TextMessage, []byte("[]"))
+		var de *DecodeError
+		if _, err := rc.Read(); !errors.As(err, &de) {
+			t.Fatalf("Read() returned %v, want decode error", err)
+		}
+		if _, _, err := wc.ReadMessage(); !IsCloseError(err, CloseUnsupportedData) {
+			t.Errorf("peer ReadMessage() returned %v, want close error with CloseUnsupportedData", err)
+		}
+	})
+}
@@ -0,0 +1,372 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package faultconn implements a net.Conn that injects faults for testing.
+//
+// Wrap the network connection with New before it is passed to the WebSocket
+// package, for example in Dialer.NetDialContext:
+//
+//	d := websocket.Dialer{
+//		NetDialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+//			c, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+//			if err != nil {
+//				return nil, err
+//			}
+//			return faultconn.New(c, faultconn.Faults{Latency: 50 * time.Millisecond}), nil
+//		},
+//	}
+//
+// The faults can be changed at any time with SetFaults. This package does not
+// depend on the websocket package.
+package faultconn
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrInjected is the error returned from Read and Write when the injected
+// error in Faults is nil.
+var ErrInjected = errors.New("faultconn: injected fault")
+
+// ErrReset is returned from Read and Write after Reset is called.
+var ErrReset = errors.New("faultconn: connection reset")
+
+// Faults specifies the faults injected by a Conn. The zero value injects no
+// faults. Byte offsets are counted from the creation of the Conn.
+type Faults struct {
+	// Latency is the delay added before each Read and Write. The delay is
+	// cut short by the connection's deadline.
+	Latency time.Duration
+
+	// ReadBytesPerSecond and WriteBytesPerSecond limit the bandwidth in
+	// each direction. If zero, the bandwidth is not limited.
+	ReadBytesPerSecond  int
+	WriteBytesPerSecond int
+
+	// MaxReadSize limits the number of bytes returned from a single Read.
+	// Use MaxReadSize to exercise code that handles short reads. If zero,
+	// reads are not limited.
+	MaxReadSize int
+
+	// MaxWriteSize limits the number of bytes passed in a single write to
+	// the wrapped connection. Larger writes are split. If zero, writes are
+	// not split.
+	MaxWriteSize int
+
+	// ReadErrorAfter and ReadError specify an error returned from Read
+	// after ReadErrorAfter bytes are read. Bytes before the offset are
+	// returned normally. If ReadError is nil and ReadErrorAfter is not
+	// positive, no error is injected. If ReadError is nil and
+	// ReadErrorAfter is positive, ErrInjected is used.
+	ReadErrorAfter int64
+	ReadError      error
+
+	// WriteErrorAfter and WriteError specify an error returned from Write
+	// after WriteErrorAfter bytes are written. The bytes before the offset
+	// are written to the wrapped connection and counted in the return value
+	// from Write. The defaulting rules are the same as for ReadError.
+	WriteErrorAfter int64
+	WriteError      error
+
+	// TruncateWriteAfter specifies the number of bytes written to the
+	// wrapped connection before the rest of the output is silently
+	// discarded. Write reports success for discarded bytes. Use
+	// TruncateWriteAfter with Reset or a deadline to simulate a peer that
+	// stops receiving mid-frame. If zero or negative, output is not
+	// truncated.
+	TruncateWriteAfter int64
+}
+
+func (f *Faults) readError() (int64, error) {
+	return injectedError(f.ReadErrorAfter, f.ReadError)
+}
+
+func (f *Faults) writeError() (int64, error) {
+	return injectedError(f.WriteErrorAfter, f.WriteError)
+}
+
+func injectedError(after int64, err error) (int64, error) {
+	if err == nil && after > 0 {
+		err = ErrInjected
+	}
+	return after, err
+}
+
+// Conn is a net.Conn that injects faults into the wrapped connection.
+type Conn struct {
+	net.Conn
+
+	mu            sync.Mutex
+	faults        Faults
+	nread         int64
+	nwritten      int64 // bytes passed to Write, including truncated bytes
+	readDeadline  time.Time
+	writeDeadline time.Time
+	readClosed    bool
+	writeClosed   bool
+	reset         bool
+}
+
+// New returns a connection that wraps c and injects faults f.
+func New(c net.Conn, f Faults) *Conn {
+	return &Conn{Conn: c, faults: f}
+}
+
+// SetFaults replaces the faults injected by the connection. The new faults
+// apply to subsequent reads and writes.
+func (c *Conn) SetFaults(f Faults) {
+	c.mu.Lock()
+	c.faults = f
+	c.mu.Unlock()
+}
+
+// Faults returns the faults injected by the connection.
+func (c *Conn) Faults() Faults {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.faults
+}
+
+// BytesRead returns the number of bytes returned from Read.
+func (c *Conn) BytesRead() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.nread
+}
+
+// BytesWritten returns the number of bytes accepted by Write, including
+// bytes discarded by TruncateWriteAfter.
+func (c *Conn) BytesWritten() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.nwritten
+}
+
+// Read reads from the wrapped connection, injecting the read faults.
+func (c *Conn) Read(p []byte) (int, error) {
+	c.mu.Lock()
+	f := c.faults
+	nread := c.nread
+	deadline := c.readDeadline
+	closed, reset := c.readClosed, c.reset
+	c.mu.Unlock()
+
+	switch {
+	case reset:
+		return 0, ErrReset
+	case closed:
+		return 0, io.EOF
+	}
+	if err := sleep(f.Latency, deadline); err != nil {
+		return 0, err
+	}
+
+	if after, err := f.readError(); err != nil {
+		if nread >= after {
+			return 0, err
+		}
+		p = limit(p, after-nread)
+	}
+	if f.MaxReadSize > 0 {
+		p = limit(p, int64(f.MaxReadSize))
+	}
+	if f.ReadBytesPerSecond > 0 {
+		p = limit(p, int64(chunkSize(f.ReadBytesPerSecond)))
+	}
+
+	n, err := c.Conn.Read(p)
+
+	c.mu.Lock()
+	c.nread += int64(n)
+	if err != nil && c.reset {
+		// Report the reset to a read blocked when Reset was called.
+		err = ErrReset
+	}
+	c.mu.Unlock()
+
+	if err == nil && f.ReadBytesPerSecond > 0 {
+		err = sleep(transferTime(n, f.ReadBytesPerSecond), deadline)
+	}
+	return n, err
+}
+
+// Write writes to the wrapped connection, injecting the write faults.
+func (c *Conn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	deadline := c.writeDeadline
+	latency := c.faults.Latency
+	c.mu.Unlock()
+
+	if err := sleep(latency, deadline); err != nil {
+		return 0, err
+	}
+
+	nn := 0
+	for len(p) > 0 {
+		c.mu.Lock()
+		f := c.faults
+		nwritten := c.nwritten
+		closed, reset := c.writeClosed, c.reset
+		c.mu.Unlock()
+
+		switch {
+		case reset:
+			return nn, ErrReset
+		case closed:
+			return nn, &net.OpError{Op: "write", Net: "faultconn", Err: net.ErrClosed}
+		}
+
+		chunk := p
+		if after, err := f.writeError(); err != nil {
+			if nwritten >= after {
+				return nn, err
+			}
+			chunk = limit(chunk, after-nwritten)
+		}
+		if f.MaxWriteSize > 0 {
+			chunk = limit(chunk, int64(f.MaxWriteSize))
+		}
+		if f.WriteBytesPerSecond > 0 {
+			chunk = limit(chunk, int64(chunkSize(f.WriteBytesPerSecond)))
+		}
+
+		var n int
+		var err error
+		if f.TruncateWriteAfter > 0 && nwritten >= f.TruncateWriteAfter {
+			// Discard the output.
+			n = len(chunk)
+		} else {
+			if f.TruncateWriteAfter > 0 {
+				chunk = limit(chunk, f.TruncateWriteAfter-nwritten)
+			}
+			n, err = c.Conn.Write(chunk)
+		}
+
+		c.mu.Lock()
+		c.nwritten += int64(n)
+		if err != nil && c.reset {
+			err = ErrReset
+		}
+		c.mu.Unlock()
+
+		nn += n
+		p = p[n:]
+		if err != nil {
+			return nn, err
+		}
+		if f.WriteBytesPerSecond > 0 {
+			if err := sleep(transferTime(n, f.WriteBytesPerSecond), deadline); err != nil {
+				return nn, err
+			}
+		}
+	}
+	return nn, nil
+}
+
+// CloseRead simulates a peer that closed its side of the connection for
+// writing. Subsequent calls to Read return io.EOF. The wrapped connection is
+// not modified.
+func (c *Conn) CloseRead() error {
+	c.mu.Lock()
+	c.readClosed = true
+	c.mu.Unlock()
+	return nil
+}
+
+// CloseWrite shuts down the writing side of the connection. Subsequent calls
+// to Write return an error. If the wrapped connection has a CloseWrite
+// method, as *net.TCPConn and *net.UnixConn do, the method is called so that
+// the peer reads io.EOF.
+func (c *Conn) CloseWrite() error {
+	c.mu.Lock()
+	c.writeClosed = true
+	c.mu.Unlock()
+	if cw, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return cw.CloseWrite()
+	}
+	return nil
+}
+
+// Reset abruptly closes the connection. If the wrapped connection is a
+// *net.TCPConn, the connection is closed with a TCP RST. Blocked and
+// subsequent calls to Read and Write on this connection return ErrReset.
+func (c *Conn) Reset() error {
+	c.mu.Lock()
+	c.reset = true
+	c.mu.Unlock()
+	if tc, ok := c.Conn.(*net.TCPConn); ok {
+		_ = tc.SetLinger(0)
+	}
+	return c.Conn.Close()
+}
+
+// SetDeadline sets the read and write deadlines.
+func (c *Conn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.readDeadline = t
+	c.writeDeadline = t
+	c.mu.Unlock()
+	return c.Conn.SetDeadline(t)
+}
+
+// SetReadDeadline sets the read deadline.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.readDeadline = t
+	c.mu.Unlock()
+	return c.Conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the write deadline.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.writeDeadline = t
+	c.mu.Unlock()
+	return c.Conn.SetWriteDeadline(t)
+}
+
+// sleep pauses for d or until the deadline, whichever is earlier. The
+// function returns os.ErrDeadlineExceeded if the deadline is reached.
+func sleep(d time.Duration, deadline time.Time) error {
+	if d <= 0 {
+		return nil
+	}
+	if !deadline.IsZero() {
+		if remaining := time.Until(deadline); remaining < d {
+			if remaining > 0 {
+				time.Sleep(remaining)
+			}
+			return os.ErrDeadlineExceeded
+		}
+	}
+	time.Sleep(d)
+	return nil
+}
+
+// chunkSize returns the number of bytes transferred at a time for the
+// bandwidth limit bps. Transfers are split so that each chunk takes about
+// 10ms.
+func chunkSize(bps int) int {
+	n := bps / 100
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+func transferTime(n, bps int) time.Duration {
+	return time.Duration(n) * time.Second / time.Duration(bps)
+}
+
+func limit(p []byte, n int64) []byte {
+	if n < int64(len(p)) {
+		return p[:n]
+	}
+	return p
+}
@@ -0,0 +1,196 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package faultconn
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// newPipe returns a fault connection wrapping one end of a pipe and the
+// other end of the pipe.
+func newPipe(f Faults) (*Conn, net.Conn) {
+	a, b := net.Pipe()
+	return New(a, f), b
+}
+
+func TestReadFaults(t *testing.T) {
+	errTest := errors.New("test")
+	c, peer := newPipe(Faults{MaxReadSize: 3, ReadErrorAfter: 7, ReadError: errTest})
+	defer c.Close()
+	go func() {
+		peer.Write([]byte("hello world"))
+		peer.Close()
+	}()
+
+	var got []int
+	var p [16]byte
+	for {
+		n, err := c.Read(p[:])
+		if err != nil {
+			if err != errTest {
+				t.Errorf("Read() returned %v, want %v", err, errTest)
+			}
+			break
+		}
+		got = append(got, n)
+	}
+	if want := []int{3, 3, 1}; !equalInts(got, want) {
+		t.Errorf("Read sizes = %v, want %v", got, want)
+	}
+	if n := c.BytesRead(); n != 7 {
+		t.Errorf("BytesRead() = %d, want 7", n)
+	}
+
+	c.SetFaults(Faults{})
+	if n, err := c.Read(p[:]); err != nil || string(p[:n]) != "orld" {
+		t.Errorf("Read() after SetFaults = %q, %v, want %q, nil", p[:n], err, "orld")
+	}
+}
+
+func TestWriteFaults(t *testing.T) {
+	c, peer := newPipe(Faults{MaxWriteSize: 2, WriteErrorAfter: 5})
+	defer c.Close()
+	done := make(chan []byte)
+	go func() {
+		p, _ := io.ReadAll(peer)
+		done <- p
+	}()
+
+	n, err := c.Write([]byte("hello world"))
+	if n != 5 || err != ErrInjected {
+		t.Errorf("Write() = %d, %v, want 5, %v", n, err, ErrInjected)
+	}
+	c.Close()
+	if p := <-done; string(p) != "hello" {
+		t.Errorf("peer read %q, want %q", p, "hello")
+	}
+}
+
+func TestTruncateWrite(t *testing.T) {
+	c, peer := newPipe(Faults{TruncateWriteAfter: 4})
+	done := make(chan []byte)
+	go func() {
+		p, _ := io.ReadAll(peer)
+		done <- p
+	}()
+
+	for _, s := range []string{"hel", "lo", " world"} {
+		if n, err := c.Write([]byte(s)); n != len(s) || err != nil {
+			t.Errorf("Write(%q) = %d, %v, want %d, nil", s, n, err, len(s))
+		}
+	}
+	if n := c.BytesWritten(); n != 11 {
+		t.Errorf("BytesWritten() = %d, want 11", n)
+	}
+	c.Close()
+	if p := <-done; string(p) != "hell" {
+		t.Errorf("peer read %q, want %q", p, "hell")
+	}
+}
+
+func TestLatencyDeadline(t *testing.T) {
+	c, peer := newPipe(Faults{Latency: time.Hour})
+	defer c.Close()
+	defer peer.Close()
+
+	c.SetDeadline(time.Now().Add(10 * time.Millisecond))
+	var p [1]byte
+	if _, err := c.Read(p[:]); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("Read() returned %v, want %v", err, os.ErrDeadlineExceeded)
+	}
+	if _, err := c.Write(p[:]); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("Write() returned %v, want %v", err, os.ErrDeadlineExceeded)
+	}
+}
+
+func TestBandwidth(t *testing.T) {
+	c, peer := newPipe(Faults{WriteBytesPerSecond: 1000})
+	defer c.Close()
+	go io.Copy(io.Discard, peer)
+
+	// 100 bytes at 1000 bytes per second takes 100ms. The deadline fires
+	// while the write is in progress.
+	c.SetWriteDeadline(time.Now().Add(30 * time.Millisecond))
+	n, err := c.Write(bytes.Repeat([]byte("x"), 100))
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Errorf("Write() returned %v, want timeout", err)
+	}
+	if n == 0 || n == 100 {
+		t.Errorf("Write() returned n = %d, want partial write", n)
+	}
+}
+
+func TestHalfCloseAndReset(t *testing.T) {
+	c, peer := newPipe(Faults{})
+	defer peer.Close()
+
+	c.CloseRead()
+	var p [1]byte
+	if _, err := c.Read(p[:]); err != io.EOF {
+		t.Errorf("Read() after CloseRead returned %v, want %v", err, io.EOF)
+	}
+	c.CloseWrite()
+	if _, err := c.Write(p[:]); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Write() after CloseWrite returned %v, want %v", err, net.ErrClosed)
+	}
+	c.Reset()
+	if _, err := c.Read(p[:]); err != ErrReset {
+		t.Errorf("Read() after Reset returned %v, want %v", err, ErrReset)
+	}
+	if _, err := peer.Read(p[:]); err != io.EOF {
+		t.Errorf("peer Read() after Reset returned %v, want %v", err, io.EOF)
+	}
+}
+
+func TestTCPHalfClose(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("listen: %v", err)
+	}
+	defer l.Close()
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		// Echo until EOF.
+		io.Copy(c, c)
+	}()
+
+	nc, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New(nc, Faults{})
+	defer c.Close()
+	c.Write([]byte("hello"))
+	if err := c.CloseWrite(); err != nil {
+		t.Fatalf("CloseWrite() returned %v", err)
+	}
+	p, err := io.ReadAll(c)
+	if err != nil || string(p) != "hello" {
+		t.Errorf("ReadAll() = %q, %v, want %q, nil", p, err, "hello")
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,173 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package wstest provides utilities for testing WebSocket applications
+// without a network.
+//
+// Connections created by this package are connected with net.Pipe. The
+// opening handshake is performed by the websocket package's Dialer and
+// Upgrader, so subprotocol and compression negotiation work as they do over
+// the network.
+package wstest
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultURL is the URL used by Pair when PairOptions.URL is empty.
+const DefaultURL = "ws://wstest/"
+
+// Server is an HTTP server that accepts connections from in-memory dialers.
+type Server struct {
+	srv *http.Server
+	l   *pipeListener
+}
+
+// NewServer starts and returns a new server that serves HTTP requests with
+// handler. The caller should call Close when finished to shut it down.
+func NewServer(handler http.Handler) *Server {
+	s := &Server{
+		srv: &http.Server{Handler: handler},
+		l:   &pipeListener{conns: make(chan net.Conn), done: make(chan struct{})},
+	}
+	go s.srv.Serve(s.l)
+	return s
+}
+
+// DialContext returns the client end of a new in-memory connection to the
+// server. The network and address arguments are ignored. The function has
+// the signature of websocket.Dialer.NetDialContext.
+func (s *Server) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	client, server := net.Pipe()
+	select {
+	case s.l.conns <- server:
+		return client, nil
+	case <-s.l.done:
+		client.Close()
+		server.Close()
+		return nil, &net.OpError{Op: "dial", Net: pipeNetwork, Err: net.ErrClosed}
+	case <-ctx.Done():
+		client.Close()
+		server.Close()
+		return nil, ctx.Err()
+	}
+}
+
+// Dialer returns a copy of d that connects to the server. If d is nil, the
+// returned dialer has default values for all other fields. The URL passed to
+// the dialer must use the ws scheme; the host in the URL sets the Host
+// header in the request.
+func (s *Server) Dialer(d *websocket.Dialer) *websocket.Dialer {
+	var dialer websocket.Dialer
+	if d != nil {
+		dialer = *d
+	}
+	dialer.Proxy = nil
+	dialer.NetDial = nil
+	dialer.NetDialTLSContext = nil
+	dialer.NetDialContext = s.DialContext
+	return &dialer
+}
+
+// Close stops the server. Connections returned from Upgrader.Upgrade are
+// not closed.
+func (s *Server) Close() error {
+	return s.srv.Close()
+}
+
+// PairOptions specifies options for creating a connection pair. The zero
+// value is a pair with no subprotocol and no compression.
+type PairOptions struct {
+	// Dialer specifies options for the client side of the handshake. The
+	// dial functions and proxy are replaced. If nil, a zero Dialer is used.
+	Dialer *websocket.Dialer
+
+	// Upgrader specifies options for the server side of the handshake. If
+	// nil, a zero Upgrader is used.
+	Upgrader *websocket.Upgrader
+
+	// URL is the URL passed to the dialer. If empty, DefaultURL is used.
+	URL string
+
+	// Header is the request header passed to the dialer.
+	Header http.Header
+}
+
+// Pair returns a connected client and server connection. The opening
+// handshake is run through the dialer and upgrader specified in opts. If
+// opts is nil, default options are used. If the handshake fails, Pair
+// returns the error from the dialer.
+func Pair(opts *PairOptions) (client, server *websocket.Conn, err error) {
+	if opts == nil {
+		opts = &PairOptions{}
+	}
+	upgrader := opts.Upgrader
+	if upgrader == nil {
+		upgrader = &websocket.Upgrader{}
+	}
+	u := opts.URL
+	if u == "" {
+		u = DefaultURL
+	}
+
+	type result struct {
+		c   *websocket.Conn
+		err error
+	}
+	ch := make(chan result, 1)
+	s := NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		ch <- result{c, err}
+	}))
+	defer s.Close()
+
+	client, _, err = s.Dialer(opts.Dialer).Dial(u, opts.Header)
+	if err != nil {
+		return nil, nil, err
+	}
+	res := <-ch
+	if res.err != nil {
+		client.Close()
+		return nil, nil, res.err
+	}
+	return client, res.c, nil
+}
+
+const pipeNetwork = "pipe"
+
+// pipeListener is a net.Listener for connections created by
+// Server.DialContext.
+type pipeListener struct {
+	conns     chan net.Conn
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.done:
+		return nil, &net.OpError{Op: "accept", Net: pipeNetwork, Err: net.ErrClosed}
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return pipeAddr{}
+}
+
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string { return pipeNetwork }
+func (pipeAddr) String() string  { return pipeNetwork }
@@ -0,0 +1,127 @@
+// Copyright 2026 The Gorilla WebSocket Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wstest
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func echo(t *testing.T, from, to *websocket.Conn, message []byte) {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- from.WriteMessage(websocket.BinaryMessage, message) }()
+	_, p, err := to.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage() returned %v", err)
+	}
+	if !bytes.Equal(p, message) {
+		t.Errorf("ReadMessage() = %q, want %q", p, message)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("WriteMessage() returned %v", err)
+	}
+}
+
+func TestPair(t *testing.T) {
+	client, server, err := Pair(nil)
+	if err != nil {
+		t.Fatalf("Pair() returned %v", err)
+	}
+	defer client.Close()
+	defer server.Close()
+
+	echo(t, client, server, []byte("hello"))
+	echo(t, server, client, []byte("world"))
+}
+
+func TestPairOptions(t *testing.T) {
+	var host string
+	client, server, err := Pair(&PairOptions{
+		Dialer: &websocket.Dialer{Subprotocols: []string{"p1", "p2"}, EnableCompression: true},
+		Upgrader: &websocket.Upgrader{
+			Subprotocols:      []string{"p2"},
+			EnableCompression: true,
+			CheckOrigin: func(r *http.Request) bool {
+				host = r.Host
+				return true
+			},
+		},
+		URL:    "ws://example.com/path",
+		Header: http.Header{"Origin": {"http://other.example.com"}},
+	})
+	if err != nil {
+		t.Fatalf("Pair() returned %v", err)
+	}
+	defer client.Close()
+	defer server.Close()
+
+	if client.Subprotocol() != "p2" || server.Subprotocol() != "p2" {
+		t.Errorf("Subprotocol() = %q, %q, want p2", client.Subprotocol(), server.Subprotocol())
+	}
+	if host != "example.com" {
+		t.Errorf("Host = %q, want example.com", host)
+	}
+	echo(t, client, server, bytes.Repeat([]byte("hello"), 1000))
+}
+
+func TestPairHandshakeError(t *testing.T) {
+	_, _, err := Pair(&PairOptions{Header: http.Header{"Origin": {"http://other.example.com"}}})
+	if !errors.Is(err, websocket.ErrBadHandshake) {
+		t.Errorf("Pair() returned %v, want %v", err, websocket.ErrBadHandshake)
+	}
+}
+
+func TestServer(t *testing.T) {
+	var upgrader websocket.Upgrader
+	s := NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ws" {
+			http.NotFound(w, r)
+			return
+		}
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		for {
+			mt, p, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := c.WriteMessage(mt, p); err != nil {
+				return
+			}
+		}
+	}))
+
+	d := s.Dialer(nil)
+	c, _, err := d.Dial("ws://wstest/ws", nil)
+	if err != nil {
+		t.Fatalf("Dial() returned %v", err)
+	}
+	if err := c.WriteMessage(websocket.TextMessage, []byte("hello")); err != nil {
+		t.Fatalf("WriteMessage() returned %v", err)
+	}
+	if _, p, err := c.ReadMessage(); err != nil || string(p) != "hello" {
+		t.Errorf("ReadMessage() = %q, %v, want hello, nil", p, err)
+	}
+	c.Close()
+
+	_, resp, err := d.Dial("ws://wstest/other", nil)
+	if !errors.Is(err, websocket.ErrBadHandshake) || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Dial() to unknown path returned %v, want bad handshake with status 404", err)
+	}
+
+	s.Close()
+	if _, _, err := d.Dial("ws://wstest/ws", nil); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Dial() after Close returned %v, want %v", err, net.ErrClosed)
+	}
+}